@@ -0,0 +1,331 @@
+// Package asm assembles CHIP-8 programs written in the mnemonic dialect
+// produced by the disassembler.
+//
+// Each line holds an optional label, then an instruction, directive, or
+// constant definition, then an optional comment starting with ';':
+//
+//	start:  MVI     V0,#$05      ; set V0 to 5
+//	        SPRITE. V0,V1,#$5
+//	        JUMP    start
+//	speed = 3
+//	sprite: db      $f0,$90,%11110000
+//	table:  dw      start,$0200
+//
+// Numbers are decimal, or hex with a $ or 0x prefix, or binary with a % or 0b
+// prefix, and immediates may be prefixed with #. Anywhere a number is
+// accepted a label, a constant, or a sum of them can be used instead.
+// Constants are defined with "name = value" or "name equ value", and the org
+// directive moves the address the following statements are assembled at.
+//
+// Lines of disassembler output are accepted as is, the address and opcode
+// columns at the start of each line are ignored, so a disassembled ROM can
+// be assembled back into the same bytes.
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+// programStart is the address programs are assembled at by default.
+const programStart = 0x200
+
+// constant is a named expression. It is evaluated the first time it is used
+// so it can refer to labels defined after it.
+type constant struct {
+	line  int
+	expr  arg
+	value int
+	state constantState
+}
+
+type constantState int
+
+const (
+	unevaluated constantState = iota
+	evaluating
+	evaluated
+	invalid
+)
+
+type assembler struct {
+	labels    map[string]int
+	constants map[string]*constant
+	errs      ErrorList
+}
+
+// Assemble reads the source and returns the assembled program. If the source
+// has mistakes the returned error is an ErrorList of all of them.
+func Assemble(src io.Reader) ([]byte, error) {
+	var statements []*statement
+	a := &assembler{
+		labels:    map[string]int{},
+		constants: map[string]*constant{},
+	}
+
+	scanner := bufio.NewScanner(src)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		s, err := parseLine(lineNum, scanner.Text())
+		if err != nil {
+			a.errs = append(a.errs, err)
+			continue
+		}
+		if s != nil {
+			statements = append(statements, s)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read source")
+	}
+
+	// first pass: lay out the program to find the address of each label
+	pc := programStart
+	for _, s := range statements {
+		pc = a.layout(s, pc)
+	}
+
+	// second pass: encode each statement now every label is known
+	program := make([]byte, 0, pc-programStart)
+	pc = programStart
+	for _, s := range statements {
+		program, pc = a.encode(s, program, pc)
+	}
+
+	// report mistakes in constants that were never used
+	for _, s := range statements {
+		if s.constant != "" {
+			a.resolve(s.constant)
+		}
+	}
+
+	if len(a.errs) > 0 {
+		sort.SliceStable(a.errs, func(i, j int) bool {
+			if a.errs[i].Line != a.errs[j].Line {
+				return a.errs[i].Line < a.errs[j].Line
+			}
+			return a.errs[i].Column < a.errs[j].Column
+		})
+		return nil, a.errs
+	}
+	return program, nil
+}
+
+// layout defines the statement's label or constant and returns the address
+// of the next statement.
+func (a *assembler) layout(s *statement, pc int) int {
+	if s.label != "" && a.checkUndefined(s.label, s.line, s.labelCol) {
+		a.labels[s.label] = pc
+	}
+
+	switch {
+	case s.constant != "":
+		if len(s.args) != 1 {
+			a.errorf(s.line, s.col, "constant %s needs exactly one value", s.constant)
+			return pc
+		}
+		if a.checkUndefined(s.constant, s.line, s.col) {
+			a.constants[s.constant] = &constant{line: s.line, expr: s.args[0]}
+		}
+		return pc
+	case s.mnemonic == "":
+		return pc
+	case s.mnemonic == "ORG":
+		if len(s.args) != 1 {
+			a.errorf(s.line, s.col, "org needs exactly one address")
+			return pc
+		}
+		v, ok := a.evaluate(s.line, s.args[0])
+		if !ok {
+			return pc
+		}
+		if v < pc {
+			a.errorf(s.line, s.args[0].col, "org $%03x is before the current address $%03x", v, pc)
+			return pc
+		}
+		s.org = v
+		return v
+	case s.mnemonic == "DB" || s.mnemonic == "DW":
+		if len(s.args) == 0 {
+			a.errorf(s.line, s.col, "%s needs at least one value", strings.ToLower(s.mnemonic))
+		}
+		if s.mnemonic == "DW" {
+			return pc + 2*len(s.args)
+		}
+		return pc + len(s.args)
+	}
+	if forms, ok := instructions[s.mnemonic]; ok {
+		return pc + forms[0].mnemonic.Length()
+	}
+	return pc + 2
+}
+
+// encode appends the bytes of the statement to the program and returns the
+// address of the next statement.
+func (a *assembler) encode(s *statement, program []byte, pc int) ([]byte, int) {
+	switch {
+	case s.constant != "" || s.mnemonic == "":
+		return program, pc
+	case s.mnemonic == "ORG":
+		if s.org < pc {
+			return program, pc
+		}
+		return append(program, make([]byte, s.org-pc)...), s.org
+	case s.mnemonic == "DB":
+		for _, arg := range s.args {
+			v, _ := a.value(s.line, arg, byte8)
+			program = append(program, byte(v))
+		}
+		return program, pc + len(s.args)
+	case s.mnemonic == "DW":
+		for _, arg := range s.args {
+			v, _ := a.value(s.line, arg, word)
+			program = append(program, byte(v>>8), byte(v))
+		}
+		return program, pc + 2*len(s.args)
+	}
+
+	in, ok := a.instruction(s)
+	if !ok {
+		// keep the address in step with the first pass
+		if forms, ok := instructions[s.mnemonic]; ok {
+			in.Mnemonic = forms[0].mnemonic
+		}
+	}
+	opcode := in.Encode()
+	if in.Mnemonic.Length() == 4 {
+		return append(program, byte(opcode>>8), byte(opcode), byte(in.Operand>>8), byte(in.Operand)), pc + 4
+	}
+	return append(program, byte(opcode>>8), byte(opcode)), pc + 2
+}
+
+// instruction decodes an instruction statement into the instruction it
+// assembles to.
+func (a *assembler) instruction(s *statement) (cpu.DecodedInstruction, bool) {
+	var in cpu.DecodedInstruction
+	forms, ok := instructions[s.mnemonic]
+	if !ok {
+		a.errorf(s.line, s.col, "unknown mnemonic %s", s.mnemonic)
+		return in, false
+	}
+
+	operands := make([]operand, len(s.args))
+	for i, arg := range s.args {
+		if arg.text == "" {
+			a.errorf(s.line, arg.col, "missing operand")
+			return in, false
+		}
+		operands[i] = parseOperand(arg)
+	}
+
+	f, ok := matchForm(forms, operands)
+	if !ok {
+		texts := make([]string, len(s.args))
+		for i, arg := range s.args {
+			texts[i] = arg.text
+		}
+		a.errorf(s.line, s.col, "invalid operands for %s: %s", s.mnemonic, strings.Join(texts, ","))
+		return in, false
+	}
+
+	values := make([]uint16, len(operands))
+	for i, o := range operands {
+		switch o.kind {
+		case operandReg:
+			values[i] = uint16(o.reg)
+		case operandRange:
+			values[i] = uint16(o.first)<<4 | uint16(o.reg)
+		case operandValue, operandIndexed:
+			v, ok := a.value(s.line, arg{text: o.expr, col: o.col}, f.slots[i].width)
+			if !ok {
+				return in, false
+			}
+			values[i] = v
+		}
+	}
+	in.Mnemonic = f.mnemonic
+	f.operands(&in, values)
+	return in, true
+}
+
+// value evaluates an expression that must fit in width bits.
+func (a *assembler) value(line int, ar arg, width valueWidth) (uint16, bool) {
+	v, ok := a.evaluate(line, ar)
+	if !ok {
+		return 0, false
+	}
+	// negative bytes are accepted as their two's complement
+	if v < 0 && width == byte8 && v >= -0x80 {
+		v += 0x100
+	}
+	if v < 0 || v >= 1<<width {
+		a.errorf(line, ar.col, "value %s out of range for %d bits", ar.text, width)
+		return 0, false
+	}
+	return uint16(v), true
+}
+
+func (a *assembler) evaluate(line int, ar arg) (int, bool) {
+	v, ok, msg := evaluate(strings.TrimPrefix(ar.text, "#"), a.resolve)
+	if !ok {
+		if msg != "" {
+			a.errorf(line, ar.col, "%s", msg)
+		}
+		return 0, false
+	}
+	return v, true
+}
+
+// resolve looks up the value of a label or constant, evaluating constants
+// as needed. Mistakes in a constant are reported once, at its definition.
+func (a *assembler) resolve(name string) (int, bool, string) {
+	if v, ok := a.labels[name]; ok {
+		return v, true, ""
+	}
+	c, ok := a.constants[name]
+	if !ok {
+		return 0, false, "undefined symbol " + strconv.Quote(name)
+	}
+
+	switch c.state {
+	case evaluated:
+		return c.value, true, ""
+	case invalid:
+		return 0, false, ""
+	case evaluating:
+		c.state = invalid
+		a.errorf(c.line, c.expr.col, "constant %s is defined in terms of itself", name)
+		return 0, false, ""
+	}
+
+	c.state = evaluating
+	v, ok := a.evaluate(c.line, c.expr)
+	if !ok {
+		c.state = invalid
+		return 0, false, ""
+	}
+	c.value, c.state = v, evaluated
+	return v, true, ""
+}
+
+// checkUndefined reports an error if name is already a label or constant.
+func (a *assembler) checkUndefined(name string, line, col int) bool {
+	_, isLabel := a.labels[name]
+	_, isConstant := a.constants[name]
+	if isLabel || isConstant {
+		a.errorf(line, col, "%s is already defined", name)
+		return false
+	}
+	return true
+}
+
+func (a *assembler) errorf(line, col int, format string, args ...interface{}) {
+	a.errs = append(a.errs, &Error{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,204 @@
+package asm_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"chip-8/internal/asm"
+	"chip-8/internal/cpu"
+	"chip-8/internal/rom"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssemble_RoundTrip(t *testing.T) {
+	src, err := ioutil.ReadFile("../../test/fixtures/test_opcode.asm")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("../../test/roms/test_opcode.ch8")
+	require.NoError(t, err)
+
+	program, err := asm.Assemble(bytes.NewReader(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, program)
+}
+
+func TestAssemble_RecursiveDisassembly(t *testing.T) {
+	src, err := ioutil.ReadFile("../../test/fixtures/test_opcode_recursive.asm")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("../../test/roms/test_opcode.ch8")
+	require.NoError(t, err)
+
+	program, err := asm.Assemble(bytes.NewReader(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, program)
+}
+
+func TestAssemble_Disassemble(t *testing.T) {
+	var opcodes []byte
+	for _, op := range []uint16{
+		0x00e0, 0x00ee, 0x1234, 0x2345, 0x3a12, 0x4b34, 0x5ab0, 0x6c56, 0x7d78,
+		0x8120, 0x8121, 0x8122, 0x8123, 0x8124, 0x8125, 0x8116, 0x8127, 0x811e,
+		0x9ab0, 0xa123, 0xb456, 0xc7ff, 0xd12f, 0xe39e, 0xe4a1, 0xf507, 0xf60a,
+		0xf715, 0xf818, 0xf91e, 0xfa29, 0xfb33, 0xfc55, 0xfd65, 0xe000,
+		0x00c3, 0x00fb, 0x00fc, 0x00fd, 0x00fe, 0x00ff, 0xd120, 0xfe30, 0xf775,
+		0xf785, 0x00d2, 0x5362, 0x5633, 0xf301, 0xf002, 0xf43a, 0xf000, 0x1234,
+	} {
+		opcodes = append(opcodes, byte(op>>8), byte(op))
+	}
+
+	disassembled, err := rom.Disassemble(bytes.NewReader(opcodes), cpu.Classic)
+	require.NoError(t, err)
+
+	program, err := asm.Assemble(disassembled)
+	require.NoError(t, err)
+
+	assert.Equal(t, opcodes, program)
+}
+
+func TestAssemble_DisassembleEveryOpcode(t *testing.T) {
+	for op := 0; op <= 0xffff; op++ {
+		opcode := []byte{byte(op >> 8), byte(op)}
+
+		disassembled, err := rom.Disassemble(bytes.NewReader(opcode), cpu.Classic)
+		require.NoError(t, err)
+
+		program, err := asm.Assemble(disassembled)
+		require.NoError(t, err, "%04x", op)
+
+		require.Equal(t, opcode, program[:2], "%04x", op)
+	}
+}
+
+func TestAssemble_DisassembleShifts(t *testing.T) {
+	var opcodes []byte
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			opcodes = append(opcodes, byte(0x80|x), byte(y<<4|0x6), byte(0x80|x), byte(y<<4|0xe))
+		}
+	}
+	// the shifts fall through into one another, so a recursive disassembly
+	// decodes them all as code before the loop at the end
+	opcodes = append(opcodes, 0x16, 0x00)
+
+	for _, disassemble := range []func(io.Reader, cpu.Syntax) (io.Reader, error){
+		rom.Disassemble,
+		rom.DisassembleRecursive,
+	} {
+		disassembled, err := disassemble(bytes.NewReader(opcodes), cpu.Classic)
+		require.NoError(t, err)
+
+		program, err := asm.Assemble(disassembled)
+		require.NoError(t, err)
+
+		assert.Equal(t, opcodes, program)
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	src := `
+; draws a sprite then loops forever
+SPEED = 3
+OFFSET equ sprite - start
+
+start:  MVI     V0,#SPEED     ; x
+        mvi     v1, 10
+        MVI     I,sprite
+        SPRITE. V0,V1,#5
+        JUMP    loop
+loop:   JUMP    loop
+        JUMP    table(V0)
+        ADI     V2,-1
+        ADI     V3,#OFFSET
+sprite: db      $f0, 0x90, %10010000, 0b11110000, 144
+table:  dw      start, $0abc
+        org     $220
+end:    UNK     0x1234
+        CALL    end+2
+`
+	expected := []byte{
+		0x60, 0x03,
+		0x61, 0x0a,
+		0xa2, 0x12,
+		0xd0, 0x15,
+		0x12, 0x0a,
+		0x12, 0x0a,
+		0xb2, 0x17,
+		0x72, 0xff,
+		0x73, 0x12,
+		0xf0, 0x90, 0x90, 0xf0, 0x90,
+		0x02, 0x00, 0x0a, 0xbc,
+		0x00, 0x00, 0x00, 0x00, 0x00,
+		0x12, 0x34,
+		0x22, 0x22,
+	}
+
+	program, err := asm.Assemble(strings.NewReader(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, program)
+}
+
+func TestAssemble_LongInstruction(t *testing.T) {
+	src := `
+        MVIL    I,data
+        MOVR    (I),V1-V2
+        JUMP    data
+data:   db      1, 2
+`
+	expected := []byte{
+		0xf0, 0x00, 0x02, 0x08,
+		0x51, 0x22,
+		0x12, 0x08,
+		0x01, 0x02,
+	}
+
+	program, err := asm.Assemble(strings.NewReader(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, program)
+}
+
+func TestAssemble_Errors(t *testing.T) {
+	src := strings.Join([]string{
+		"start:  MVI V0,#$100",
+		"        FOO V1",
+		"        JUMP nowhere",
+		"        SPRITE. V0,V1",
+		"start:  CLS",
+		"        db",
+		"V3:     CLS",
+		"        MVI V0,#$1g",
+		"        org $100",
+		"LOOP  = LOOP+1",
+	}, "\n")
+
+	_, err := asm.Assemble(strings.NewReader(src))
+	require.Error(t, err)
+
+	errs, ok := err.(asm.ErrorList)
+	require.True(t, ok, "expected asm.ErrorList, got %T", err)
+
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		`1:16: value $100 out of range for 8 bits`,
+		`2:9: unknown mnemonic FOO`,
+		`3:14: undefined symbol "nowhere"`,
+		`4:9: invalid operands for SPRITE.: V0,V1`,
+		`5:1: start is already defined`,
+		`6:9: db needs at least one value`,
+		`7:1: invalid label "V3"`,
+		`8:16: invalid number "$1g"`,
+		`9:13: org $100 is before the current address $20c`,
+		`10:9: constant LOOP is defined in terms of itself`,
+	}, messages)
+	assert.Equal(t, `1:16: value $100 out of range for 8 bits (and 9 more errors)`, err.Error())
+}
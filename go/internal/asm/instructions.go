@@ -0,0 +1,191 @@
+package asm
+
+import "chip-8/internal/cpu"
+
+// valueWidth restricts an operandValue or operandIndexed to a number of bits.
+type valueWidth uint
+
+const (
+	nibble  valueWidth = 4
+	byte8   valueWidth = 8
+	address valueWidth = 12
+	word    valueWidth = 16
+)
+
+// slot is an operand an instruction form accepts.
+type slot struct {
+	kind  operandKind
+	width valueWidth
+	// fromV0 restricts an operandRange to ranges starting at V0.
+	fromV0 bool
+}
+
+var (
+	reg        = slot{kind: operandReg}
+	regI       = slot{kind: operandI}
+	indirectI  = slot{kind: operandIndirectI}
+	delayTimer = slot{kind: operandDelay}
+	soundTimer = slot{kind: operandSound}
+	rplFlags   = slot{kind: operandRPL}
+	regRange   = slot{kind: operandRange, fromV0: true}
+	regSpan    = slot{kind: operandRange}
+	addr       = slot{kind: operandValue, width: address}
+	addrV0     = slot{kind: operandIndexed, width: address}
+	imm8       = slot{kind: operandValue, width: byte8}
+	imm4       = slot{kind: operandValue, width: nibble}
+	imm16      = slot{kind: operandValue, width: word}
+)
+
+// form is one way of writing an instruction. operands fills in the operands
+// of the instruction from the value of each slot: the register number for
+// registers, the first register number in the high nibble and the last in
+// the low nibble for register ranges, and the evaluated expression for
+// values.
+type form struct {
+	slots    []slot
+	mnemonic cpu.Mnemonic
+	operands func(in *cpu.DecodedInstruction, v []uint16)
+}
+
+func fixed(m cpu.Mnemonic) []form {
+	return []form{{mnemonic: m, operands: func(*cpu.DecodedInstruction, []uint16) {}}}
+}
+
+func x(m cpu.Mnemonic) form {
+	return form{slots: []slot{reg}, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.X = byte(v[0])
+	}}
+}
+
+func xy(m cpu.Mnemonic) form {
+	return form{slots: []slot{reg, reg}, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.X, in.Y = byte(v[0]), byte(v[1])
+	}}
+}
+
+func xkk(m cpu.Mnemonic) form {
+	return form{slots: []slot{reg, imm8}, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.X, in.KK = byte(v[0]), byte(v[1])
+	}}
+}
+
+func n(m cpu.Mnemonic) form {
+	return form{slots: []slot{imm4}, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.N = byte(v[0])
+	}}
+}
+
+func nnn(m cpu.Mnemonic, s slot) form {
+	return form{slots: []slot{s}, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.NNN = v[0]
+	}}
+}
+
+// xx encodes a shift written with only Vx, which the disassembler emits for
+// shifts, by using Vx for Vy as well.
+func xx(m cpu.Mnemonic) form {
+	return form{slots: []slot{reg}, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.X, in.Y = byte(v[0]), byte(v[0])
+	}}
+}
+
+// slotX is a form whose only operand is the register in slot i of slots.
+func slotX(m cpu.Mnemonic, i int, slots ...slot) form {
+	return form{slots: slots, mnemonic: m, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+		in.X = byte(v[i])
+	}}
+}
+
+// instructions maps each mnemonic of the disassembler's dialect to the forms
+// it can be written in.
+var instructions = map[string][]form{
+	"CLS":       fixed(cpu.Op00E0),
+	"RTS":       fixed(cpu.Op00EE),
+	"SCROLL.DN": {n(cpu.Op00Cn)},
+	"SCROLL.RT": fixed(cpu.Op00FB),
+	"SCROLL.LT": fixed(cpu.Op00FC),
+	"EXIT":      fixed(cpu.Op00FD),
+	"LORES":     fixed(cpu.Op00FE),
+	"HIRES":     fixed(cpu.Op00FF),
+	"SCROLL.UP": {n(cpu.Op00Dn)},
+	"JUMP":      {nnn(cpu.Op1nnn, addr), nnn(cpu.OpBnnn, addrV0)},
+	"CALL":      {nnn(cpu.Op2nnn, addr)},
+	"SKIP.EQ":   {xkk(cpu.Op3xkk), xy(cpu.Op5xy0)},
+	"SKIP.NE":   {xkk(cpu.Op4xkk), xy(cpu.Op9xy0)},
+	"MVI": {
+		xkk(cpu.Op6xkk),
+		{slots: []slot{regI, addr}, mnemonic: cpu.OpAnnn, operands: func(in *cpu.DecodedInstruction, v []uint16) { in.NNN = v[1] }},
+	},
+	"MVIL": {
+		{slots: []slot{regI, imm16}, mnemonic: cpu.OpF000, operands: func(in *cpu.DecodedInstruction, v []uint16) { in.Operand = v[1] }},
+	},
+	"ADI": {xkk(cpu.Op7xkk)},
+	"MOV": {
+		xy(cpu.Op8xy0),
+		slotX(cpu.OpFx07, 0, reg, delayTimer),
+		slotX(cpu.OpFx15, 1, delayTimer, reg),
+		slotX(cpu.OpFx18, 1, soundTimer, reg),
+	},
+	"OR":         {xy(cpu.Op8xy1)},
+	"AND":        {xy(cpu.Op8xy2)},
+	"XOR":        {xy(cpu.Op8xy3)},
+	"ADD.":       {xy(cpu.Op8xy4)},
+	"SUB.":       {xy(cpu.Op8xy5)},
+	"SHR.":       {xx(cpu.Op8xy6), xy(cpu.Op8xy6)},
+	"SUBB.":      {xy(cpu.Op8xy7)},
+	"SHL.":       {xx(cpu.Op8xyE), xy(cpu.Op8xyE)},
+	"RND":        {xkk(cpu.OpCxkk)},
+	"SKIP.KEY":   {x(cpu.OpEx9E)},
+	"SKIP.NOKEY": {x(cpu.OpExA1)},
+	"SPRITE.": {
+		{slots: []slot{reg, reg, imm4}, mnemonic: cpu.OpDxyn, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+			in.X, in.Y, in.N = byte(v[0]), byte(v[1]), byte(v[2])
+		}},
+	},
+	"WAITKEY":    {x(cpu.OpFx0A)},
+	"ADD":        {slotX(cpu.OpFx1E, 1, regI, reg)},
+	"SPRITECHAR": {x(cpu.OpFx29)},
+	"SPRITEBIG":  {x(cpu.OpFx30)},
+	"MOVBCD":     {x(cpu.OpFx33)},
+	"PLANE":      {slotX(cpu.OpFn01, 0, imm4)},
+	"AUDIO":      fixed(cpu.OpF002),
+	"PITCH":      {x(cpu.OpFx3A)},
+	"MOVM": {
+		slotX(cpu.OpFx55, 1, indirectI, regRange),
+		slotX(cpu.OpFx65, 0, regRange, indirectI),
+		slotX(cpu.OpFx75, 1, rplFlags, regRange),
+		slotX(cpu.OpFx85, 0, regRange, rplFlags),
+	},
+	"MOVR": {
+		{slots: []slot{indirectI, regSpan}, mnemonic: cpu.Op5xy2, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+			in.X, in.Y = byte(v[1]>>4), byte(v[1])
+		}},
+		{slots: []slot{regSpan, indirectI}, mnemonic: cpu.Op5xy3, operands: func(in *cpu.DecodedInstruction, v []uint16) {
+			in.X, in.Y = byte(v[0]>>4), byte(v[0])
+		}},
+	},
+	// UNK is how the disassembler writes words that aren't instructions
+	"UNK": {
+		{slots: []slot{imm16}, mnemonic: cpu.OpUnknown, operands: func(in *cpu.DecodedInstruction, v []uint16) { in.Opcode = cpu.Opcode(v[0]) }},
+	},
+}
+
+// matchForm returns the first form whose slots accept the operands.
+func matchForm(forms []form, operands []operand) (form, bool) {
+	for _, f := range forms {
+		if len(f.slots) != len(operands) {
+			continue
+		}
+		matched := true
+		for i, s := range f.slots {
+			if s.kind != operands[i].kind || (s.fromV0 && operands[i].first != 0) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return f, true
+		}
+	}
+	return form{}, false
+}
@@ -0,0 +1,268 @@
+package asm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type operandKind int
+
+const (
+	// operandReg is a V register: Vx.
+	operandReg operandKind = iota
+	// operandI is the address register: I.
+	operandI
+	// operandIndirectI is the memory I points to: (I).
+	operandIndirectI
+	// operandDelay is the delay timer: DELAY.
+	operandDelay
+	// operandSound is the sound timer: SOUND.
+	operandSound
+	// operandRPL is the SUPER-CHIP RPL user flags: RPL.
+	operandRPL
+	// operandRange is a range of V registers: Vx-Vy.
+	operandRange
+	// operandIndexed is an address offset by V0: nnn(V0).
+	operandIndexed
+	// operandValue is an expression such as $200, #$0f, or label+2.
+	operandValue
+)
+
+// operand is a parsed instruction operand.
+type operand struct {
+	kind operandKind
+	// reg is the register of an operandReg, or the last register of an
+	// operandRange.
+	reg byte
+	// first is the first register of an operandRange.
+	first byte
+	// expr is the expression of an operandValue or operandIndexed.
+	expr string
+	col  int
+}
+
+// statement is a parsed line of source.
+type statement struct {
+	line int
+	// label is defined at the address of the statement.
+	label    string
+	labelCol int
+	// constant is defined with the value of the first arg when set.
+	constant string
+	mnemonic string
+	col      int
+	args     []arg
+	// org is the address an org directive moves to, found during layout.
+	org int
+}
+
+// arg is an unparsed, comma separated argument and the column it starts at.
+type arg struct {
+	text string
+	col  int
+}
+
+var (
+	// listingPrefix matches the address and instruction or data bytes that
+	// begin each line of disassembler output, so listings can be assembled
+	// directly.
+	listingPrefix = regexp.MustCompile(`^[0-9a-fA-F]{4}( [0-9a-fA-F]{2}){1,4}\s`)
+	identifier    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	register      = regexp.MustCompile(`^[Vv]([0-9A-Fa-f])$`)
+	registerRange = regexp.MustCompile(`^[Vv]([0-9A-Fa-f])-[Vv]([0-9A-Fa-f])$`)
+	indexed       = regexp.MustCompile(`^(.+)\([Vv]0\)$`)
+)
+
+// parseLine splits a line of source into its label, mnemonic, and arguments.
+// Returns nil if the line holds nothing but whitespace and comments.
+func parseLine(lineNum int, line string) (*statement, *Error) {
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		line = line[:i]
+	}
+
+	// blank out rather than strip the listing prefix so columns still refer
+	// to the original line
+	if loc := listingPrefix.FindStringIndex(line); loc != nil {
+		line = strings.Repeat(" ", loc[1]) + line[loc[1]:]
+	}
+
+	s := &statement{line: lineNum}
+	pos := skipSpace(line, 0)
+	word, end := nextWord(line, pos)
+
+	if strings.HasSuffix(word, ":") {
+		s.label, s.labelCol = word[:len(word)-1], pos+1
+		if !identifier.MatchString(s.label) || isReserved(s.label) {
+			return nil, &Error{Line: lineNum, Column: pos + 1, Msg: "invalid label " + strconv.Quote(s.label)}
+		}
+		pos = skipSpace(line, end)
+		word, end = nextWord(line, pos)
+	}
+
+	if word == "" {
+		if s.label == "" {
+			return nil, nil
+		}
+		return s, nil
+	}
+
+	// constant definitions are written "name = value" or "name equ value"
+	next, nextEnd := nextWord(line, skipSpace(line, end))
+	if next == "=" || strings.EqualFold(next, "equ") {
+		if !identifier.MatchString(word) || isReserved(word) {
+			return nil, &Error{Line: lineNum, Column: pos + 1, Msg: "invalid constant name " + strconv.Quote(word)}
+		}
+		s.constant, s.col = word, pos+1
+		s.args = splitArgs(line, nextEnd)
+		return s, nil
+	}
+
+	s.mnemonic, s.col = strings.ToUpper(word), pos+1
+	s.args = splitArgs(line, end)
+	return s, nil
+}
+
+// parseOperand determines the kind of an instruction argument.
+func parseOperand(a arg) operand {
+	text := a.text
+	upper := strings.ToUpper(text)
+	switch {
+	case upper == "I":
+		return operand{kind: operandI, col: a.col}
+	case upper == "(I)":
+		return operand{kind: operandIndirectI, col: a.col}
+	case upper == "DELAY":
+		return operand{kind: operandDelay, col: a.col}
+	case upper == "SOUND":
+		return operand{kind: operandSound, col: a.col}
+	case upper == "RPL":
+		return operand{kind: operandRPL, col: a.col}
+	}
+	if m := register.FindStringSubmatch(text); m != nil {
+		return operand{kind: operandReg, reg: hexDigit(m[1]), col: a.col}
+	}
+	if m := registerRange.FindStringSubmatch(text); m != nil {
+		return operand{kind: operandRange, first: hexDigit(m[1]), reg: hexDigit(m[2]), col: a.col}
+	}
+	if m := indexed.FindStringSubmatch(text); m != nil {
+		return operand{kind: operandIndexed, expr: strings.TrimSpace(m[1]), col: a.col}
+	}
+	return operand{kind: operandValue, expr: strings.TrimPrefix(text, "#"), col: a.col}
+}
+
+// resolver looks up the value of a symbol. It returns a message describing
+// why the symbol has no value, or ok false with no message if the problem has
+// already been reported.
+type resolver func(name string) (v int, ok bool, msg string)
+
+// evaluate computes the value of an expression made of numbers and symbols
+// joined by + and -. It returns ok false if the expression has no value, with
+// a message describing why unless it has already been reported.
+func evaluate(expr string, resolve resolver) (int, bool, string) {
+	expr = strings.Replace(expr, " ", "", -1)
+	if expr == "" {
+		return 0, false, "missing value"
+	}
+
+	total, sign, start := 0, 1, 0
+	if expr[0] == '-' || expr[0] == '+' {
+		if expr[0] == '-' {
+			sign = -1
+		}
+		start = 1
+	}
+	for i := start; i <= len(expr); i++ {
+		if i < len(expr) && expr[i] != '+' && expr[i] != '-' {
+			continue
+		}
+		term := expr[start:i]
+		v, ok, msg := evaluateTerm(term, resolve)
+		if !ok {
+			return 0, false, msg
+		}
+		total += sign * v
+		if i < len(expr) {
+			sign = 1
+			if expr[i] == '-' {
+				sign = -1
+			}
+		}
+		start = i + 1
+	}
+	return total, true, ""
+}
+
+func evaluateTerm(term string, resolve resolver) (int, bool, string) {
+	if term == "" {
+		return 0, false, "missing term in expression"
+	}
+
+	var digits string
+	base := 10
+	lower := strings.ToLower(term)
+	switch {
+	case strings.HasPrefix(lower, "$"):
+		digits, base = lower[1:], 16
+	case strings.HasPrefix(lower, "0x"):
+		digits, base = lower[2:], 16
+	case strings.HasPrefix(lower, "%"):
+		digits, base = lower[1:], 2
+	case strings.HasPrefix(lower, "0b"):
+		digits, base = lower[2:], 2
+	case lower[0] >= '0' && lower[0] <= '9':
+		digits = lower
+	default:
+		if identifier.MatchString(term) && !isReserved(term) {
+			return resolve(term)
+		}
+		return 0, false, "invalid value " + strconv.Quote(term)
+	}
+
+	v, err := strconv.ParseInt(digits, base, 32)
+	if err != nil {
+		return 0, false, "invalid number " + strconv.Quote(term)
+	}
+	return int(v), true, ""
+}
+
+// isReserved reports whether name is a register or other operand keyword
+// that can't be used as a symbol.
+func isReserved(name string) bool {
+	upper := strings.ToUpper(name)
+	return upper == "I" || upper == "DELAY" || upper == "SOUND" || upper == "RPL" || register.MatchString(name)
+}
+
+func splitArgs(line string, pos int) []arg {
+	var args []arg
+	rest := line[pos:]
+	if strings.TrimSpace(rest) == "" {
+		return nil
+	}
+	for _, part := range strings.Split(rest, ",") {
+		lead := len(part) - len(strings.TrimLeft(part, " \t"))
+		args = append(args, arg{text: strings.TrimSpace(part), col: pos + lead + 1})
+		pos += len(part) + 1
+	}
+	return args
+}
+
+func skipSpace(line string, pos int) int {
+	for pos < len(line) && (line[pos] == ' ' || line[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+func nextWord(line string, pos int) (string, int) {
+	end := pos
+	for end < len(line) && line[end] != ' ' && line[end] != '\t' {
+		end++
+	}
+	return line[pos:end], end
+}
+
+func hexDigit(s string) byte {
+	v, _ := strconv.ParseUint(s, 16, 4)
+	return byte(v)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chip-8/internal/asm"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var assembleOut string
+
+var cmdAssemble = &cobra.Command{
+	Use:   "assemble <source file>",
+	Short: "Assemble a CHIP-8 ROM file",
+	Long: "assemble reads the specified source file, written in the same mnemonic\n" +
+		"syntax the disassembler produces, and assembles it into a ROM file. Labels,\n" +
+		"constants, comments, and db/dw data directives are supported, and\n" +
+		"disassembler output can be assembled as is. Writes the ROM next to the\n" +
+		"source file with a .ch8 extension by default.",
+	Args: cobra.ExactArgs(1),
+	Run:  assembleSource,
+}
+
+func init() {
+	cmdAssemble.Flags().StringVarP(&assembleOut, "output", "o", "", "Output file to write to.")
+	rootCmd.AddCommand(cmdAssemble)
+}
+
+func assembleSource(_ *cobra.Command, args []string) {
+	fileIn := args[0]
+	src, err := os.Open(fileIn)
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to open %s", fileIn))
+	}
+	defer src.Close()
+
+	program, err := asm.Assemble(src)
+	if errs, ok := err.(asm.ErrorList); ok {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", fileIn, e)
+		}
+		logAndExit(1, "failed to assemble %s: %d errors", fileIn, len(errs))
+	}
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to assemble %s", fileIn))
+	}
+
+	fileOut := assembleOut
+	if fileOut == "" {
+		fileOut = strings.TrimSuffix(fileIn, filepath.Ext(fileIn)) + ".ch8"
+		if fileOut == fileIn {
+			logAndExit(1, "refusing to overwrite %s, choose an output file with -o", fileIn)
+		}
+	}
+	if err := ioutil.WriteFile(fileOut, program, 0644); err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to write %s", fileOut))
+	}
+
+	logAndExit(0, "wrote out %d bytes to %s", len(program), fileOut)
+}
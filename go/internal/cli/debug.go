@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+
+	"chip-8/internal/debugger"
+
+	"github.com/spf13/cobra"
+)
+
+var debugCyclesPerTick int
+
+var cmdDebug = &cobra.Command{
+	Use:   "debug <rom file>",
+	Short: "Debug a CHIP-8 ROM file",
+	Long: "debug loads the specified ROM file and starts an interactive step\n" +
+		"debugger on it. Instructions can be stepped through one at a time or\n" +
+		"run until a breakpoint on an address or opcode, or a watchpoint on a\n" +
+		"register or memory range, is hit, and stepped back through with\n" +
+		"reverse-step and reverse-continue. Type 'help' at the prompt for a\n" +
+		"list of commands.",
+	Args: cobra.ExactArgs(1),
+	Run:  debugROM,
+}
+
+func init() {
+	addCPUFlags(cmdDebug)
+	addROMDBFlags(cmdDebug)
+	cmdDebug.Flags().IntVarP(&debugCyclesPerTick, "tick", "t", debugger.DefaultCyclesPerTick,
+		"Instructions executed between each tick of the delay and sound timers.")
+	addRewindFlag(cmdDebug)
+	rootCmd.AddCommand(cmdDebug)
+}
+
+func debugROM(cmd *cobra.Command, args []string) {
+	c, _ := loadCPU(cmd, args[0])
+
+	d := debugger.New(c, os.Stdout, debugger.Config{CyclesPerTick: debugCyclesPerTick, Rewind: newRewindBuffer()})
+	if err := d.Run(os.Stdin); err != nil {
+		logErrorAndExit(err)
+	}
+}
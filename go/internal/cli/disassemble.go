@@ -1,15 +1,22 @@
 package cli
 
 import (
+	"bytes"
+	"chip-8/internal/cpu"
 	"chip-8/internal/rom"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
-var disassembleOut string
+var (
+	disassembleOut       string
+	disassembleRecursive bool
+	disassembleSyntax    string
+)
 
 var cmdDisassemble = &cobra.Command{
 	Use:   "disassemble <rom file>",
@@ -17,24 +24,47 @@ var cmdDisassemble = &cobra.Command{
 	Long: "disassemble reads the specified ROM file, disassembles it into opcodes,\n" +
 		"then maps those opcodes into their respective instructions. Can return\n" +
 		"a file containing the decompiled instructions, but writes to stdout by\n" +
-		"default.",
+		"default. The ROM file may be - for standard input or an archive, as\n" +
+		"with run.\n\n" +
+		"By default every two bytes of the ROM are disassembled in turn. With\n" +
+		"--recursive the program's jumps, calls, and skips are followed from the\n" +
+		"entry point instead, which separates code from data, labels jump and\n" +
+		"call targets, and shows data as db bytes with a sprite preview.\n\n" +
+		"--syntax chooses the dialect instructions are written in: classic, the\n" +
+		"dialect the assemble command reads; cowgod, the LD/SE/DRW names of\n" +
+		"Cowgod's technical reference; or octo, Octo source with : labels and\n" +
+		":byte data that Octo can assemble back into the ROM.",
 	Args: cobra.ExactArgs(1),
 	Run:  disassembleROM,
 }
 
 func init() {
 	cmdDisassemble.Flags().StringVarP(&disassembleOut, "output", "o", "stdout", "Output file to write to.")
+	cmdDisassemble.Flags().BoolVarP(&disassembleRecursive, "recursive", "r", false,
+		"Follow control flow from the entry point to separate code from data.")
+	cmdDisassemble.Flags().StringVar(&disassembleSyntax, "syntax", "classic",
+		"Syntax to write instructions in, one of: "+strings.Join(cpu.SyntaxNames(), ", ")+".")
 	rootCmd.AddCommand(cmdDisassemble)
 }
 
 func disassembleROM(_ *cobra.Command, args []string) {
 	fileIn := args[0]
-	rawRom, err := rom.Load(fileIn)
+	syntax, ok := cpu.SyntaxByName(disassembleSyntax)
+	if !ok {
+		logAndExit(1, "unknown syntax %q, must be one of: %s", disassembleSyntax, strings.Join(cpu.SyntaxNames(), ", "))
+	}
+
+	r, err := rom.Load(fileIn)
 	if err != nil {
 		logErrorAndExit(errors.Wrapf(err, "failed to load %s", fileIn))
 	}
 
-	disassembledRom, err := rom.Disassemble(rawRom)
+	disassemble := rom.Disassemble
+	if disassembleRecursive {
+		disassemble = rom.DisassembleRecursive
+	}
+
+	disassembledRom, err := disassemble(bytes.NewReader(r.Data), syntax)
 	if err != nil {
 		logErrorAndExit(errors.Wrapf(err, "failed to disassemble %s", fileIn))
 	}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/runner"
+	"chip-8/internal/screenshot"
+
+	"github.com/pkg/errors"
+)
+
+// registersJSON is the layout of the registers file written by a headless
+// run.
+type registersJSON struct {
+	V     [16]byte `json:"v"`
+	I     uint16   `json:"i"`
+	PC    uint16   `json:"pc"`
+	SP    uint16   `json:"sp"`
+	Stack []uint16 `json:"stack"`
+	Delay byte     `json:"delay"`
+	Sound byte     `json:"sound"`
+}
+
+// runHeadlessROM runs the CPU without a display for the frames or cycles set
+// by the headless flags, then prints the hash of the screen and writes out
+// the requested files. They are written even if the CPU faults, so the
+// state it faulted in can be inspected. stopTrace is called once the run
+// ends.
+func runHeadlessROM(c *cpu.CPU, s romSettings, stopTrace func() error) {
+	if runFrames <= 0 && runCycles <= 0 {
+		logAndExit(1, "--headless needs a limit, set --frames or --cycles")
+	}
+
+	runErr := runner.New(c, nullDisplay{}, runner.Config{Speed: s.speed, Timing: runnerTiming()}).Headless(runFrames, runCycles)
+	if err := stopTrace(); err != nil {
+		logErrorAndExit(err)
+	}
+	if runScreenshot != "" {
+		if err := writeScreenshot(runScreenshot, c, s.palette); err != nil {
+			logErrorAndExit(err)
+		}
+	}
+	if runRegisters != "" {
+		if err := writeRegisters(runRegisters, c); err != nil {
+			logErrorAndExit(err)
+		}
+	}
+	fmt.Printf("%x\n", c.ScreenHash())
+	if runErr != nil {
+		logErrorAndExit(runErr)
+	}
+}
+
+// writeScreenshot writes the screen to a PNG or PBM image, chosen by the
+// file's extension. PNG images are coloured from the palette, or the default
+// screenshot.Palette if it is nil.
+func writeScreenshot(fileOut string, c *cpu.CPU, palette color.Palette) error {
+	if palette == nil {
+		palette = screenshot.Palette
+	}
+	write := func(w io.Writer, pixels []byte, width, height int) error {
+		return screenshot.WritePNGPalette(w, pixels, width, height, palette)
+	}
+	switch ext := strings.ToLower(filepath.Ext(fileOut)); ext {
+	case ".png":
+	case ".pbm":
+		write = screenshot.WritePBM
+	default:
+		return errors.Errorf("unsupported screenshot format %q, must be .png or .pbm", ext)
+	}
+
+	f, err := os.Create(fileOut)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", fileOut)
+	}
+	width, height := c.Resolution()
+	if err := write(f, c.Screen(), width, height); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write %s", fileOut)
+	}
+	return errors.Wrapf(f.Close(), "failed to write %s", fileOut)
+}
+
+// writeRegisters writes the registers to a JSON file.
+func writeRegisters(fileOut string, c *cpu.CPU) error {
+	regs := c.Registers()
+	data, err := json.MarshalIndent(registersJSON{
+		V:     regs.V,
+		I:     regs.I,
+		PC:    regs.PC,
+		SP:    regs.SP,
+		Stack: regs.Stack,
+		Delay: regs.Delay,
+		Sound: regs.Sound,
+	}, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Wrapf(ioutil.WriteFile(fileOut, append(data, '\n'), 0644), "failed to write %s", fileOut)
+}
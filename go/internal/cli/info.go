@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var cmdInfo = &cobra.Command{
+	Use:   "info <rom file>",
+	Short: "Show what is known about a CHIP-8 ROM file",
+	Long: "info prints the size and checksums of the specified ROM file and the\n" +
+		"platform it appears to be written for. If the ROM is in the ROM\n" +
+		"database, its title and authors are printed too, along with the quirk\n" +
+		"profile, speed, keys, and colours that run uses for it unless they are\n" +
+		"set with flags. Only the emulator's test ROMs are built in; see --rom-db\n" +
+		"for reading the full chip-8-database.",
+	Args: cobra.ExactArgs(1),
+	Run:  infoROM,
+}
+
+func init() {
+	addROMDBFlags(cmdInfo)
+	rootCmd.AddCommand(cmdInfo)
+}
+
+func infoROM(_ *cobra.Command, args []string) {
+	r := readROM(args[0])
+
+	size := fmt.Sprintf("%d bytes", len(r.Data))
+	if r.OddLength {
+		size += ", odd length"
+	}
+	fmt.Printf("%-12s %s\n", "Size:", size)
+	fmt.Printf("%-12s %x\n", "SHA-1:", r.SHA1)
+	fmt.Printf("%-12s %08x\n", "CRC-32:", r.CRC32)
+	fmt.Printf("%-12s %s (guessed)\n", "Platform:", r.Platform)
+
+	e, ok, err := lookupROM(r)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	if !ok {
+		fmt.Println("Not in the ROM database.")
+		return
+	}
+
+	fields := [][2]string{
+		{"Title:", e.Program.Title},
+		{"Authors:", strings.Join(e.Program.Authors, ", ")},
+		{"Released:", e.Program.Release},
+		{"Description:", e.Program.Description},
+		{"Platforms:", strings.Join(e.ROM.Platforms, ", ")},
+	}
+	if name, _, ok := e.ROM.Profile(); ok {
+		fields = append(fields, [2]string{"Profile:", name})
+	}
+	var quirks []string
+	for platform, overrides := range e.ROM.QuirkyPlatforms {
+		for quirk, on := range overrides {
+			quirks = append(quirks, fmt.Sprintf("%s.%s=%t", platform, quirk, on))
+		}
+	}
+	sort.Strings(quirks)
+	fields = append(fields, [2]string{"Quirks:", strings.Join(quirks, " ")})
+	if e.ROM.TickRate > 0 {
+		fields = append(fields, [2]string{"Speed:", fmt.Sprintf("%d instructions per frame", e.ROM.TickRate)})
+	}
+	if len(e.ROM.Keys) > 0 {
+		keys := make([]string, 0, len(e.ROM.Keys))
+		for name, key := range e.ROM.Keys {
+			keys = append(keys, fmt.Sprintf("%s=%X", name, key))
+		}
+		sort.Strings(keys)
+		fields = append(fields, [2]string{"Keys:", strings.Join(keys, " ")})
+	}
+	if c := e.ROM.Colors; c != nil {
+		colours := strings.Join(c.Pixels, " ")
+		if c.Buzzer != "" || c.Silence != "" {
+			colours += fmt.Sprintf(" (buzzer %s, silence %s)", c.Buzzer, c.Silence)
+		}
+		fields = append(fields, [2]string{"Colours:", strings.TrimSpace(colours)})
+	}
+	for _, f := range fields {
+		if f[1] != "" {
+			fmt.Printf("%-12s %s\n", f[0], f[1])
+		}
+	}
+}
@@ -13,3 +13,7 @@ func logAndExit(code int, msg string, a ...interface{}) {
 func logErrorAndExit(err error) {
 	logAndExit(1, err.Error())
 }
+
+func logError(err error) {
+	log.Println(err)
+}
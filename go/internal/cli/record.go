@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"chip-8/internal/movie"
+	"chip-8/internal/rom"
+	"chip-8/internal/runner"
+	"chip-8/internal/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var recordOut string
+
+var cmdRecord = &cobra.Command{
+	Use:   "record <rom file>",
+	Short: "Record a movie of a CHIP-8 ROM being played",
+	Long: "record runs the specified ROM file in the terminal like run, and records\n" +
+		"the random seed and the keypad state of every frame to a movie file that\n" +
+		"replay plays back exactly. RPL user flags, save states, and rewinding are\n" +
+		"not available while recording, since they would make the run impossible\n" +
+		"to reproduce, and neither are the settings of the ROM database, since a\n" +
+		"movie only keeps the quirk profile. Writes the movie next to the ROM file\n" +
+		"with a .movie extension by default.",
+	Args: cobra.ExactArgs(1),
+	Run:  recordROM,
+}
+
+func init() {
+	addCPUFlags(cmdRecord)
+	addPlayFlags(cmdRecord)
+	cmdRecord.Flags().StringVarP(&recordOut, "output", "o", "", "Output file to write to.")
+	rootCmd.AddCommand(cmdRecord)
+}
+
+func recordROM(_ *cobra.Command, args []string) {
+	checkPlayFlags()
+	fileIn := args[0]
+	fileOut := recordOut
+	if fileOut == "" && fileIn == rom.Stdin {
+		logAndExit(1, "choose an output file with -o when reading the ROM from standard input")
+	}
+	if fileOut == "" {
+		fileOut = strings.TrimSuffix(fileIn, filepath.Ext(fileIn)) + ".movie"
+		if fileOut == fileIn {
+			logAndExit(1, "refusing to overwrite %s, choose an output file with -o", fileIn)
+		}
+	}
+	keymap, err := terminal.ParseKeymap(runKeys)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+
+	if cpuSeed == 0 {
+		cpuSeed = time.Now().UnixNano()
+	}
+	r := readROM(fileIn)
+	s := flagSettings()
+	c := newCPU(fileIn, r, s)
+	m := &movie.Movie{
+		Seed:    cpuSeed,
+		Speed:   s.speed,
+		Profile: s.profile,
+		Font:    cpuFont,
+		ROM:     r.SHA1,
+	}
+
+	term, err := terminal.Open()
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	runErr := runner.New(c, term, runner.Config{
+		Speed:      s.speed,
+		Multiplier: runMultiplier,
+		Turbo:      runTurbo,
+		Keymap:     keymap,
+		Recorder:   m,
+	}).Run()
+	if err := term.Close(); err != nil {
+		logErrorAndExit(errors.Wrap(err, "failed to restore terminal"))
+	}
+
+	m.ScreenHash = c.ScreenHash()
+	if err := writeMovie(fileOut, m); err != nil {
+		logErrorAndExit(err)
+	}
+	if runErr != nil {
+		logErrorAndExit(runErr)
+	}
+	logAndExit(0, "recorded %d frames to %s, screen hash %x", len(m.Keys), fileOut, m.ScreenHash)
+}
+
+func writeMovie(fileOut string, m *movie.Movie) error {
+	f, err := os.Create(fileOut)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", fileOut)
+	}
+	if _, err := m.WriteTo(f); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write %s", fileOut)
+	}
+	return errors.Wrapf(f.Close(), "failed to write %s", fileOut)
+}
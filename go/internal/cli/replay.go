@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"os"
+
+	"chip-8/internal/movie"
+	"chip-8/internal/runner"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var cmdReplay = &cobra.Command{
+	Use:   "replay <movie file> <rom file>",
+	Short: "Replay a movie recorded with record",
+	Long: "replay plays the specified movie back on the ROM file it was recorded\n" +
+		"with, as fast as possible and without drawing, using the seed, speed,\n" +
+		"profile, and font the movie was recorded with. It prints the hash of the\n" +
+		"final screen and fails if it differs from the one recorded.",
+	Args: cobra.ExactArgs(2),
+	Run:  replayMovie,
+}
+
+func init() {
+	rootCmd.AddCommand(cmdReplay)
+}
+
+// nullDisplay is a Display that discards frames and never has key presses.
+type nullDisplay struct{}
+
+func (nullDisplay) Draw([]byte, int, int) error { return nil }
+func (nullDisplay) Beep() error                 { return nil }
+func (nullDisplay) Keys() <-chan byte           { return nil }
+
+func replayMovie(_ *cobra.Command, args []string) {
+	movieFile, romFile := args[0], args[1]
+	f, err := os.Open(movieFile)
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to open %s", movieFile))
+	}
+	m, err := movie.Read(f)
+	f.Close()
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to read %s", movieFile))
+	}
+
+	program := readROM(romFile)
+	if program.SHA1 != m.ROM {
+		logAndExit(1, "%s is not the ROM %s was recorded with", romFile, movieFile)
+	}
+	cpuSeed, cpuFont = m.Seed, m.Font
+	c := newCPU(romFile, program, romSettings{profile: m.Profile, speed: m.Speed})
+
+	r := runner.New(c, nullDisplay{}, runner.Config{Speed: m.Speed})
+	if err := m.Replay(c, r.Frame); err != nil {
+		// a run that faulted while recording faults on the same frame
+		logError(err)
+	}
+
+	hash := c.ScreenHash()
+	if hash != m.ScreenHash {
+		logAndExit(1, "replayed %d frames, screen hash %x does not match the recorded %x", len(m.Keys), hash, m.ScreenHash)
+	}
+	logAndExit(0, "replayed %d frames, screen hash %x", len(m.Keys), hash)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rom"
+	"chip-8/internal/romdb"
+	"chip-8/internal/runner"
+	"chip-8/internal/screenshot"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	romDBFile string
+	romDBOff  bool
+)
+
+// romSettings are the settings a ROM is run with, from the flags and the
+// ROM database.
+type romSettings struct {
+	// profile is the name of the quirk profile, and quirks are the ROM
+	// database's quirks for the ROM in place of the profile's own, if any.
+	profile string
+	quirks  *cpu.Quirks
+	// speed is the number of instructions executed per second.
+	speed    int
+	controls map[string]int
+	palette  color.Palette
+}
+
+// flagSettings returns the settings chosen by the flags alone, working out
+// the speed from --ipf when it is given.
+func flagSettings() romSettings {
+	s := romSettings{profile: cpuProfile, speed: runSpeed}
+	if runIPF > 0 {
+		s.speed = runIPF * runner.TimerHz
+	}
+	return s
+}
+
+// addROMDBFlags adds the flags that choose the ROM database romDBSettings looks
+// ROMs up in.
+func addROMDBFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&romDBFile, "rom-db", "",
+		"ROM database in the chip-8-database programs.json format, looked up before the built in one, which only lists the emulator's test ROMs (default: chip8/programs.json in the user config directory, if it exists).")
+	cmd.Flags().BoolVar(&romDBOff, "no-rom-db", false,
+		"Don't configure the emulator from the ROM database.")
+}
+
+// lookupROM looks the ROM up in the ROM database file and then the built in
+// database, returning whether either has it.
+func lookupROM(r *rom.ROM) (romdb.Entry, bool, error) {
+	file, explicit := romDBFile, romDBFile != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			file = filepath.Join(dir, "chip8", "programs.json")
+		}
+	}
+
+	if file != "" {
+		f, err := os.Open(file)
+		switch {
+		case err == nil:
+			db, err := romdb.Read(f)
+			f.Close()
+			if err != nil {
+				return romdb.Entry{}, false, errors.Wrapf(err, "failed to read %s", file)
+			}
+			if e, ok := db.Lookup(r.SHA1); ok {
+				return e, true, nil
+			}
+		case explicit || !os.IsNotExist(err):
+			return romdb.Entry{}, false, errors.Wrap(err, "failed to open ROM database")
+		}
+	}
+
+	e, ok := romdb.Builtin().Lookup(r.SHA1)
+	return e, ok, nil
+}
+
+// romDBSettings returns the settings chosen by the flags, with the settings
+// the ROM database has for the ROM in place of those not set by flags,
+// exiting if the database can't be read.
+func romDBSettings(cmd *cobra.Command, r *rom.ROM) romSettings {
+	s := flagSettings()
+	if romDBOff {
+		return s
+	}
+	e, ok, err := lookupROM(r)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	if !ok {
+		return s
+	}
+
+	flags := cmd.Flags()
+	if name, quirks, ok := e.ROM.Profile(); ok && !flags.Changed("profile") {
+		s.profile, s.quirks = name, &quirks
+	}
+	if e.ROM.TickRate > 0 && flags.Lookup("speed") != nil && !flags.Changed("speed") && !flags.Changed("ipf") {
+		s.speed = e.ROM.TickRate * runner.TimerHz
+	}
+	if flags.Lookup("keys") != nil && !flags.Changed("keys") {
+		s.controls = e.ROM.Keys
+	}
+	colors, err := e.ROM.PixelColors()
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "invalid colours for %s in ROM database", e.Program.Title))
+	}
+	s.palette = paletteWith(colors)
+	return s
+}
+
+// paletteWith returns a copy of screenshot.Palette with the colours in place
+// of its first ones, or nil if there are none.
+func paletteWith(colors []color.RGBA) color.Palette {
+	if len(colors) == 0 {
+		return nil
+	}
+	palette := append(color.Palette(nil), screenshot.Palette...)
+	for i, c := range colors {
+		if i < len(palette) {
+			palette[i] = c
+		}
+	}
+	return palette
+}
@@ -6,19 +6,20 @@ import (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "chip8",
+	Use:   "chip8 <rom file>",
 	Short: "chip8 is an emulator to run, debug, and (dis)assemble CHIP-8 ROM's.",
 	Long:  "",
+	Args:  cobra.ExactArgs(1),
 	Run:   runROM,
 }
 
+func init() {
+	addRunFlags(rootCmd)
+}
+
 // Execute loads and executes the cli app.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		logErrorAndExit(errors.WithStack(err))
 	}
 }
-
-func runROM(cmd *cobra.Command, args []string) {
-	_, _ = cmd, args
-}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rom"
+
+	"github.com/pkg/errors"
+)
+
+// rplFile returns the file the SUPER-CHIP RPL user flags of the ROM are kept
+// in: the --rpl flag if it is set, otherwise a file named after the ROM and
+// its hash in the user's config directory.
+func rplFile(romFile string, r *rom.ROM) (string, error) {
+	if runRPL != "" {
+		return runRPL, nil
+	}
+	return userFile("rpl", romFile, r, ".rpl")
+}
+
+// userFile returns the path of a file kept for the ROM in a subdirectory of
+// the user's config directory, named after the ROM file and the SHA-1 hash
+// of the ROM with the extension. The hash keeps apart different ROMs with the
+// same file name, and an edited ROM from the states of the one it was.
+func userFile(subdir, romFile string, r *rom.ROM, ext string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find config directory")
+	}
+	name := strings.TrimSuffix(filepath.Base(romFile), filepath.Ext(romFile))
+	return filepath.Join(dir, "chip8", subdir, fmt.Sprintf("%s-%x%s", name, r.SHA1, ext)), nil
+}
+
+// loadRPLFlags sets the CPU's RPL user flags to those saved in the file,
+// leaving them zeroed if the file doesn't exist yet, and returns them.
+func loadRPLFlags(c *cpu.CPU, file string) ([16]byte, error) {
+	var flags [16]byte
+	saved, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return flags, nil
+	}
+	if err != nil {
+		return flags, errors.Wrap(err, "failed to read RPL flags")
+	}
+	copy(flags[:], saved)
+	c.SetRPLFlags(flags)
+	return flags, nil
+}
+
+// saveRPLFlags writes the CPU's RPL user flags to the file if the program
+// changed them from those it was started with.
+func saveRPLFlags(c *cpu.CPU, file string, loaded [16]byte) error {
+	flags := c.RPLFlags()
+	if flags == loaded {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.Wrap(err, "failed to create RPL flags directory")
+	}
+	return errors.Wrap(ioutil.WriteFile(file, flags[:], 0644), "failed to save RPL flags")
+}
@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"math/rand"
+	"strings"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rewind"
+	"chip-8/internal/rom"
+	"chip-8/internal/runner"
+	"chip-8/internal/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runSpeed      int
+	runIPF        int
+	runMultiplier float64
+	runTurbo      bool
+	runTiming     string
+	runKeys       string
+	runRPL        string
+	runState      string
+
+	runHeadless   bool
+	runFrames     int
+	runCycles     int
+	runScreenshot string
+	runRegisters  string
+
+	cpuFont    string
+	cpuProfile string
+	cpuSeed    int64
+
+	rewindBudget int
+)
+
+var cmdRun = &cobra.Command{
+	Use:   "run <rom file>",
+	Short: "Run a CHIP-8 ROM file",
+	Long: "run loads the specified ROM file and runs it in the terminal. The\n" +
+		"display is drawn with half-block characters, and the hex keypad is\n" +
+		"mapped onto the keyboard (1234/qwer/asdf/zxcv by default). Press\n" +
+		"Ctrl-C to quit.\n\n" +
+		"The ROM file can also be a .zip, .tar.gz, or .tgz archive holding one\n" +
+		".ch8, .sc8, or .xo8 file, or picking one with a colon after the name,\n" +
+		"as in games.zip:pong.ch8. With --headless, - reads the ROM from\n" +
+		"standard input.\n\n" +
+		"Ctrl-S followed by a digit saves the state of the machine to that\n" +
+		"slot, and Ctrl-L followed by a digit loads it again. Slots are kept\n" +
+		"between runs in the chip8/states directory of the user config\n" +
+		"directory. Holding Backspace rewinds the program a frame at a time.\n\n" +
+		"ROMs found in the ROM database are run with the quirk profile, speed,\n" +
+		"and colours it lists for them, and its up, down, left, right, a, and b\n" +
+		"controls are mapped to the arrow keys, Space, and Enter, unless set\n" +
+		"with flags. Only the emulator's test ROMs are built in, so download the\n" +
+		"chip-8-database programs.json to chip8/programs.json in the user config\n" +
+		"directory, or pass it with --rom-db, to recognise others. See info for\n" +
+		"what is known about a ROM.\n\n" +
+		"Frames run at 60 Hz, or scaled by --multiplier, with --speed or --ipf\n" +
+		"instructions executed each second or frame. Ctrl-P pauses and resumes,\n" +
+		"Ctrl-N advances a frame while paused, Ctrl-T runs as fast as possible\n" +
+		"until typed again, and Ctrl-W halves the speed, down to an eighth before\n" +
+		"going back to full speed. The measured speed is shown below the screen.\n\n" +
+		"--timing vip replaces --speed with a model of the COSMAC VIP, which\n" +
+		"charges each instruction the machine cycles it took on the original\n" +
+		"interpreter and ends the frame whenever a sprite is drawn, so programs\n" +
+		"written for the VIP run at the speed they were written for.\n\n" +
+		"With --headless the ROM is run as fast as possible without a display\n" +
+		"for --frames frames or --cycles instructions, after which the hash of\n" +
+		"the screen is printed and the screen and registers are optionally\n" +
+		"written out, for regression testing ROMs. Pass --seed as well for\n" +
+		"reproducible results.\n\n" +
+		"--trace writes every executed instruction, with the registers it\n" +
+		"changed, to a text or JSON lines file, optionally only those in an\n" +
+		"address range or opcode class. Compare the traces of two runs with\n" +
+		"trace-diff.",
+	Args: cobra.ExactArgs(1),
+	Run:  runROM,
+}
+
+func init() {
+	addRunFlags(cmdRun)
+	rootCmd.AddCommand(cmdRun)
+}
+
+func addRunFlags(cmd *cobra.Command) {
+	addCPUFlags(cmd)
+	addPlayFlags(cmd)
+	addROMDBFlags(cmd)
+	cmd.Flags().StringVar(&runTiming, "timing", "fixed",
+		"Instruction timing model, one of: "+strings.Join(runner.TimingNames(), ", ")+". vip ignores --speed.")
+	cmd.Flags().StringVar(&runRPL, "rpl", "",
+		"File the SUPER-CHIP RPL user flags are kept in between runs (default: named after the ROM and its SHA-1 hash in the user config directory).")
+	cmd.Flags().StringVar(&runState, "load-state", "",
+		"Save state file to resume from, such as a saved slot.")
+	addRewindFlag(cmd)
+	cmd.Flags().BoolVar(&runHeadless, "headless", false,
+		"Run without a display, as fast as possible, then print the hash of the screen.")
+	cmd.Flags().IntVar(&runFrames, "frames", 0, "Frames to run for with --headless.")
+	cmd.Flags().IntVar(&runCycles, "cycles", 0, "Instructions to execute with --headless.")
+	cmd.Flags().StringVar(&runScreenshot, "screenshot", "",
+		"File to write the final screen to with --headless, as a .png or .pbm image.")
+	cmd.Flags().StringVar(&runRegisters, "registers", "",
+		"File to write the final registers to with --headless, as JSON.")
+	addTraceFlags(cmd)
+}
+
+// addRewindFlag adds the flag that sizes the buffer returned by
+// newRewindBuffer.
+func addRewindFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&rewindBudget, "rewind-budget", rewind.DefaultBudget>>20,
+		"Memory in MiB kept for rewinding, 0 to disable rewinding.")
+}
+
+// newRewindBuffer returns a rewind buffer sized by the rewind flag, or nil if
+// rewinding is disabled.
+func newRewindBuffer() *rewind.Buffer {
+	if rewindBudget <= 0 {
+		return nil
+	}
+	return rewind.New(rewindBudget << 20)
+}
+
+// runnerTiming returns the timing model chosen by the timing flag, exiting if
+// it is unknown.
+func runnerTiming() runner.Timing {
+	t, ok := runner.TimingByName(runTiming)
+	if !ok {
+		logAndExit(1, "unknown timing %q, must be one of: %s", runTiming, strings.Join(runner.TimingNames(), ", "))
+	}
+	return t
+}
+
+// addPlayFlags adds the flags that configure playing a ROM in the terminal.
+func addPlayFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&runSpeed, "speed", "s", runner.DefaultSpeed, "Instructions executed per second.")
+	cmd.Flags().IntVar(&runIPF, "ipf", 0, "Instructions executed per 60 Hz frame, instead of --speed.")
+	cmd.Flags().Float64Var(&runMultiplier, "multiplier", 1,
+		"Real-time speed multiplier, below 1 for slow motion.")
+	cmd.Flags().BoolVar(&runTurbo, "turbo", false, "Run as fast as possible, also toggled with Ctrl-T.")
+	cmd.Flags().StringVarP(&runKeys, "keys", "k", terminal.DefaultLayout,
+		"16 keys standing in for the hex keypad, row by row (123C 456D 789E A0BF).")
+}
+
+// checkPlayFlags validates the play flags, exiting if they are invalid.
+func checkPlayFlags() {
+	if runIPF < 0 {
+		logAndExit(1, "--ipf must not be negative")
+	}
+	if runMultiplier <= 0 {
+		logAndExit(1, "--multiplier must be greater than 0")
+	}
+}
+
+// addCPUFlags adds the flags that configure the CPU loaded by loadCPU.
+func addCPUFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&cpuFont, "font", "cosmac",
+		"Hex digit font set, one of: "+strings.Join(cpu.FontNames(), ", ")+".")
+	cmd.Flags().StringVar(&cpuProfile, "profile", "cowgod",
+		"Interpreter quirk profile, one of: "+strings.Join(cpu.ProfileNames(), ", ")+". The xochip profile also turns on XO-CHIP mode.")
+	cmd.Flags().Int64Var(&cpuSeed, "seed", 0,
+		"Seed for the random number generator, seeded from the clock when 0.")
+}
+
+func runROM(cmd *cobra.Command, args []string) {
+	checkPlayFlags()
+	if runHeadless {
+		c, s := loadCPU(cmd, args[0])
+		if runState != "" {
+			if err := loadStateFile(c, runState); err != nil {
+				logErrorAndExit(err)
+			}
+		}
+		runHeadlessROM(c, s, startTrace(c))
+		return
+	}
+
+	keymap, err := terminal.ParseKeymap(runKeys)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	timing := runnerTiming()
+
+	r := readROM(args[0])
+	s := romDBSettings(cmd, r)
+	c := newCPU(args[0], r, s)
+	keymap.BindControls(s.controls)
+	flagsFile, err := rplFile(args[0], r)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	flags, err := loadRPLFlags(c, flagsFile)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	if runState != "" {
+		if err := loadStateFile(c, runState); err != nil {
+			logErrorAndExit(err)
+		}
+	}
+	states, err := newFileStates(args[0], r)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+
+	term, err := terminal.Open()
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	term.SetPalette(s.palette)
+
+	stopTrace := startTrace(c)
+	runErr := runner.New(c, term, runner.Config{
+		Speed:      s.speed,
+		Timing:     timing,
+		Multiplier: runMultiplier,
+		Turbo:      runTurbo,
+		Keymap:     keymap,
+		States:     states,
+		Rewind:     newRewindBuffer(),
+	}).Run()
+	if err := term.Close(); err != nil {
+		logErrorAndExit(errors.Wrap(err, "failed to restore terminal"))
+	}
+	if err := stopTrace(); err != nil {
+		logErrorAndExit(err)
+	}
+	if err := saveRPLFlags(c, flagsFile, flags); err != nil {
+		logErrorAndExit(err)
+	}
+	if runErr != nil {
+		logErrorAndExit(runErr)
+	}
+}
+
+// loadCPU loads the ROM file into a new CPU configured by the ROM database
+// and the CPU flags, exiting if it can't be loaded. It returns the settings
+// the ROM is run with.
+func loadCPU(cmd *cobra.Command, fileIn string) (*cpu.CPU, romSettings) {
+	r := readROM(fileIn)
+	s := romDBSettings(cmd, r)
+	return newCPU(fileIn, r, s), s
+}
+
+// readROM reads the ROM file, exiting if it can't be read or isn't a ROM.
+func readROM(fileIn string) *rom.ROM {
+	r, err := rom.Load(fileIn)
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to load %s", fileIn))
+	}
+	return r
+}
+
+// newCPU loads the ROM read from the ROM file into a new CPU configured by
+// the settings and the CPU flags, exiting if it doesn't fit.
+func newCPU(fileIn string, r *rom.ROM, s romSettings) *cpu.CPU {
+	font, ok := cpu.FontByName(cpuFont)
+	if !ok {
+		logAndExit(1, "unknown font %q, must be one of: %s", cpuFont, strings.Join(cpu.FontNames(), ", "))
+	}
+	quirks, ok := cpu.ProfileByName(s.profile)
+	if !ok {
+		logAndExit(1, "unknown profile %q, must be one of: %s", s.profile, strings.Join(cpu.ProfileNames(), ", "))
+	}
+
+	c := cpu.NewCPU()
+	if cpuSeed != 0 {
+		c.SetRandom(rand.New(rand.NewSource(cpuSeed)))
+	}
+	c.SetFont(font)
+	c.Quirks = quirks
+	if s.quirks != nil {
+		c.Quirks = *s.quirks
+	}
+	platform := rom.PlatformCHIP8
+	if s.profile == "xochip" {
+		platform = rom.PlatformXOCHIP
+	}
+	if err := r.Fits(platform); err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to load %s", fileIn))
+	}
+	c.SetXOCHIP(platform == rom.PlatformXOCHIP)
+	if err := c.LoadProgram(r.Data); err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to load %s into memory", fileIn))
+	}
+	return c
+}
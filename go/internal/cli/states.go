@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rom"
+
+	"github.com/pkg/errors"
+)
+
+// fileStates keeps the save state slots of a ROM as files, named after the
+// ROM and its SHA-1 hash with the slot number, in the user's config
+// directory.
+type fileStates struct {
+	// prefix is the path of the slot files without the slot number.
+	prefix string
+}
+
+func newFileStates(romFile string, r *rom.ROM) (*fileStates, error) {
+	prefix, err := userFile("states", romFile, r, "")
+	if err != nil {
+		return nil, err
+	}
+	return &fileStates{prefix: prefix}, nil
+}
+
+func (s *fileStates) file(slot int) string {
+	return fmt.Sprintf("%s.%d.state", s.prefix, slot)
+}
+
+// SaveState writes the snapshot to the slot's file.
+func (s *fileStates) SaveState(slot int, snapshot []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.prefix), 0755); err != nil {
+		return errors.Wrap(err, "failed to create save state directory")
+	}
+	return errors.WithStack(ioutil.WriteFile(s.file(slot), snapshot, 0644))
+}
+
+// LoadState reads the snapshot from the slot's file, if it has been saved.
+func (s *fileStates) LoadState(slot int) ([]byte, error) {
+	snapshot, err := ioutil.ReadFile(s.file(slot))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return snapshot, errors.WithStack(err)
+}
+
+// loadStateFile restores the CPU from the save state in the file.
+func loadStateFile(c *cpu.CPU, file string) error {
+	snapshot, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read save state")
+	}
+	if err := c.Restore(snapshot); err != nil {
+		return errors.Wrapf(err, "failed to restore %s", file)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/trace"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	traceFile   string
+	traceFormat string
+	traceRange  string
+	traceOps    string
+
+	traceDiffContext int
+)
+
+var cmdTraceDiff = &cobra.Command{
+	Use:   "trace-diff <trace file> <trace file>",
+	Short: "Compare two execution traces",
+	Long: "trace-diff compares two execution traces written in the same format by\n" +
+		"run --trace, and prints the first instruction where they differ along\n" +
+		"with the instructions leading up to it. It exits with status 1 if the\n" +
+		"traces differ.",
+	Args: cobra.ExactArgs(2),
+	Run:  diffTraces,
+}
+
+func init() {
+	cmdTraceDiff.Flags().IntVarP(&traceDiffContext, "context", "C", 5,
+		"Matching instructions printed before the difference.")
+	rootCmd.AddCommand(cmdTraceDiff)
+}
+
+// addTraceFlags adds the flags that configure the trace started by
+// startTrace.
+func addTraceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&traceFile, "trace", "", "File to write a trace of every executed instruction to.")
+	cmd.Flags().StringVar(&traceFormat, "trace-format", "text", "Format of the trace, text or json.")
+	cmd.Flags().StringVar(&traceRange, "trace-range", "",
+		"Only trace instructions in an inclusive range of hex addresses, such as 200-2ff.")
+	cmd.Flags().StringVar(&traceOps, "trace-ops", "",
+		"Only trace opcodes in a comma separated list of classes, each the first hex digit of its opcodes, such as 8,D.")
+}
+
+// startTrace starts tracing the CPU to the file set by the trace flags, if
+// any, exiting if it can't be created. The returned function stops tracing,
+// and must be called for the trace to be complete.
+func startTrace(c *cpu.CPU) func() error {
+	if traceFile == "" {
+		return func() error { return nil }
+	}
+
+	format, err := trace.ParseFormat(traceFormat)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	var filter trace.Filter
+	if traceRange != "" {
+		if filter.Start, filter.End, err = trace.ParseRange(traceRange); err != nil {
+			logErrorAndExit(err)
+		}
+	}
+	if traceOps != "" {
+		if filter.Classes, err = trace.ParseClasses(traceOps); err != nil {
+			logErrorAndExit(err)
+		}
+	}
+
+	f, err := os.Create(traceFile)
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to create %s", traceFile))
+	}
+	w := trace.NewWriter(f, format, filter)
+	c.SetTracer(w)
+	return func() error {
+		c.SetTracer(nil)
+		if err := w.Flush(); err != nil {
+			f.Close()
+			return errors.Wrapf(err, "failed to write %s", traceFile)
+		}
+		return errors.Wrapf(f.Close(), "failed to write %s", traceFile)
+	}
+}
+
+func diffTraces(_ *cobra.Command, args []string) {
+	a, err := os.Open(args[0])
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to open %s", args[0]))
+	}
+	defer a.Close()
+	b, err := os.Open(args[1])
+	if err != nil {
+		logErrorAndExit(errors.Wrapf(err, "failed to open %s", args[1]))
+	}
+	defer b.Close()
+
+	d, err := trace.Diff(a, b, traceDiffContext)
+	if err != nil {
+		logErrorAndExit(err)
+	}
+	if d == nil {
+		fmt.Println("traces are identical")
+		return
+	}
+
+	fmt.Printf("traces differ at line %d\n", d.Line)
+	for _, line := range d.Context {
+		fmt.Printf("  %s\n", line)
+	}
+	for _, side := range []struct {
+		name string
+		line string
+		end  bool
+	}{{args[0], d.A, d.AEnd}, {args[1], d.B, d.BEnd}} {
+		if side.end {
+			side.line = "(end of trace)"
+		}
+		fmt.Printf("%s:\n- %s\n", side.name, side.line)
+	}
+	os.Exit(1)
+}
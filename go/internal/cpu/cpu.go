@@ -1,24 +1,76 @@
 package cpu
 
 import (
-	"log"
+	"crypto/sha1"
+	"math"
+	"math/rand"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
-type (
-	operation func() error
-	opDecoder func(Opcode) operation
+const (
+	// ScreenWidth is the width of the low resolution display in pixels.
+	ScreenWidth = 64
+	// ScreenHeight is the height of the low resolution display in pixels.
+	ScreenHeight = 32
+
+	// HiResWidth is the width of the SUPER-CHIP high resolution display in
+	// pixels.
+	HiResWidth = 128
+	// HiResHeight is the height of the SUPER-CHIP high resolution display in
+	// pixels.
+	HiResHeight = 64
+
+	// MemorySize is the amount of memory in bytes.
+	MemorySize = 0x1000
+	// XOCHIPMemorySize is the amount of memory in bytes in XO-CHIP mode.
+	XOCHIPMemorySize = 0x10000
+
+	// programStart is the address CHIP-8 programs are loaded at and
+	// where execution begins.
+	programStart = 0x200
+
+	// defaultPitch is the XO-CHIP pitch register's initial value, which
+	// plays the audio pattern at 4000 bits a second.
+	defaultPitch = 64
 )
 
+var (
+	// ErrStackOverflow is returned when a subroutine call is made with a full stack.
+	ErrStackOverflow = errors.New("stack overflow")
+	// ErrStackUnderflow is returned when returning from a subroutine with an empty stack.
+	ErrStackUnderflow = errors.New("stack underflow")
+	// ErrProgramTooLarge is returned when a program does not fit in memory.
+	ErrProgramTooLarge = errors.New("program too large for memory")
+	// ErrMemoryOutOfBounds is returned when an operation addresses memory past the end of RAM.
+	ErrMemoryOutOfBounds = errors.New("memory address out of bounds")
+	// ErrRPLOutOfBounds is returned when Fx75 or Fx85 uses more than the 8
+	// SUPER-CHIP RPL user flags outside XO-CHIP mode.
+	ErrRPLOutOfBounds = errors.New("RPL flag out of bounds")
+)
+
+// Random is the source of the random numbers used by the CXKK instruction.
+// A *rand.Rand from math/rand satisfies it, so runs can be reproduced by
+// seeding one with a known value.
+type Random interface {
+	// Intn returns a random number in [0, n).
+	Intn(n int) int
+}
+
 // NewCPU constructs and returns a pointer to a CPU instance with the
-// stack pointer and program counter set to their initial values.
+// stack pointer and program counter set to their initial values, and the
+// built-in fonts loaded into the interpreter area of memory.
 func NewCPU() *CPU {
 	c := CPU{
-		sp: 0xfa0,
-		pc: 0x200,
+		sp:      0,
+		pc:      programStart,
+		memSize: MemorySize,
+		plane:   1,
+		pitch:   defaultPitch,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
-	c.registerOpDecoder()
+	c.loadFonts()
 
 	return &c
 }
@@ -28,241 +80,897 @@ type CPU struct {
 	V [16]byte
 	I uint16
 
+	// Quirks selects the interpreter behaviours programs rely on.
+	Quirks Quirks
+
 	pc uint16
 
-	memory [4096]byte
-	screen [2048]byte
+	memory [XOCHIPMemorySize]byte
+	// memSize is the amount of memory addressable, which is only all of
+	// memory in XO-CHIP mode.
+	memSize int
+	xochip  bool
+
+	// screen holds one byte per pixel, where bit n is set when the pixel is
+	// lit in bit-plane n+1.
+	screen [HiResWidth * HiResHeight]byte
+	// plane is the mask of the XO-CHIP bit-planes drawn to.
+	plane byte
+	// hires is set while the display is in SUPER-CHIP high resolution mode.
+	hires bool
+	// exited is set once the program has run the SUPER-CHIP exit instruction.
+	exited bool
 
 	delay byte
 	sound byte
 
+	// audio is the XO-CHIP audio pattern buffer, played a bit at a time
+	// while the sound timer is running, at a rate set by pitch.
+	audio [16]byte
+	pitch byte
+
 	stack [16]uint16
 	sp    uint16
 
 	keyboard [16]byte
 
-	opDecoder
+	// rpl holds the SUPER-CHIP RPL user flags, which survive between runs.
+	rpl [16]byte
+
+	rng Random
+	// tracer is told about every executed instruction when it is set.
+	tracer Tracer
+
+	// instruction is the instruction currently being executed, and vx the
+	// value its Vx held before it was executed.
+	instruction DecodedInstruction
+	vx          byte
+	// skipped is set when the last skip instruction executed skipped the
+	// next one.
+	skipped bool
+	// fault is set when an instruction fails, halting the CPU.
+	fault *ExecutionError
+}
+
+// Registers is a snapshot of the CPU's registers, timers, and call stack.
+type Registers struct {
+	V     [16]byte
+	I     uint16
+	PC    uint16
+	SP    uint16
+	Stack []uint16
+	Delay byte
+	Sound byte
+}
+
+// Registers returns a copy of the CPU's registers. Stack only holds the
+// return addresses currently pushed, oldest first.
+func (c *CPU) Registers() Registers {
+	stack := make([]uint16, c.sp)
+	copy(stack, c.stack[:c.sp])
+	return Registers{
+		V:     c.V,
+		I:     c.I,
+		PC:    c.pc,
+		SP:    c.sp,
+		Stack: stack,
+		Delay: c.delay,
+		Sound: c.sound,
+	}
+}
+
+// ReadMemory returns a copy of the n bytes of memory starting at addr. The
+// range is truncated at the end of memory.
+func (c *CPU) ReadMemory(addr uint16, n int) []byte {
+	start := int(addr)
+	if start > c.memSize {
+		start = c.memSize
+	}
+	end := start + n
+	if end > c.memSize {
+		end = c.memSize
+	}
+	mem := make([]byte, end-start)
+	copy(mem, c.memory[start:end])
+	return mem
+}
+
+// LoadProgram copies the program into memory at the program start address.
+func (c *CPU) LoadProgram(program []byte) error {
+	if len(program) > c.memSize-programStart {
+		return errors.Wrapf(ErrProgramTooLarge, "%d bytes", len(program))
+	}
+	copy(c.memory[programStart:], program)
+	return nil
+}
+
+// Screen returns a copy of the display buffer, one byte per pixel in row-major
+// order, where a non-zero byte is a lit pixel. Its dimensions are those
+// returned by Resolution. The byte is the pixel's colour: bit n is set when
+// the pixel is lit in XO-CHIP bit-plane n+1.
+func (c *CPU) Screen() []byte {
+	width, height := c.Resolution()
+	screen := make([]byte, width*height)
+	copy(screen, c.screen[:])
+	return screen
+}
+
+// ScreenHash returns the SHA-1 hash of the display resolution and contents,
+// which is the same for two runs exactly when they ended on the same frame.
+func (c *CPU) ScreenHash() [sha1.Size]byte {
+	width, height := c.Resolution()
+	h := sha1.New()
+	_, _ = h.Write([]byte{byte(width), byte(height)})
+	_, _ = h.Write(c.screen[:width*height])
+	var sum [sha1.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Resolution returns the width and height of the display in pixels, which
+// SUPER-CHIP programs can switch between 64x32 and 128x64.
+func (c *CPU) Resolution() (width, height int) {
+	if c.hires {
+		return HiResWidth, HiResHeight
+	}
+	return ScreenWidth, ScreenHeight
+}
+
+// SetXOCHIP turns XO-CHIP mode on or off. XO-CHIP mode extends addressable
+// memory to 64 KiB and enables the XO-CHIP instructions, which are unknown
+// opcodes otherwise.
+func (c *CPU) SetXOCHIP(enabled bool) {
+	c.xochip = enabled
+	c.memSize = MemorySize
+	if enabled {
+		c.memSize = XOCHIPMemorySize
+	}
+}
+
+// XOCHIP reports whether XO-CHIP mode is on.
+func (c *CPU) XOCHIP() bool {
+	return c.xochip
+}
+
+// AudioPattern returns the XO-CHIP audio pattern buffer, 128 bits played
+// from the most significant bit of the first byte while the sound timer is
+// running, and the rate in bits per second it is played at.
+func (c *CPU) AudioPattern() (pattern [16]byte, rate float64) {
+	return c.audio, 4000 * math.Pow(2, (float64(c.pitch)-defaultPitch)/48)
+}
+
+// Exited reports whether the program has exited with the SUPER-CHIP 00FD
+// instruction. An exited CPU stays on the exit instruction, so further
+// cycles do nothing.
+func (c *CPU) Exited() bool {
+	return c.exited
+}
+
+// RPLFlags returns the SUPER-CHIP RPL user flags, which programs save to
+// with Fx75 and load from with Fx85 to keep data such as high scores
+// between runs.
+func (c *CPU) RPLFlags() [16]byte {
+	return c.rpl
+}
+
+// SetRPLFlags sets the SUPER-CHIP RPL user flags, typically to those saved
+// by an earlier run of the program.
+func (c *CPU) SetRPLFlags(flags [16]byte) {
+	c.rpl = flags
+}
+
+// SetRandom replaces the source of random numbers, which is seeded from the
+// clock by default.
+func (c *CPU) SetRandom(r Random) {
+	c.rng = r
+}
+
+// Keys returns the state of the keypad as a bitmask, where bit n is set while
+// key n is held down.
+func (c *CPU) Keys() uint16 {
+	var keys uint16
+	for key, pressed := range c.keyboard {
+		if pressed != 0 {
+			keys |= 1 << uint(key)
+		}
+	}
+	return keys
+}
+
+// SetKeys sets the state of the whole keypad from a bitmask as returned by
+// Keys.
+func (c *CPU) SetKeys(keys uint16) {
+	for key := range c.keyboard {
+		c.keyboard[key] = byte(keys>>uint(key)) & 1
+	}
+}
+
+// SetKey sets the pressed state of one of the 16 keypad keys.
+func (c *CPU) SetKey(key byte, pressed bool) {
+	c.keyboard[key&0xf] = boolToByte(pressed)
+}
+
+// AnyKeyPressed reports whether any keypad key is held down.
+func (c *CPU) AnyKeyPressed() bool {
+	for _, pressed := range c.keyboard {
+		if pressed != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TickTimers decrements the delay and sound timers if they are non-zero.
+// It is meant to be called at 60 Hz regardless of the instruction rate.
+func (c *CPU) TickTimers() {
+	if c.delay > 0 {
+		c.delay--
+	}
+	if c.sound > 0 {
+		c.sound--
+	}
+}
+
+// SoundActive reports whether the sound timer is running, in which case the
+// buzzer should be sounding.
+func (c *CPU) SoundActive() bool {
+	return c.sound > 0
 }
 
 // Cycle performs one CPU cycle by fetching, decoding, and executing an opcode.
-func (c *CPU) Cycle() {
+// The program counter is advanced past the fetched opcode before it is executed,
+// so jumps, calls, and skips adjust pc relative to the next instruction.
+//
+// If the instruction fails, Cycle returns an *ExecutionError, leaves pc pointing
+// at the failing instruction, and faults the CPU. A faulted CPU executes nothing
+// and keeps returning the same error until ClearFault is called.
+func (c *CPU) Cycle() error {
+	if c.fault != nil {
+		return c.fault
+	}
+
+	pc := c.pc
+	if int(pc)+1 >= c.memSize {
+		return c.halt(pc, 0, errors.Wrapf(ErrMemoryOutOfBounds, "fetch at pc $%03x", pc))
+	}
+
 	// fetch the opcode corresponding to the current pc address
-	b := c.memory[c.pc : c.pc+2]
+	b := c.memory[pc : pc+2]
 	opcode := OpcodeFromBytes(b)
+	c.pc += 2
 
-	// decode the opcode operation
-	op := c.opDecoder(opcode)
-
-	// execute the operation on the CPU
-	if err := op(); err != nil {
-		log.Println(errors.WithStack(err))
-	}
-}
-
-func (c CPU) registerOpDecoder() {
-	var _0x0map = map[byte]operation{
-		0x00: c._0x0000,
-		0xe0: c._0x00E0,
-		0xee: c._0x00EE,
-	}
-	var _0x8map = map[byte]operation{
-		0x0: c._0x8xy0,
-		0x1: c._0x8xy1,
-		0x2: c._0x8xy2,
-		0x3: c._0x8xy3,
-		0x4: c._0x8xy4,
-		0x5: c._0x8xy5,
-		0x6: c._0x8xy6,
-		0x7: c._0x8xy7,
-		0xe: c._0x8xyE,
-	}
-	var _0xEmap = map[byte]operation{
-		0x9e: c._0xEx9E,
-		0xa1: c._0xExA1,
-	}
-	var _0xFmap = map[byte]operation{
-		0x07: c._0xFx07,
-		0x0a: c._0xFx0A,
-		0x15: c._0xFx15,
-		0x18: c._0xFx18,
-		0x1e: c._0xFx1E,
-		0x29: c._0xFx29,
-		0x33: c._0xFx33,
-		0x55: c._0xFx55,
-		0x65: c._0xFx65,
-	}
-
-	var opcodeMap = map[byte]func(byte) operation{
-		0x0: func(b byte) operation { return _0x0map[b] },
-		0x1: func(b byte) operation { return c._0x1nnn },
-		0x2: func(b byte) operation { return c._0x2nnn },
-		0x3: func(b byte) operation { return c._0x3xkk },
-		0x4: func(b byte) operation { return c._0x4xkk },
-		0x5: func(b byte) operation { return c._0x5xy0 },
-		0x6: func(b byte) operation { return c._0x6xkk },
-		0x7: func(b byte) operation { return c._0x7xkk },
-		0x8: func(b byte) operation { return _0x8map[b&0xf] },
-		0x9: func(b byte) operation { return c._0x9xy0 },
-		0xa: func(b byte) operation { return c._0xAnnn },
-		0xb: func(b byte) operation { return c._0xBnnn },
-		0xc: func(b byte) operation { return c._0xCxkk },
-		0xd: func(b byte) operation { return c._0xDxyn },
-		0xe: func(b byte) operation { return _0xEmap[b] },
-		0xf: func(b byte) operation { return _0xFmap[b] },
-	}
-
-	c.opDecoder = func(opcode Opcode) operation {
-		firstByte, secondByte := opcode.Bytes()
-
-		op := opcodeMap[firstByte](secondByte)
-		if op == nil {
-			return c.unknownOp
-		}
+	// decode the opcode
+	c.instruction.decode(opcode)
+	m := c.instruction.Mnemonic
+	if m.Extension() == ExtensionXOCHIP && !c.xochip {
+		m = OpUnknown
+	}
+
+	var before traceState
+	if c.tracer != nil {
+		before = c.traceState()
+	}
+	// kept for VIPCycles, as instructions such as DFyn overwrite it
+	c.vx = c.V[c.instruction.X]
+
+	// execute the instruction on the CPU
+	err := handlers[m](c)
+	if c.tracer != nil {
+		c.trace(pc, opcode, before, err)
+	}
+	if err != nil {
+		return c.halt(pc, opcode, err)
+	}
+	return nil
+}
+
+// Fault returns the error that halted the CPU, or nil if it is running.
+func (c *CPU) Fault() *ExecutionError {
+	return c.fault
+}
+
+// ClearFault clears a fault so that the CPU resumes execution at pc on the
+// next Cycle.
+func (c *CPU) ClearFault() {
+	c.fault = nil
+}
+
+// halt faults the CPU at the instruction at pc.
+func (c *CPU) halt(pc uint16, opcode Opcode, err error) error {
+	c.pc = pc
+	c.fault = &ExecutionError{PC: pc, Opcode: opcode, Err: err}
+	return c.fault
+}
+
+// x returns the second nibble of the current instruction.
+func (c *CPU) x() byte {
+	return c.instruction.X
+}
 
-		return op
+// y returns the third nibble of the current instruction.
+func (c *CPU) y() byte {
+	return c.instruction.Y
+}
+
+// n returns the lowest nibble of the current instruction.
+func (c *CPU) n() byte {
+	return c.instruction.N
+}
+
+// kk returns the lowest byte of the current instruction.
+func (c *CPU) kk() byte {
+	return c.instruction.KK
+}
+
+// nnn returns the lowest 12 bits of the current instruction.
+func (c *CPU) nnn() uint16 {
+	return c.instruction.NNN
+}
+
+// skipIf advances the program counter past the next instruction if cond is
+// true. In XO-CHIP mode the next instruction may be the 4 byte F000 nnnn.
+func (c *CPU) skipIf(cond bool) {
+	c.skipped = cond
+	if !cond {
+		return
+	}
+	if c.xochip && int(c.pc)+1 < c.memSize && OpcodeFromBytes(c.memory[c.pc:c.pc+2]).Size() == 4 {
+		c.pc += 2
 	}
+	c.pc += 2
+}
+
+// checkAddress returns ErrMemoryOutOfBounds if the n bytes starting at addr
+// do not fit in memory.
+func (c *CPU) checkAddress(addr uint16, n int) error {
+	if int(addr)+n > c.memSize {
+		return errors.Wrapf(ErrMemoryOutOfBounds, "access of %d bytes at $%04x", n, addr)
+	}
+	return nil
 }
 
 func (c *CPU) unknownOp() error {
 	return ErrUnknownOpcode
 }
 
+// _0x0000 is the SYS instruction, which jumps to a machine code routine on
+// the original hardware and is ignored by modern interpreters.
 func (c *CPU) _0x0000() error {
 	return nil
 }
 
+// _0x00E0 clears the selected bit-planes of the display.
 func (c *CPU) _0x00E0() error {
+	for i := range c.screen {
+		c.screen[i] &^= c.plane
+	}
+	return nil
+}
+
+// _0x00Cn scrolls the selected bit-planes of the display down n pixels.
+func (c *CPU) _0x00Cn() error {
+	c.scroll(0, int(c.n()))
+	return nil
+}
+
+// _0x00Dn scrolls the selected bit-planes of the display up n pixels.
+func (c *CPU) _0x00Dn() error {
+	c.scroll(0, -int(c.n()))
+	return nil
+}
+
+// _0x00FB scrolls the selected bit-planes of the display right 4 pixels.
+func (c *CPU) _0x00FB() error {
+	c.scroll(4, 0)
 	return nil
 }
 
+// _0x00FC scrolls the selected bit-planes of the display left 4 pixels.
+func (c *CPU) _0x00FC() error {
+	c.scroll(-4, 0)
+	return nil
+}
+
+// scroll moves the selected bit-planes of the display dx pixels right and dy
+// pixels down. Pixels scrolled off the edge are lost.
+func (c *CPU) scroll(dx, dy int) {
+	width, height := c.Resolution()
+	old := c.screen
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var moved byte
+			if sx, sy := x-dx, y-dy; sx >= 0 && sx < width && sy >= 0 && sy < height {
+				moved = old[sy*width+sx] & c.plane
+			}
+			pixel := &c.screen[y*width+x]
+			*pixel = *pixel&^c.plane | moved
+		}
+	}
+}
+
+// _0x00FD exits the interpreter. Exiting is done by rewinding pc so the
+// instruction executes again every cycle.
+func (c *CPU) _0x00FD() error {
+	c.exited = true
+	c.pc -= 2
+	return nil
+}
+
+// _0x00FE switches the display to low resolution and clears it.
+func (c *CPU) _0x00FE() error {
+	c.hires = false
+	c.screen = [len(c.screen)]byte{}
+	return nil
+}
+
+// _0x00FF switches the display to high resolution and clears it.
+func (c *CPU) _0x00FF() error {
+	c.hires = true
+	c.screen = [len(c.screen)]byte{}
+	return nil
+}
+
+// _0x00EE returns from a subroutine.
 func (c *CPU) _0x00EE() error {
+	if c.sp == 0 {
+		return ErrStackUnderflow
+	}
+	c.sp--
+	c.pc = c.stack[c.sp]
 	return nil
 }
 
+// _0x1nnn jumps to location nnn.
 func (c *CPU) _0x1nnn() error {
+	c.pc = c.nnn()
 	return nil
 }
 
+// _0x2nnn calls the subroutine at nnn.
 func (c *CPU) _0x2nnn() error {
+	if int(c.sp) >= len(c.stack) {
+		return ErrStackOverflow
+	}
+	c.stack[c.sp] = c.pc
+	c.sp++
+	c.pc = c.nnn()
 	return nil
 }
 
+// _0x3xkk skips the next instruction if Vx == kk.
 func (c *CPU) _0x3xkk() error {
+	c.skipIf(c.V[c.x()] == c.kk())
 	return nil
 }
 
+// _0x4xkk skips the next instruction if Vx != kk.
 func (c *CPU) _0x4xkk() error {
+	c.skipIf(c.V[c.x()] != c.kk())
 	return nil
 }
 
+// _0x5xy0 skips the next instruction if Vx == Vy.
 func (c *CPU) _0x5xy0() error {
+	c.skipIf(c.V[c.x()] == c.V[c.y()])
 	return nil
 }
 
+// _0x5xy2 stores registers Vx through Vy in memory starting at location I,
+// in reverse order if x is greater than y. I is not changed.
+func (c *CPU) _0x5xy2() error {
+	regs := c.registerSpan()
+	if err := c.checkAddress(c.I, len(regs)); err != nil {
+		return err
+	}
+	for i, r := range regs {
+		c.memory[int(c.I)+i] = c.V[r]
+	}
+	return nil
+}
+
+// _0x5xy3 reads registers Vx through Vy from memory starting at location I,
+// in reverse order if x is greater than y. I is not changed.
+func (c *CPU) _0x5xy3() error {
+	regs := c.registerSpan()
+	if err := c.checkAddress(c.I, len(regs)); err != nil {
+		return err
+	}
+	for i, r := range regs {
+		c.V[r] = c.memory[int(c.I)+i]
+	}
+	return nil
+}
+
+// registerSpan returns the registers from x to y inclusive, in that order.
+func (c *CPU) registerSpan() []byte {
+	x, y := c.x(), c.y()
+	step := 1
+	if x > y {
+		step = -1
+	}
+	regs := []byte{x}
+	for r := int(x); r != int(y); {
+		r += step
+		regs = append(regs, byte(r))
+	}
+	return regs
+}
+
+// _0x6xkk sets Vx = kk.
 func (c *CPU) _0x6xkk() error {
+	c.V[c.x()] = c.kk()
 	return nil
 }
 
+// _0x7xkk sets Vx = Vx + kk. The carry flag is not affected.
 func (c *CPU) _0x7xkk() error {
+	c.V[c.x()] += c.kk()
 	return nil
 }
 
+// _0x8xy0 sets Vx = Vy.
 func (c *CPU) _0x8xy0() error {
+	c.V[c.x()] = c.V[c.y()]
 	return nil
 }
 
+// _0x8xy1 sets Vx = Vx OR Vy.
 func (c *CPU) _0x8xy1() error {
+	c.V[c.x()] |= c.V[c.y()]
+	c.logicVF()
 	return nil
 }
 
+// _0x8xy2 sets Vx = Vx AND Vy.
 func (c *CPU) _0x8xy2() error {
+	c.V[c.x()] &= c.V[c.y()]
+	c.logicVF()
 	return nil
 }
 
+// _0x8xy3 sets Vx = Vx XOR Vy.
 func (c *CPU) _0x8xy3() error {
+	c.V[c.x()] ^= c.V[c.y()]
+	c.logicVF()
 	return nil
 }
 
+// logicVF resets VF after a logic operation if the quirk is enabled.
+func (c *CPU) logicVF() {
+	if c.Quirks.LogicResetsVF {
+		c.V[0xf] = 0
+	}
+}
+
+// _0x8xy4 sets Vx = Vx + Vy, and VF = carry. VF is written last so
+// that it holds the flag even when x is F.
 func (c *CPU) _0x8xy4() error {
+	sum := uint16(c.V[c.x()]) + uint16(c.V[c.y()])
+	c.V[c.x()] = byte(sum)
+	c.V[0xf] = boolToByte(sum > 0xff)
 	return nil
 }
 
+// _0x8xy5 sets Vx = Vx - Vy, and VF = NOT borrow.
 func (c *CPU) _0x8xy5() error {
+	vx, vy := c.V[c.x()], c.V[c.y()]
+	c.V[c.x()] = vx - vy
+	c.V[0xf] = boolToByte(vx >= vy)
 	return nil
 }
 
+// _0x8xy6 sets Vx = Vx SHR 1, and VF = the bit shifted out. With the
+// ShiftUsesVy quirk Vx = Vy SHR 1 instead.
 func (c *CPU) _0x8xy6() error {
+	v := c.shiftSource()
+	c.V[c.x()] = v >> 1
+	c.V[0xf] = v & 0x1
 	return nil
 }
 
+// _0x8xy7 sets Vx = Vy - Vx, and VF = NOT borrow.
 func (c *CPU) _0x8xy7() error {
+	vx, vy := c.V[c.x()], c.V[c.y()]
+	c.V[c.x()] = vy - vx
+	c.V[0xf] = boolToByte(vy >= vx)
 	return nil
 }
 
+// _0x8xyE sets Vx = Vx SHL 1, and VF = the bit shifted out. With the
+// ShiftUsesVy quirk Vx = Vy SHL 1 instead.
 func (c *CPU) _0x8xyE() error {
+	v := c.shiftSource()
+	c.V[c.x()] = v << 1
+	c.V[0xf] = v >> 7
 	return nil
 }
 
+// shiftSource returns the register value shifted by 8xy6 and 8xyE.
+func (c *CPU) shiftSource() byte {
+	if c.Quirks.ShiftUsesVy {
+		return c.V[c.y()]
+	}
+	return c.V[c.x()]
+}
+
+// _0x9xy0 skips the next instruction if Vx != Vy.
 func (c *CPU) _0x9xy0() error {
+	c.skipIf(c.V[c.x()] != c.V[c.y()])
 	return nil
 }
 
+// _0xAnnn sets I = nnn.
 func (c *CPU) _0xAnnn() error {
+	c.I = c.nnn()
 	return nil
 }
 
+// _0xBnnn jumps to location nnn + V0. With the JumpUsesVx quirk it is read
+// as BXnn and jumps to Xnn + VX instead.
 func (c *CPU) _0xBnnn() error {
+	offset := c.V[0]
+	if c.Quirks.JumpUsesVx {
+		offset = c.V[c.x()]
+	}
+	c.pc = c.nnn() + uint16(offset)
 	return nil
 }
 
+// _0xCxkk sets Vx = random byte AND kk.
 func (c *CPU) _0xCxkk() error {
+	c.V[c.x()] = byte(c.rng.Intn(0x100)) & c.kk()
 	return nil
 }
 
+// _0xDxyn draws the n-byte sprite starting at memory location I at (Vx, Vy),
+// and sets VF = collision. When n is 0 the SUPER-CHIP 16x16 sprite of 32
+// bytes, two per row, is drawn instead in high resolution or XO-CHIP mode,
+// and an 8x16 sprite of 16 bytes in low resolution, as SUPER-CHIP 1.1 does.
+// Sprites are XORed onto the screen and
+// wrap around its edges, unless the ClipSprites quirk is enabled. Either way
+// the starting position wraps.
+//
+// A sprite is drawn to each selected XO-CHIP bit-plane in turn, the sprite for
+// each plane following the previous one's in memory.
 func (c *CPU) _0xDxyn() error {
+	rows, cols := int(c.n()), 8
+	if rows == 0 {
+		rows = 16
+		if c.hires || c.xochip {
+			cols = 16
+		}
+	}
+	size := rows * cols / 8
+	planes := 0
+	for p := c.plane; p != 0; p >>= 1 {
+		planes += int(p & 1)
+	}
+	if err := c.checkAddress(c.I, size*planes); err != nil {
+		return err
+	}
+
+	collision := false
+	addr := int(c.I)
+	for bit := byte(1); bit != 0 && bit <= c.plane; bit <<= 1 {
+		if c.plane&bit == 0 {
+			continue
+		}
+		if c.drawSprite(addr, rows, cols, bit) {
+			collision = true
+		}
+		addr += size
+	}
+	c.V[0xf] = boolToByte(collision)
 	return nil
 }
 
+// drawSprite XORs the sprite at addr onto the bit-plane with the mask bit,
+// reporting whether any lit pixel was turned off.
+func (c *CPU) drawSprite(addr, rows, cols int, bit byte) bool {
+	width, height := c.Resolution()
+	x0, y0 := int(c.V[c.x()])%width, int(c.V[c.y()])%height
+	collision := false
+	for row := 0; row < rows; row++ {
+		if c.Quirks.ClipSprites && y0+row >= height {
+			break
+		}
+		var sprite uint16
+		if cols == 16 {
+			sprite = uint16(c.memory[addr+2*row])<<8 | uint16(c.memory[addr+2*row+1])
+		} else {
+			sprite = uint16(c.memory[addr+row]) << 8
+		}
+		y := (y0 + row) % height
+		for col := 0; col < cols; col++ {
+			if sprite&(0x8000>>uint(col)) == 0 {
+				continue
+			}
+			if c.Quirks.ClipSprites && x0+col >= width {
+				break
+			}
+			x := (x0 + col) % width
+			pixel := &c.screen[y*width+x]
+			if *pixel&bit != 0 {
+				collision = true
+			}
+			*pixel ^= bit
+		}
+	}
+	return collision
+}
+
+// _0xEx9E skips the next instruction if the key with the value of Vx is pressed.
 func (c *CPU) _0xEx9E() error {
+	c.skipIf(c.keyboard[c.V[c.x()]&0xf] != 0)
 	return nil
 }
 
+// _0xExA1 skips the next instruction if the key with the value of Vx is not pressed.
 func (c *CPU) _0xExA1() error {
+	c.skipIf(c.keyboard[c.V[c.x()]&0xf] == 0)
 	return nil
 }
 
+// _0xF000 sets I = nnnn, the 16 bit word following the opcode, and skips
+// over it.
+func (c *CPU) _0xF000() error {
+	if err := c.checkAddress(c.pc, 2); err != nil {
+		return err
+	}
+	c.I = uint16(c.memory[c.pc])<<8 | uint16(c.memory[c.pc+1])
+	c.pc += 2
+	return nil
+}
+
+// _0xFn01 selects the bit-planes n that are drawn to, cleared, and scrolled.
+func (c *CPU) _0xFn01() error {
+	c.plane = c.x()
+	return nil
+}
+
+// _0xF002 loads the 16 byte audio pattern buffer from memory starting at
+// location I.
+func (c *CPU) _0xF002() error {
+	if err := c.checkAddress(c.I, len(c.audio)); err != nil {
+		return err
+	}
+	copy(c.audio[:], c.memory[c.I:])
+	return nil
+}
+
+// _0xFx07 sets Vx = delay timer value.
 func (c *CPU) _0xFx07() error {
+	c.V[c.x()] = c.delay
 	return nil
 }
 
+// _0xFx0A waits for a key press and stores the value of the key in Vx. Waiting
+// is done by rewinding pc so the instruction executes again next cycle.
 func (c *CPU) _0xFx0A() error {
+	for k, pressed := range c.keyboard {
+		if pressed != 0 {
+			c.V[c.x()] = byte(k)
+			return nil
+		}
+	}
+	c.pc -= 2
 	return nil
 }
 
+// _0xFx15 sets delay timer = Vx.
 func (c *CPU) _0xFx15() error {
+	c.delay = c.V[c.x()]
 	return nil
 }
 
+// _0xFx18 sets sound timer = Vx.
 func (c *CPU) _0xFx18() error {
+	c.sound = c.V[c.x()]
 	return nil
 }
 
+// _0xFx1E sets I = I + Vx.
 func (c *CPU) _0xFx1E() error {
+	c.I += uint16(c.V[c.x()])
 	return nil
 }
 
+// _0xFx29 sets I = location of the 5-byte sprite for the hex digit in Vx.
 func (c *CPU) _0xFx29() error {
+	c.I = fontAddress + uint16(c.V[c.x()]&0xf)*fontGlyphSize
 	return nil
 }
 
+// _0xFx30 sets I = location of the 10-byte SUPER-CHIP sprite for the hex
+// digit in Vx.
+func (c *CPU) _0xFx30() error {
+	c.I = bigFontAddress + uint16(c.V[c.x()]&0xf)*bigFontGlyphSize
+	return nil
+}
+
+// _0xFx33 stores the BCD representation of Vx in memory locations I, I+1, and I+2.
 func (c *CPU) _0xFx33() error {
+	if err := c.checkAddress(c.I, 3); err != nil {
+		return err
+	}
+	vx := c.V[c.x()]
+	c.memory[c.I] = vx / 100
+	c.memory[c.I+1] = vx / 10 % 10
+	c.memory[c.I+2] = vx % 10
 	return nil
 }
 
+// _0xFx3A sets the pitch register = Vx, which sets the rate the audio
+// pattern is played at.
+func (c *CPU) _0xFx3A() error {
+	c.pitch = c.V[c.x()]
+	return nil
+}
+
+// _0xFx55 stores registers V0 through Vx in memory starting at location I,
+// then moves I as set by the LoadStoreIncrement quirk.
 func (c *CPU) _0xFx55() error {
+	n := int(c.x()) + 1
+	if err := c.checkAddress(c.I, n); err != nil {
+		return err
+	}
+	copy(c.memory[c.I:], c.V[:n])
+	c.incrementI()
 	return nil
 }
 
+// _0xFx65 reads registers V0 through Vx from memory starting at location I,
+// then moves I as set by the LoadStoreIncrement quirk.
 func (c *CPU) _0xFx65() error {
+	n := int(c.x()) + 1
+	if err := c.checkAddress(c.I, n); err != nil {
+		return err
+	}
+	copy(c.V[:n], c.memory[c.I:])
+	c.incrementI()
+	return nil
+}
+
+// _0xFx75 stores registers V0 through Vx in the RPL user flags.
+func (c *CPU) _0xFx75() error {
+	if err := c.checkRPL(); err != nil {
+		return err
+	}
+	copy(c.rpl[:], c.V[:c.x()+1])
 	return nil
 }
+
+// _0xFx85 reads registers V0 through Vx from the RPL user flags.
+func (c *CPU) _0xFx85() error {
+	if err := c.checkRPL(); err != nil {
+		return err
+	}
+	copy(c.V[:c.x()+1], c.rpl[:])
+	return nil
+}
+
+// checkRPL returns ErrRPLOutOfBounds if Fx75 or Fx85 uses more RPL user
+// flags than there are: 8 in SUPER-CHIP, and 16 in XO-CHIP mode.
+func (c *CPU) checkRPL() error {
+	if x := c.x(); x > 7 && !c.xochip {
+		return errors.Wrapf(ErrRPLOutOfBounds, "V0-V%X needs XO-CHIP mode", x)
+	}
+	return nil
+}
+
+// incrementI moves I past the registers stored or loaded by Fx55 and Fx65.
+func (c *CPU) incrementI() {
+	switch c.Quirks.LoadStoreIncrement {
+	case IncrementX:
+		c.I += uint16(c.x())
+	case IncrementXPlusOne:
+		c.I += uint16(c.x()) + 1
+	}
+}
+
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,701 @@
+package cpu
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCPU returns a CPU with the passed opcodes loaded at the program start address.
+func newTestCPU(opcodes ...Opcode) *CPU {
+	c := NewCPU()
+	c.SetRandom(rand.New(rand.NewSource(1)))
+	for i, o := range opcodes {
+		first, second := o.Bytes()
+		c.memory[programStart+2*i] = first
+		c.memory[programStart+2*i+1] = second
+	}
+	return c
+}
+
+func TestCPU_Cycle(t *testing.T) {
+	type testCase struct {
+		label  string
+		opcode Opcode
+		setup  func(c *CPU)
+		check  func(t *testing.T, c *CPU)
+	}
+	cases := []testCase{
+		{
+			label:  "0nnn is ignored",
+			opcode: 0x0000,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "00E0 clear the display",
+			opcode: 0x00E0,
+			setup: func(c *CPU) {
+				c.screen[0], c.screen[100], c.screen[2047] = 1, 1, 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, [HiResWidth * HiResHeight]byte{}, c.screen)
+			},
+		},
+		{
+			label:  "00EE return from a subroutine",
+			opcode: 0x00EE,
+			setup: func(c *CPU) {
+				c.stack[0] = 0x300
+				c.sp = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x300), c.pc)
+				assert.Equal(t, uint16(0), c.sp)
+			},
+		},
+		{
+			label:  "1nnn jump to location nnn",
+			opcode: 0x128A,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x28a), c.pc)
+			},
+		},
+		{
+			label:  "2nnn call subroutine at nnn",
+			opcode: 0x228A,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x28a), c.pc)
+				assert.Equal(t, uint16(1), c.sp)
+				assert.Equal(t, uint16(0x202), c.stack[0])
+			},
+		},
+		{
+			label:  "3xkk skip next instruction if Vx == kk",
+			opcode: 0x3A12,
+			setup: func(c *CPU) {
+				c.V[0xa] = 0x12
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:  "3xkk don't skip next instruction if Vx != kk",
+			opcode: 0x3A12,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "4xkk skip next instruction if Vx != kk",
+			opcode: 0x4812,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:  "4xkk don't skip next instruction if Vx == kk",
+			opcode: 0x4812,
+			setup: func(c *CPU) {
+				c.V[0x8] = 0x12
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "5xy0 skip next instruction if Vx == Vy",
+			opcode: 0x5A70,
+			setup: func(c *CPU) {
+				c.V[0xa], c.V[0x7] = 0x33, 0x33
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:  "5xy0 don't skip next instruction if Vx != Vy",
+			opcode: 0x5A70,
+			setup: func(c *CPU) {
+				c.V[0xa], c.V[0x7] = 0x33, 0x34
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "6xkk set Vx = kk",
+			opcode: 0x6208,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x08), c.V[0x2])
+			},
+		},
+		{
+			label:  "7xkk set Vx = Vx + kk without touching VF",
+			opcode: 0x7BF3,
+			setup: func(c *CPU) {
+				c.V[0xb] = 0x20
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x13), c.V[0xb])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy0 set Vx = Vy",
+			opcode: 0x83A0,
+			setup: func(c *CPU) {
+				c.V[0xa] = 0x42
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x42), c.V[0x3])
+			},
+		},
+		{
+			label:  "8xy1 set Vx = Vx OR Vy",
+			opcode: 0x83A1,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xa] = 0xf0, 0x0f
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0xff), c.V[0x3])
+			},
+		},
+		{
+			label:  "8xy2 set Vx = Vx AND Vy",
+			opcode: 0x83A2,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xa] = 0xf3, 0x0f
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x03), c.V[0x3])
+			},
+		},
+		{
+			label:  "8xy3 set Vx = Vx XOR Vy",
+			opcode: 0x83A3,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xa] = 0xff, 0x0f
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0xf0), c.V[0x3])
+			},
+		},
+		{
+			label:  "8xy4 set Vx = Vx + Vy without carry",
+			opcode: 0x83B4,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb], c.V[0xf] = 0x10, 0x20, 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x30), c.V[0x3])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy4 set Vx = Vx + Vy with carry",
+			opcode: 0x83B4,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb] = 0xf0, 0x20
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x10), c.V[0x3])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy4 VF holds the carry when x is F",
+			opcode: 0x8F04,
+			setup: func(c *CPU) {
+				c.V[0xf], c.V[0x0] = 0x10, 0x20
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy5 set Vx = Vx - Vy without borrow",
+			opcode: 0x83B5,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb] = 0x30, 0x20
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x10), c.V[0x3])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy5 set Vx = Vx - Vy with equal operands",
+			opcode: 0x83B5,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb] = 0x30, 0x30
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x00), c.V[0x3])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy5 set Vx = Vx - Vy with borrow",
+			opcode: 0x83B5,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb], c.V[0xf] = 0x10, 0x20, 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0xf0), c.V[0x3])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy6 set Vx = Vx SHR 1",
+			opcode: 0x83B6,
+			setup: func(c *CPU) {
+				c.V[0x3] = 0x05
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x02), c.V[0x3])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy7 set Vx = Vy - Vx without borrow",
+			opcode: 0x83B7,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb] = 0x20, 0x30
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x10), c.V[0x3])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy7 set Vx = Vy - Vx with borrow",
+			opcode: 0x83B7,
+			setup: func(c *CPU) {
+				c.V[0x3], c.V[0xb], c.V[0xf] = 0x30, 0x20, 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0xf0), c.V[0x3])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xyE set Vx = Vx SHL 1",
+			opcode: 0x83BE,
+			setup: func(c *CPU) {
+				c.V[0x3] = 0x81
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x02), c.V[0x3])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "9xy0 skip next instruction if Vx != Vy",
+			opcode: 0x93B0,
+			setup: func(c *CPU) {
+				c.V[0x3] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:  "9xy0 don't skip next instruction if Vx == Vy",
+			opcode: 0x93B0,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "Annn set I = nnn",
+			opcode: 0xA220,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x220), c.I)
+			},
+		},
+		{
+			label:  "Bnnn jump to location nnn + V0",
+			opcode: 0xB290,
+			setup: func(c *CPU) {
+				c.V[0x0] = 0x10
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x2a0), c.pc)
+			},
+		},
+		{
+			label:  "Cxkk set Vx = random byte AND kk",
+			opcode: 0xC90F,
+			setup: func(c *CPU) {
+				c.V[0x9] = 0xff
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.V[0x9]&0xf0)
+			},
+		},
+		{
+			label:  "Dxyn draw sprite without collision",
+			opcode: 0xD012,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0xc0, 0x81
+				c.V[0x0], c.V[0x1] = 2, 3
+				c.V[0xf] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[3*64+2])
+				assert.Equal(t, byte(1), c.screen[3*64+3])
+				assert.Equal(t, byte(0), c.screen[3*64+4])
+				assert.Equal(t, byte(1), c.screen[4*64+2])
+				assert.Equal(t, byte(1), c.screen[4*64+9])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "Dxyn draw sprite with collision",
+			opcode: 0xD011,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300] = 0xff
+				c.screen[0] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.screen[0])
+				assert.Equal(t, byte(1), c.screen[1])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "Dxyn sprite wraps around the screen edges",
+			opcode: 0xD012,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0xc0, 0xc0
+				c.V[0x0], c.V[0x1] = 63, 31
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[31*64+63])
+				assert.Equal(t, byte(1), c.screen[31*64+0])
+				assert.Equal(t, byte(1), c.screen[0*64+63])
+				assert.Equal(t, byte(1), c.screen[0*64+0])
+			},
+		},
+		{
+			label:  "Ex9E skip next instruction if key Vx is pressed",
+			opcode: 0xE79E,
+			setup: func(c *CPU) {
+				c.V[0x7] = 0xa
+				c.keyboard[0xa] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:  "Ex9E don't skip next instruction if key Vx is not pressed",
+			opcode: 0xE79E,
+			setup: func(c *CPU) {
+				c.V[0x7] = 0xa
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "ExA1 skip next instruction if key Vx is not pressed",
+			opcode: 0xE7A1,
+			setup: func(c *CPU) {
+				c.V[0x7] = 0xa
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:  "ExA1 don't skip next instruction if key Vx is pressed",
+			opcode: 0xE7A1,
+			setup: func(c *CPU) {
+				c.V[0x7] = 0xa
+				c.keyboard[0xa] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+			},
+		},
+		{
+			label:  "Fx07 set Vx = delay timer value",
+			opcode: 0xF907,
+			setup: func(c *CPU) {
+				c.delay = 0x3c
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x3c), c.V[0x9])
+			},
+		},
+		{
+			label:  "Fx0A wait for a key press",
+			opcode: 0xFC0A,
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x200), c.pc)
+			},
+		},
+		{
+			label:  "Fx0A store the value of the pressed key in Vx",
+			opcode: 0xFC0A,
+			setup: func(c *CPU) {
+				c.keyboard[0x5] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x202), c.pc)
+				assert.Equal(t, byte(0x5), c.V[0xc])
+			},
+		},
+		{
+			label:  "Fx15 set delay timer = Vx",
+			opcode: 0xF215,
+			setup: func(c *CPU) {
+				c.V[0x2] = 0x3c
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x3c), c.delay)
+			},
+		},
+		{
+			label:  "Fx18 set sound timer = Vx",
+			opcode: 0xF318,
+			setup: func(c *CPU) {
+				c.V[0x3] = 0x3c
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x3c), c.sound)
+			},
+		},
+		{
+			label:  "Fx1E set I = I + Vx",
+			opcode: 0xF81E,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.V[0x8] = 0x20
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x320), c.I)
+			},
+		},
+		{
+			label:  "Fx29 set I = location of sprite for digit Vx",
+			opcode: 0xFD29,
+			setup: func(c *CPU) {
+				c.V[0xd] = 0xa
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x050+50), c.I)
+			},
+		},
+		{
+			label:  "Fx30 set I = location of large sprite for digit Vx",
+			opcode: 0xFD30,
+			setup: func(c *CPU) {
+				c.V[0xd] = 0xa
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x0a0+100), c.I)
+			},
+		},
+		{
+			label:  "Fx33 store BCD representation of Vx",
+			opcode: 0xF533,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.V[0x5] = 254
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{2, 5, 4}, c.memory[0x300:0x303])
+			},
+		},
+		{
+			label:  "Fx55 store registers V0 through Vx",
+			opcode: 0xF255,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.V[0x0], c.V[0x1], c.V[0x2], c.V[0x3] = 1, 2, 3, 4
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{1, 2, 3, 0}, c.memory[0x300:0x304])
+				assert.Equal(t, uint16(0x300), c.I)
+			},
+		},
+		{
+			label:  "Fx65 read registers V0 through Vx",
+			opcode: 0xF265,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				copy(c.memory[0x300:], []byte{1, 2, 3, 4})
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{1, 2, 3, 0}, c.V[:4])
+				assert.Equal(t, uint16(0x300), c.I)
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			testCPU := newTestCPU(c.opcode)
+			if c.setup != nil {
+				c.setup(testCPU)
+			}
+			require.NoError(t, testCPU.Cycle())
+			c.check(t, testCPU)
+		})
+	}
+}
+
+func TestCPU_CallAndReturn(t *testing.T) {
+	testCPU := newTestCPU(
+		0x2206, // 0x200: CALL $206
+		0x6101, // 0x202: MVI V1,#$01
+		0x1204, // 0x204: JUMP $204
+		0x6001, // 0x206: MVI V0,#$01
+		0x00EE, // 0x208: RTS
+	)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, testCPU.Cycle())
+	}
+
+	assert.Equal(t, byte(1), testCPU.V[0x0])
+	assert.Equal(t, byte(1), testCPU.V[0x1])
+	assert.Equal(t, uint16(0x204), testCPU.pc)
+	assert.Equal(t, uint16(0), testCPU.sp)
+}
+
+func TestCPU_StackErrors(t *testing.T) {
+	testCPU := newTestCPU(0x00EE)
+	testCPU.instruction = Decode(0x00EE)
+	require.Equal(t, ErrStackUnderflow, testCPU._0x00EE())
+
+	testCPU.sp = uint16(len(testCPU.stack))
+	testCPU.instruction = Decode(0x2300)
+	require.Equal(t, ErrStackOverflow, testCPU._0x2nnn())
+}
+
+func TestCPU_MemoryOutOfBounds(t *testing.T) {
+	testCPU := newTestCPU()
+	testCPU.I = 0xffe
+	testCPU.instruction = Decode(0xF033)
+	err := testCPU._0xFx33()
+	require.Error(t, err)
+	assert.Equal(t, ErrMemoryOutOfBounds, errors.Cause(err))
+}
+
+func TestCPU_Cycle_Fault(t *testing.T) {
+	testCPU := newTestCPU(
+		0x6001, // 0x200: MVI V0,#$01
+		0xE000, // 0x202: UNK
+		0x6101, // 0x204: MVI V1,#$01
+	)
+	require.NoError(t, testCPU.Cycle())
+	assert.Nil(t, testCPU.Fault())
+
+	err := testCPU.Cycle()
+	require.Error(t, err)
+	execErr, ok := err.(*ExecutionError)
+	require.True(t, ok, "expected *ExecutionError, got %T", err)
+	assert.Equal(t, uint16(0x202), execErr.PC)
+	assert.Equal(t, Opcode(0xE000), execErr.Opcode)
+	assert.Equal(t, ErrUnknownOpcode, errors.Cause(err))
+	assert.Equal(t, "execution fault at $202 (e000 UNK        0xe000): unknown opcode", err.Error())
+
+	assert.Equal(t, execErr, testCPU.Fault())
+	assert.Equal(t, uint16(0x202), testCPU.pc)
+
+	assert.Equal(t, err, testCPU.Cycle(), "faulted CPU should keep returning the fault")
+	assert.Equal(t, uint16(0x202), testCPU.pc)
+
+	testCPU.ClearFault()
+	testCPU.pc = 0x204
+	require.NoError(t, testCPU.Cycle())
+	assert.Equal(t, byte(1), testCPU.V[0x1])
+}
+
+func TestCPU_Cycle_FetchOutOfBounds(t *testing.T) {
+	testCPU := newTestCPU()
+	testCPU.pc = 0xfff
+
+	err := testCPU.Cycle()
+	require.Error(t, err)
+	assert.Equal(t, ErrMemoryOutOfBounds, errors.Cause(err))
+	assert.Equal(t, uint16(0xfff), testCPU.Fault().PC)
+}
+
+func TestCPU_SetRandom(t *testing.T) {
+	run := func(seed int64) [16]byte {
+		c := newTestCPU()
+		c.SetRandom(rand.New(rand.NewSource(seed)))
+		for x := range c.V {
+			c.instruction = Decode(Opcode(0xc0ff | x<<8))
+			require.NoError(t, c._0xCxkk())
+		}
+		return c.V
+	}
+
+	assert.Equal(t, run(42), run(42), "the same seed should produce the same numbers")
+	assert.NotEqual(t, run(42), run(43))
+}
+
+func TestCPU_Keys(t *testing.T) {
+	c := newTestCPU()
+	c.SetKey(0x1, true)
+	c.SetKey(0xf, true)
+	assert.Equal(t, uint16(0x8002), c.Keys())
+
+	c.SetKeys(0x0104)
+	assert.Equal(t, uint16(0x0104), c.Keys())
+	assert.Equal(t, byte(1), c.keyboard[0x2])
+	assert.Equal(t, byte(1), c.keyboard[0x8])
+	assert.Equal(t, byte(0), c.keyboard[0xf])
+}
+
+func TestCPU_ScreenHash(t *testing.T) {
+	c := newTestCPU()
+	blank := c.ScreenHash()
+
+	c.screen[5] = 1
+	lit := c.ScreenHash()
+	assert.NotEqual(t, blank, lit)
+
+	c.screen[5] = 0
+	assert.Equal(t, blank, c.ScreenHash())
+	c.hires = true
+	assert.NotEqual(t, blank, c.ScreenHash(), "the resolution should be part of the hash")
+}
+
+// benchmarkProgram is a loop of common instructions from every family that
+// is decoded through a nested table, so the cost of decoding shows.
+var benchmarkProgram = []Opcode{
+	0x6001, // MVI        V0,#$01
+	0x7102, // ADI        V1,#$02
+	0x8014, // ADD.       V0,V1
+	0x8122, // AND        V1,V2
+	0x8306, // SHR.       V3,V0
+	0x3000, // SKIP.EQ    V0,#$00
+	0xa300, // MVI        I,#$300
+	0xf01e, // ADD        I,V0
+	0xf007, // MOV        V0,DELAY
+	0xe09e, // SKIP.KEY   V0
+	0x1200, // JUMP       $200
+}
+
+func BenchmarkCPU_Cycle(b *testing.B) {
+	c := newTestCPU(benchmarkProgram...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Cycle(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
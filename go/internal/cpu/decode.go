@@ -0,0 +1,452 @@
+package cpu
+
+// Mnemonic identifies the instruction an opcode encodes, independent of its
+// operands. The names follow the opcode patterns of Cowgod's technical
+// reference, since each syntax spells the instructions differently.
+type Mnemonic uint8
+
+const (
+	// OpUnknown is an opcode that isn't an instruction.
+	OpUnknown Mnemonic = iota
+	Op0nnn
+	Op00Cn
+	Op00Dn
+	Op00E0
+	Op00EE
+	Op00FB
+	Op00FC
+	Op00FD
+	Op00FE
+	Op00FF
+	Op1nnn
+	Op2nnn
+	Op3xkk
+	Op4xkk
+	Op5xy0
+	Op5xy2
+	Op5xy3
+	Op6xkk
+	Op7xkk
+	Op8xy0
+	Op8xy1
+	Op8xy2
+	Op8xy3
+	Op8xy4
+	Op8xy5
+	Op8xy6
+	Op8xy7
+	Op8xyE
+	Op9xy0
+	OpAnnn
+	OpBnnn
+	OpCxkk
+	OpDxyn
+	OpEx9E
+	OpExA1
+	OpF000
+	OpFn01
+	OpF002
+	OpFx07
+	OpFx0A
+	OpFx15
+	OpFx18
+	OpFx1E
+	OpFx29
+	OpFx30
+	OpFx33
+	OpFx3A
+	OpFx55
+	OpFx65
+	OpFx75
+	OpFx85
+
+	numMnemonics
+)
+
+// Length returns the size in bytes of instructions with the mnemonic.
+func (m Mnemonic) Length() int {
+	return encodings[m].length
+}
+
+// Extension returns the variant that introduced the mnemonic.
+func (m Mnemonic) Extension() Extension {
+	return encodings[m].extension
+}
+
+// Extension is the CHIP-8 variant that introduced an instruction.
+type Extension uint8
+
+const (
+	// ExtensionCHIP8 instructions are part of the original CHIP-8.
+	ExtensionCHIP8 Extension = iota
+	// ExtensionSCHIP instructions were added by SUPER-CHIP.
+	ExtensionSCHIP
+	// ExtensionXOCHIP instructions were added by XO-CHIP, and are unknown
+	// opcodes outside of XO-CHIP mode.
+	ExtensionXOCHIP
+)
+
+// DecodedInstruction is an opcode broken down into the instruction it
+// encodes and its operands. Every operand field is filled in from the opcode
+// whether or not the instruction uses it.
+type DecodedInstruction struct {
+	Mnemonic Mnemonic
+	// X and Y are the second and third nibbles, usually registers.
+	X, Y byte
+	// N is the lowest nibble.
+	N byte
+	// KK is the lowest byte.
+	KK byte
+	// NNN is the lowest 12 bits, usually an address.
+	NNN uint16
+	// Operand is the word following the opcode of a 4 byte instruction. It
+	// is only set by DecodeLong.
+	Operand uint16
+	// Opcode is the opcode the instruction was decoded from.
+	Opcode Opcode
+}
+
+// Decode breaks the opcode down into the instruction it encodes and its
+// operands. The operand of a 4 byte instruction isn't part of the opcode, so
+// it is left at 0; use DecodeLong to include it.
+func Decode(o Opcode) DecodedInstruction {
+	var in DecodedInstruction
+	in.decode(o)
+	return in
+}
+
+// decode fills in the fields of the instruction that come from the opcode in
+// place, which saves the CPU building a copy every cycle.
+func (in *DecodedInstruction) decode(o Opcode) {
+	in.Mnemonic = mnemonics[o]
+	in.X = byte(o>>8) & 0xf
+	in.Y = byte(o>>4) & 0xf
+	in.N = byte(o) & 0xf
+	in.KK = byte(o)
+	in.NNN = uint16(o) & 0xfff
+	in.Opcode = o
+}
+
+// DecodeLong decodes a 4 byte instruction, given the word following the
+// opcode. Opcodes of 2 byte instructions ignore the word.
+func DecodeLong(o Opcode, operand uint16) DecodedInstruction {
+	in := Decode(o)
+	if in.Length() == 4 {
+		in.Operand = operand
+	}
+	return in
+}
+
+// Length returns the size of the instruction in bytes.
+func (in DecodedInstruction) Length() int {
+	return in.Mnemonic.Length()
+}
+
+// Extension returns the variant that introduced the instruction.
+func (in DecodedInstruction) Extension() Extension {
+	return in.Mnemonic.Extension()
+}
+
+// Encode returns the opcode of the instruction, built from its mnemonic and
+// the operands it uses. Unknown instructions return the opcode they were
+// decoded from.
+func (in DecodedInstruction) Encode() Opcode {
+	e := encodings[in.Mnemonic]
+	if in.Mnemonic == OpUnknown {
+		return in.Opcode
+	}
+
+	o := e.base
+	if e.operands&operandX != 0 {
+		o |= Opcode(in.X&0xf) << 8
+	}
+	if e.operands&operandY != 0 {
+		o |= Opcode(in.Y&0xf) << 4
+	}
+	if e.operands&operandN != 0 {
+		o |= Opcode(in.N & 0xf)
+	}
+	if e.operands&operandKK != 0 {
+		o |= Opcode(in.KK)
+	}
+	if e.operands&operandNNN != 0 {
+		o |= Opcode(in.NNN & 0xfff)
+	}
+	return o
+}
+
+// mnemonics holds the mnemonic of every opcode, so decoding is a single
+// lookup.
+var mnemonics [0x10000]Mnemonic
+
+func init() {
+	for o := range mnemonics {
+		mnemonics[o] = decodeMnemonic(Opcode(o))
+	}
+}
+
+// decodeMnemonic works out the mnemonic of an opcode. It is only used to
+// fill in mnemonics; use Decode instead.
+func decodeMnemonic(o Opcode) Mnemonic {
+	x, y, n, kk := byte(o>>8)&0xf, byte(o>>4)&0xf, byte(o)&0xf, byte(o)
+
+	switch o >> 12 {
+	case 0x0:
+		// the 00xx instructions need the second nibble to be 0, and every
+		// other opcode is SYS
+		if x != 0 {
+			return Op0nnn
+		}
+		switch {
+		case y == 0xc:
+			return Op00Cn
+		case y == 0xd:
+			return Op00Dn
+		}
+		switch kk {
+		case 0xe0:
+			return Op00E0
+		case 0xee:
+			return Op00EE
+		case 0xfb:
+			return Op00FB
+		case 0xfc:
+			return Op00FC
+		case 0xfd:
+			return Op00FD
+		case 0xfe:
+			return Op00FE
+		case 0xff:
+			return Op00FF
+		}
+		return Op0nnn
+	case 0x1:
+		return Op1nnn
+	case 0x2:
+		return Op2nnn
+	case 0x3:
+		return Op3xkk
+	case 0x4:
+		return Op4xkk
+	case 0x5:
+		switch n {
+		case 0x0:
+			return Op5xy0
+		case 0x2:
+			return Op5xy2
+		case 0x3:
+			return Op5xy3
+		}
+	case 0x6:
+		return Op6xkk
+	case 0x7:
+		return Op7xkk
+	case 0x8:
+		switch n {
+		case 0x0:
+			return Op8xy0
+		case 0x1:
+			return Op8xy1
+		case 0x2:
+			return Op8xy2
+		case 0x3:
+			return Op8xy3
+		case 0x4:
+			return Op8xy4
+		case 0x5:
+			return Op8xy5
+		case 0x6:
+			return Op8xy6
+		case 0x7:
+			return Op8xy7
+		case 0xe:
+			return Op8xyE
+		}
+	case 0x9:
+		return Op9xy0
+	case 0xa:
+		return OpAnnn
+	case 0xb:
+		return OpBnnn
+	case 0xc:
+		return OpCxkk
+	case 0xd:
+		return OpDxyn
+	case 0xe:
+		switch kk {
+		case 0x9e:
+			return OpEx9E
+		case 0xa1:
+			return OpExA1
+		}
+	case 0xf:
+		switch {
+		case o == 0xf000:
+			return OpF000
+		case o == 0xf002:
+			return OpF002
+		}
+		switch kk {
+		case 0x01:
+			return OpFn01
+		case 0x07:
+			return OpFx07
+		case 0x0a:
+			return OpFx0A
+		case 0x15:
+			return OpFx15
+		case 0x18:
+			return OpFx18
+		case 0x1e:
+			return OpFx1E
+		case 0x29:
+			return OpFx29
+		case 0x30:
+			return OpFx30
+		case 0x33:
+			return OpFx33
+		case 0x3a:
+			return OpFx3A
+		case 0x55:
+			return OpFx55
+		case 0x65:
+			return OpFx65
+		case 0x75:
+			return OpFx75
+		case 0x85:
+			return OpFx85
+		}
+	}
+	return OpUnknown
+}
+
+// operands is a set of the operand fields an instruction uses.
+type operands uint8
+
+const (
+	operandX operands = 1 << iota
+	operandY
+	operandN
+	operandKK
+	operandNNN
+)
+
+// encoding describes how a mnemonic is encoded: the opcode with every
+// operand 0, and the operands filled in on top of it.
+type encoding struct {
+	base      Opcode
+	operands  operands
+	length    int
+	extension Extension
+}
+
+var encodings = [numMnemonics]encoding{
+	OpUnknown: {length: 2},
+	Op0nnn:    {0x0000, operandNNN, 2, ExtensionCHIP8},
+	Op00Cn:    {0x00c0, operandN, 2, ExtensionSCHIP},
+	Op00Dn:    {0x00d0, operandN, 2, ExtensionXOCHIP},
+	Op00E0:    {0x00e0, 0, 2, ExtensionCHIP8},
+	Op00EE:    {0x00ee, 0, 2, ExtensionCHIP8},
+	Op00FB:    {0x00fb, 0, 2, ExtensionSCHIP},
+	Op00FC:    {0x00fc, 0, 2, ExtensionSCHIP},
+	Op00FD:    {0x00fd, 0, 2, ExtensionSCHIP},
+	Op00FE:    {0x00fe, 0, 2, ExtensionSCHIP},
+	Op00FF:    {0x00ff, 0, 2, ExtensionSCHIP},
+	Op1nnn:    {0x1000, operandNNN, 2, ExtensionCHIP8},
+	Op2nnn:    {0x2000, operandNNN, 2, ExtensionCHIP8},
+	Op3xkk:    {0x3000, operandX | operandKK, 2, ExtensionCHIP8},
+	Op4xkk:    {0x4000, operandX | operandKK, 2, ExtensionCHIP8},
+	Op5xy0:    {0x5000, operandX | operandY, 2, ExtensionCHIP8},
+	Op5xy2:    {0x5002, operandX | operandY, 2, ExtensionXOCHIP},
+	Op5xy3:    {0x5003, operandX | operandY, 2, ExtensionXOCHIP},
+	Op6xkk:    {0x6000, operandX | operandKK, 2, ExtensionCHIP8},
+	Op7xkk:    {0x7000, operandX | operandKK, 2, ExtensionCHIP8},
+	Op8xy0:    {0x8000, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy1:    {0x8001, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy2:    {0x8002, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy3:    {0x8003, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy4:    {0x8004, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy5:    {0x8005, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy6:    {0x8006, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xy7:    {0x8007, operandX | operandY, 2, ExtensionCHIP8},
+	Op8xyE:    {0x800e, operandX | operandY, 2, ExtensionCHIP8},
+	Op9xy0:    {0x9000, operandX | operandY, 2, ExtensionCHIP8},
+	OpAnnn:    {0xa000, operandNNN, 2, ExtensionCHIP8},
+	OpBnnn:    {0xb000, operandNNN, 2, ExtensionCHIP8},
+	OpCxkk:    {0xc000, operandX | operandKK, 2, ExtensionCHIP8},
+	OpDxyn:    {0xd000, operandX | operandY | operandN, 2, ExtensionCHIP8},
+	OpEx9E:    {0xe09e, operandX, 2, ExtensionCHIP8},
+	OpExA1:    {0xe0a1, operandX, 2, ExtensionCHIP8},
+	OpF000:    {0xf000, 0, 4, ExtensionXOCHIP},
+	OpFn01:    {0xf001, operandX, 2, ExtensionXOCHIP},
+	OpF002:    {0xf002, 0, 2, ExtensionXOCHIP},
+	OpFx07:    {0xf007, operandX, 2, ExtensionCHIP8},
+	OpFx0A:    {0xf00a, operandX, 2, ExtensionCHIP8},
+	OpFx15:    {0xf015, operandX, 2, ExtensionCHIP8},
+	OpFx18:    {0xf018, operandX, 2, ExtensionCHIP8},
+	OpFx1E:    {0xf01e, operandX, 2, ExtensionCHIP8},
+	OpFx29:    {0xf029, operandX, 2, ExtensionCHIP8},
+	OpFx30:    {0xf030, operandX, 2, ExtensionSCHIP},
+	OpFx33:    {0xf033, operandX, 2, ExtensionCHIP8},
+	OpFx3A:    {0xf03a, operandX, 2, ExtensionXOCHIP},
+	OpFx55:    {0xf055, operandX, 2, ExtensionCHIP8},
+	OpFx65:    {0xf065, operandX, 2, ExtensionCHIP8},
+	OpFx75:    {0xf075, operandX, 2, ExtensionSCHIP},
+	OpFx85:    {0xf085, operandX, 2, ExtensionSCHIP},
+}
+
+// handlers executes each instruction on the CPU.
+var handlers = [numMnemonics]func(c *CPU) error{
+	OpUnknown: (*CPU).unknownOp,
+	Op0nnn:    (*CPU)._0x0000,
+	Op00Cn:    (*CPU)._0x00Cn,
+	Op00Dn:    (*CPU)._0x00Dn,
+	Op00E0:    (*CPU)._0x00E0,
+	Op00EE:    (*CPU)._0x00EE,
+	Op00FB:    (*CPU)._0x00FB,
+	Op00FC:    (*CPU)._0x00FC,
+	Op00FD:    (*CPU)._0x00FD,
+	Op00FE:    (*CPU)._0x00FE,
+	Op00FF:    (*CPU)._0x00FF,
+	Op1nnn:    (*CPU)._0x1nnn,
+	Op2nnn:    (*CPU)._0x2nnn,
+	Op3xkk:    (*CPU)._0x3xkk,
+	Op4xkk:    (*CPU)._0x4xkk,
+	Op5xy0:    (*CPU)._0x5xy0,
+	Op5xy2:    (*CPU)._0x5xy2,
+	Op5xy3:    (*CPU)._0x5xy3,
+	Op6xkk:    (*CPU)._0x6xkk,
+	Op7xkk:    (*CPU)._0x7xkk,
+	Op8xy0:    (*CPU)._0x8xy0,
+	Op8xy1:    (*CPU)._0x8xy1,
+	Op8xy2:    (*CPU)._0x8xy2,
+	Op8xy3:    (*CPU)._0x8xy3,
+	Op8xy4:    (*CPU)._0x8xy4,
+	Op8xy5:    (*CPU)._0x8xy5,
+	Op8xy6:    (*CPU)._0x8xy6,
+	Op8xy7:    (*CPU)._0x8xy7,
+	Op8xyE:    (*CPU)._0x8xyE,
+	Op9xy0:    (*CPU)._0x9xy0,
+	OpAnnn:    (*CPU)._0xAnnn,
+	OpBnnn:    (*CPU)._0xBnnn,
+	OpCxkk:    (*CPU)._0xCxkk,
+	OpDxyn:    (*CPU)._0xDxyn,
+	OpEx9E:    (*CPU)._0xEx9E,
+	OpExA1:    (*CPU)._0xExA1,
+	OpF000:    (*CPU)._0xF000,
+	OpFn01:    (*CPU)._0xFn01,
+	OpF002:    (*CPU)._0xF002,
+	OpFx07:    (*CPU)._0xFx07,
+	OpFx0A:    (*CPU)._0xFx0A,
+	OpFx15:    (*CPU)._0xFx15,
+	OpFx18:    (*CPU)._0xFx18,
+	OpFx1E:    (*CPU)._0xFx1E,
+	OpFx29:    (*CPU)._0xFx29,
+	OpFx30:    (*CPU)._0xFx30,
+	OpFx33:    (*CPU)._0xFx33,
+	OpFx3A:    (*CPU)._0xFx3A,
+	OpFx55:    (*CPU)._0xFx55,
+	OpFx65:    (*CPU)._0xFx65,
+	OpFx75:    (*CPU)._0xFx75,
+	OpFx85:    (*CPU)._0xFx85,
+}
@@ -0,0 +1,58 @@
+package cpu_test
+
+import (
+	"testing"
+
+	"chip-8/internal/cpu"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	in := cpu.Decode(0xD12A)
+	assert.Equal(t, cpu.OpDxyn, in.Mnemonic)
+	assert.Equal(t, byte(0x1), in.X)
+	assert.Equal(t, byte(0x2), in.Y)
+	assert.Equal(t, byte(0xa), in.N)
+	assert.Equal(t, byte(0x2a), in.KK)
+	assert.Equal(t, uint16(0x12a), in.NNN)
+	assert.Equal(t, 2, in.Length())
+	assert.Equal(t, cpu.ExtensionCHIP8, in.Extension())
+
+	long := cpu.DecodeLong(0xF000, 0x1234)
+	assert.Equal(t, cpu.OpF000, long.Mnemonic)
+	assert.Equal(t, uint16(0x1234), long.Operand)
+	assert.Equal(t, 4, long.Length())
+	assert.Equal(t, cpu.ExtensionXOCHIP, long.Extension())
+
+	assert.Equal(t, uint16(0), cpu.DecodeLong(0xA123, 0x1234).Operand)
+	assert.Equal(t, cpu.ExtensionSCHIP, cpu.Decode(0xF130).Extension())
+	assert.Equal(t, cpu.OpUnknown, cpu.Decode(0x800F).Mnemonic)
+	assert.Equal(t, cpu.Op0nnn, cpu.Decode(0x01E0).Mnemonic, "00E0 needs the second nibble to be 0")
+	assert.Equal(t, cpu.Op0nnn, cpu.Decode(0x0AC3).Mnemonic, "00Cn needs the second nibble to be 0")
+
+	for _, o := range []cpu.Opcode{0x0000, 0x0100, 0x0123, 0x00E1, 0x00FA, 0x0FFF} {
+		in := cpu.Decode(o)
+		assert.Equal(t, cpu.Op0nnn, in.Mnemonic, "%04x should be SYS", uint16(o))
+		assert.Equal(t, uint16(o), in.NNN)
+	}
+}
+
+func TestDecodedInstruction_Encode(t *testing.T) {
+	// some nibbles are ignored when decoding, like the operands the
+	// instruction doesn't use, so encoding gives the canonical opcode
+	for o := 0; o <= 0xffff; o++ {
+		in := cpu.Decode(cpu.Opcode(o))
+		encoded := in.Encode()
+		if cpu.Decode(encoded).Mnemonic != in.Mnemonic || cpu.Decode(encoded).Encode() != encoded {
+			t.Fatalf("opcode %04x was encoded as %04x", o, uint16(encoded))
+		}
+	}
+
+	for _, o := range []cpu.Opcode{0x00C3, 0x00E0, 0x1234, 0x5AB2, 0x8AB6, 0xD12F, 0xF301, 0xF000, 0xFA85} {
+		assert.Equal(t, o, cpu.Decode(o).Encode())
+	}
+
+	in := cpu.DecodedInstruction{Mnemonic: cpu.Op8xy4, X: 0x3, Y: 0xc, KK: 0xff}
+	assert.Equal(t, cpu.Opcode(0x83c4), in.Encode(), "operands the instruction doesn't use should be ignored")
+}
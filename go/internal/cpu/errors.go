@@ -0,0 +1,30 @@
+package cpu
+
+import "fmt"
+
+// ExecutionError is returned by Cycle when an instruction fails. Once it has
+// been returned the CPU is faulted and stays halted at the failing
+// instruction until ClearFault is called.
+type ExecutionError struct {
+	// PC is the address of the instruction that failed.
+	PC uint16
+	// Opcode is the instruction that failed.
+	Opcode Opcode
+	// Err is the underlying reason for the failure, such as ErrUnknownOpcode.
+	Err error
+}
+
+func (e *ExecutionError) Error() string {
+	return fmt.Sprintf("execution fault at $%03x (%04x %s): %v",
+		e.PC, uint16(e.Opcode), e.Opcode.Instruction(), e.Err)
+}
+
+// Cause returns the underlying error, for use with errors.Cause.
+func (e *ExecutionError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying error, for use with errors.Is and errors.As.
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,142 @@
+package cpu
+
+import "sort"
+
+const (
+	// fontAddress is where the 5 byte hex digit font is loaded in the
+	// interpreter area of memory.
+	fontAddress = 0x050
+	// bigFontAddress is where the 10 byte SUPER-CHIP font is loaded, just
+	// after the small font.
+	bigFontAddress = fontAddress + 16*fontGlyphSize
+
+	fontGlyphSize    = 5
+	bigFontGlyphSize = 10
+)
+
+// FontSet holds the 16 hex digit glyphs 0-F used by Fx29, each 5 rows of 4
+// pixels stored in the high nibble of a byte.
+type FontSet [16 * fontGlyphSize]byte
+
+// BigFontSet holds the 16 large hex digit glyphs 0-F used by Fx30, each 10
+// rows of 8 pixels.
+type BigFontSet [16 * bigFontGlyphSize]byte
+
+var (
+	// FontCOSMAC is the font of the original COSMAC VIP interpreter, used by
+	// almost every interpreter since.
+	FontCOSMAC = FontSet{
+		0xf0, 0x90, 0x90, 0x90, 0xf0, // 0
+		0x20, 0x60, 0x20, 0x20, 0x70, // 1
+		0xf0, 0x10, 0xf0, 0x80, 0xf0, // 2
+		0xf0, 0x10, 0xf0, 0x10, 0xf0, // 3
+		0x90, 0x90, 0xf0, 0x10, 0x10, // 4
+		0xf0, 0x80, 0xf0, 0x10, 0xf0, // 5
+		0xf0, 0x80, 0xf0, 0x90, 0xf0, // 6
+		0xf0, 0x10, 0x20, 0x40, 0x40, // 7
+		0xf0, 0x90, 0xf0, 0x90, 0xf0, // 8
+		0xf0, 0x90, 0xf0, 0x10, 0xf0, // 9
+		0xf0, 0x90, 0xf0, 0x90, 0x90, // A
+		0xe0, 0x90, 0xe0, 0x90, 0xe0, // B
+		0xf0, 0x80, 0x80, 0x80, 0xf0, // C
+		0xe0, 0x90, 0x90, 0x90, 0xe0, // D
+		0xf0, 0x80, 0xf0, 0x80, 0xf0, // E
+		0xf0, 0x80, 0xf0, 0x80, 0x80, // F
+	}
+
+	// FontDREAM6800 is the narrower 3 pixel wide font of the DREAM 6800.
+	FontDREAM6800 = FontSet{
+		0xe0, 0xa0, 0xa0, 0xa0, 0xe0, // 0
+		0x40, 0x40, 0x40, 0x40, 0x40, // 1
+		0xe0, 0x20, 0xe0, 0x80, 0xe0, // 2
+		0xe0, 0x20, 0xe0, 0x20, 0xe0, // 3
+		0x80, 0xa0, 0xa0, 0xe0, 0x20, // 4
+		0xe0, 0x80, 0xe0, 0x20, 0xe0, // 5
+		0xe0, 0x80, 0xe0, 0xa0, 0xe0, // 6
+		0xe0, 0x20, 0x20, 0x20, 0x20, // 7
+		0xe0, 0xa0, 0xe0, 0xa0, 0xe0, // 8
+		0xe0, 0xa0, 0xe0, 0x20, 0xe0, // 9
+		0xe0, 0xa0, 0xe0, 0xa0, 0xa0, // A
+		0xc0, 0xa0, 0xe0, 0xa0, 0xc0, // B
+		0xe0, 0x80, 0x80, 0x80, 0xe0, // C
+		0xc0, 0xa0, 0xa0, 0xa0, 0xc0, // D
+		0xe0, 0x80, 0xe0, 0x80, 0xe0, // E
+		0xe0, 0x80, 0xc0, 0x80, 0x80, // F
+	}
+
+	// FontETI660 is the 3 pixel wide font of the ETI-660, with lower case
+	// style b and d.
+	FontETI660 = FontSet{
+		0xe0, 0xa0, 0xa0, 0xa0, 0xe0, // 0
+		0x20, 0x20, 0x20, 0x20, 0x20, // 1
+		0xe0, 0x20, 0xe0, 0x80, 0xe0, // 2
+		0xe0, 0x20, 0xe0, 0x20, 0xe0, // 3
+		0xa0, 0xa0, 0xe0, 0x20, 0x20, // 4
+		0xe0, 0x80, 0xe0, 0x20, 0xe0, // 5
+		0xe0, 0x80, 0xe0, 0xa0, 0xe0, // 6
+		0xe0, 0x20, 0x20, 0x20, 0x20, // 7
+		0xe0, 0xa0, 0xe0, 0xa0, 0xe0, // 8
+		0xe0, 0xa0, 0xe0, 0x20, 0xe0, // 9
+		0xe0, 0xa0, 0xe0, 0xa0, 0xa0, // A
+		0x80, 0x80, 0xe0, 0xa0, 0xe0, // b
+		0xe0, 0x80, 0x80, 0x80, 0xe0, // C
+		0x20, 0x20, 0xe0, 0xa0, 0xe0, // d
+		0xe0, 0x80, 0xe0, 0x80, 0xe0, // E
+		0xe0, 0x80, 0xc0, 0x80, 0x80, // F
+	}
+
+	// BigFontSCHIP is the large font of SUPER-CHIP 1.1, which only defined
+	// the digits 0-9, extended with the A-F glyphs later interpreters added.
+	BigFontSCHIP = BigFontSet{
+		0xff, 0xff, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0xff, 0xff, // 0
+		0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xff, 0xff, // 1
+		0xff, 0xff, 0x03, 0x03, 0xff, 0xff, 0xc0, 0xc0, 0xff, 0xff, // 2
+		0xff, 0xff, 0x03, 0x03, 0xff, 0xff, 0x03, 0x03, 0xff, 0xff, // 3
+		0xc3, 0xc3, 0xc3, 0xc3, 0xff, 0xff, 0x03, 0x03, 0x03, 0x03, // 4
+		0xff, 0xff, 0xc0, 0xc0, 0xff, 0xff, 0x03, 0x03, 0xff, 0xff, // 5
+		0xff, 0xff, 0xc0, 0xc0, 0xff, 0xff, 0xc3, 0xc3, 0xff, 0xff, // 6
+		0xff, 0xff, 0x03, 0x03, 0x06, 0x0c, 0x18, 0x18, 0x18, 0x18, // 7
+		0xff, 0xff, 0xc3, 0xc3, 0xff, 0xff, 0xc3, 0xc3, 0xff, 0xff, // 8
+		0xff, 0xff, 0xc3, 0xc3, 0xff, 0xff, 0x03, 0x03, 0xff, 0xff, // 9
+		0x7e, 0xff, 0xc3, 0xc3, 0xc3, 0xff, 0xff, 0xc3, 0xc3, 0xc3, // A
+		0xfc, 0xfc, 0xc3, 0xc3, 0xfc, 0xfc, 0xc3, 0xc3, 0xfc, 0xfc, // B
+		0x3c, 0xff, 0xc3, 0xc0, 0xc0, 0xc0, 0xc0, 0xc3, 0xff, 0x3c, // C
+		0xfc, 0xfe, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0xfe, 0xfc, // D
+		0xff, 0xff, 0xc0, 0xc0, 0xff, 0xff, 0xc0, 0xc0, 0xff, 0xff, // E
+		0xff, 0xff, 0xc0, 0xc0, 0xff, 0xff, 0xc0, 0xc0, 0xc0, 0xc0, // F
+	}
+)
+
+// fontSets maps the names fonts are chosen by to the font sets.
+var fontSets = map[string]FontSet{
+	"cosmac":    FontCOSMAC,
+	"dream6800": FontDREAM6800,
+	"eti660":    FontETI660,
+}
+
+// FontByName returns the font set with the name, as listed by FontNames.
+func FontByName(name string) (FontSet, bool) {
+	font, ok := fontSets[name]
+	return font, ok
+}
+
+// FontNames returns the names of the available font sets in sorted order.
+func FontNames() []string {
+	names := make([]string, 0, len(fontSets))
+	for name := range fontSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetFont replaces the hex digit font in memory with the font set.
+func (c *CPU) SetFont(font FontSet) {
+	copy(c.memory[fontAddress:], font[:])
+}
+
+// loadFonts loads the default fonts into the interpreter area of memory.
+func (c *CPU) loadFonts() {
+	c.SetFont(FontCOSMAC)
+	copy(c.memory[bigFontAddress:], BigFontSCHIP[:])
+}
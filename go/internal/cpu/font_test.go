@@ -0,0 +1,155 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cosmacGlyphs are the expected renderings of the COSMAC VIP font.
+var cosmacGlyphs = [16]string{
+	"####|#..#|#..#|#..#|####",
+	"..#.|.##.|..#.|..#.|.###",
+	"####|...#|####|#...|####",
+	"####|...#|####|...#|####",
+	"#..#|#..#|####|...#|...#",
+	"####|#...|####|...#|####",
+	"####|#...|####|#..#|####",
+	"####|...#|..#.|.#..|.#..",
+	"####|#..#|####|#..#|####",
+	"####|#..#|####|...#|####",
+	"####|#..#|####|#..#|#..#",
+	"###.|#..#|###.|#..#|###.",
+	"####|#...|#...|#...|####",
+	"###.|#..#|#..#|#..#|###.",
+	"####|#...|####|#...|####",
+	"####|#...|####|#...|#...",
+}
+
+// bigGlyphs are the expected renderings of the SUPER-CHIP large font.
+var bigGlyphs = [16]string{
+	"########|########|##....##|##....##|##....##|##....##|##....##|##....##|########|########",
+	"...##...|.####...|.####...|...##...|...##...|...##...|...##...|...##...|########|########",
+	"########|########|......##|......##|########|########|##......|##......|########|########",
+	"########|########|......##|......##|########|########|......##|......##|########|########",
+	"##....##|##....##|##....##|##....##|########|########|......##|......##|......##|......##",
+	"########|########|##......|##......|########|########|......##|......##|########|########",
+	"########|########|##......|##......|########|########|##....##|##....##|########|########",
+	"########|########|......##|......##|.....##.|....##..|...##...|...##...|...##...|...##...",
+	"########|########|##....##|##....##|########|########|##....##|##....##|########|########",
+	"########|########|##....##|##....##|########|########|......##|......##|########|########",
+	".######.|########|##....##|##....##|##....##|########|########|##....##|##....##|##....##",
+	"######..|######..|##....##|##....##|######..|######..|##....##|##....##|######..|######..",
+	"..####..|########|##....##|##......|##......|##......|##......|##....##|########|..####..",
+	"######..|#######.|##....##|##....##|##....##|##....##|##....##|##....##|#######.|######..",
+	"########|########|##......|##......|########|########|##......|##......|########|########",
+	"########|########|##......|##......|########|########|##......|##......|##......|##......",
+}
+
+// drawGlyph runs a program that draws the glyph for digit with the font
+// instruction fontOp (Fx29 or Fx30) at the top left of the screen, and
+// returns the top left width x height pixels as rows joined by |.
+func drawGlyph(t *testing.T, c *CPU, fontOp Opcode, digit byte, width, height int) string {
+	c.V[0x0] = digit
+	program := []Opcode{
+		fontOp,                      // I = glyph for V0
+		0xD110 | Opcode(height&0xf), // draw it at (V1, V1)
+		0x1204,                      // spin
+	}
+	for i, o := range program {
+		first, second := o.Bytes()
+		c.memory[programStart+2*i], c.memory[programStart+2*i+1] = first, second
+	}
+	for range program[:2] {
+		require.NoError(t, c.Cycle())
+	}
+
+	rows := make([]string, height)
+	for y := range rows {
+		row := make([]byte, width)
+		for x := range row {
+			row[x] = '.'
+			if c.screen[y*ScreenWidth+x] != 0 {
+				row[x] = '#'
+			}
+		}
+		rows[y] = string(row)
+	}
+	return strings.Join(rows, "|")
+}
+
+// renderGlyph renders the rows of a glyph as drawGlyph would.
+func renderGlyph(rows []byte, width int) string {
+	rendered := make([]string, len(rows))
+	for i, b := range rows {
+		rendered[i] = spriteRow(b)[:width]
+	}
+	return strings.Join(rendered, "|")
+}
+
+func spriteRow(b byte) string {
+	row := make([]byte, 8)
+	for i := range row {
+		row[i] = '.'
+		if b&(0x80>>uint(i)) != 0 {
+			row[i] = '#'
+		}
+	}
+	return string(row)
+}
+
+func TestFont_Default(t *testing.T) {
+	for digit, expected := range cosmacGlyphs {
+		t.Run(fmt.Sprintf("digit %X", digit), func(t *testing.T) {
+			c := newTestCPU()
+			assert.Equal(t, expected, drawGlyph(t, c, 0xF029, byte(digit), 4, 5))
+		})
+	}
+}
+
+func TestFont_Big(t *testing.T) {
+	for digit, expected := range bigGlyphs {
+		t.Run(fmt.Sprintf("digit %X", digit), func(t *testing.T) {
+			c := newTestCPU()
+			assert.Equal(t, expected, drawGlyph(t, c, 0xF030, byte(digit), 8, 10))
+		})
+	}
+}
+
+func TestFont_Alternatives(t *testing.T) {
+	for _, name := range FontNames() {
+		font, ok := FontByName(name)
+		require.True(t, ok)
+
+		for digit := 0; digit < 16; digit++ {
+			t.Run(fmt.Sprintf("%s digit %X", name, digit), func(t *testing.T) {
+				c := newTestCPU()
+				c.SetFont(font)
+
+				glyph := font[digit*fontGlyphSize : (digit+1)*fontGlyphSize]
+				assert.Equal(t, renderGlyph(glyph, 4), drawGlyph(t, c, 0xF029, byte(digit), 4, 5))
+			})
+		}
+	}
+}
+
+func TestFontByName(t *testing.T) {
+	assert.Equal(t, []string{"cosmac", "dream6800", "eti660"}, FontNames())
+
+	font, ok := FontByName("dream6800")
+	assert.True(t, ok)
+	assert.Equal(t, FontDREAM6800, font)
+
+	_, ok = FontByName("bogus")
+	assert.False(t, ok)
+}
+
+func TestFont_Layout(t *testing.T) {
+	c := newTestCPU()
+	assert.Equal(t, FontCOSMAC[:], c.memory[fontAddress:fontAddress+len(FontCOSMAC)])
+	assert.Equal(t, BigFontSCHIP[:], c.memory[bigFontAddress:bigFontAddress+len(BigFontSCHIP)])
+	assert.True(t, bigFontAddress+len(BigFontSCHIP) <= programStart, "fonts must fit below programs")
+}
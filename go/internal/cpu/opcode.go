@@ -22,97 +22,28 @@ func OpcodeFromBytes(b []byte) Opcode {
 
 var ErrUnknownOpcode = errors.New("unknown opcode")
 
-// Instruction returns the Opcode's name and instruction. If the Opcode is
-// unknown then ErrUnknownOp will be returned.
-func (o Opcode) Instruction() string {
-	firstByte, secondByte := o.Bytes()
+// Size returns the length in bytes of the instruction the opcode begins: 4
+// for the XO-CHIP F000 nnnn instruction, whose operand is the word following
+// the opcode, and 2 for every other instruction.
+func (o Opcode) Size() int {
+	return Decode(o).Length()
+}
 
-	firstNib := firstByte >> 4
-	secondNib := firstByte & 0xf
-	thirdNib := secondByte >> 4
-	fourthNib := secondByte & 0xf
+// LongInstruction returns the name and instruction of a 4 byte instruction,
+// given the word following the opcode. Opcodes of 2 byte instructions ignore
+// the word and return their Instruction.
+func (o Opcode) LongInstruction(operand uint16) string {
+	return Classic.Format(DecodeLong(o, operand), nil)
+}
 
-	switch firstNib {
-	case 0x0:
-		switch secondByte {
-		case 0xe0:
-			return fmt.Sprintf("%-10s", "CLS")
-		case 0xee:
-			return fmt.Sprintf("%-10s", "RTS")
-		}
-	case 0x1:
-		return fmt.Sprintf("%-10s $%01x%02x", "JUMP", secondNib, secondByte)
-	case 0x2:
-		return fmt.Sprintf("%-10s $%01x%02x", "CALL", secondNib, secondByte)
-	case 0x3:
-		return fmt.Sprintf("%-10s V%01X,#$%02x", "SKIP.EQ", secondNib, secondByte)
-	case 0x4:
-		return fmt.Sprintf("%-10s V%01X,#$%02x", "SKIP.NE", secondNib, secondByte)
-	case 0x5:
-		return fmt.Sprintf("%-10s V%01X,V%01X", "SKIP.EQ", secondNib, thirdNib)
-	case 0x6:
-		return fmt.Sprintf("%-10s V%01X,#$%02x", "MVI", secondNib, secondByte)
-	case 0x7:
-		return fmt.Sprintf("%-10s V%01X,#$%02x", "ADI", secondNib, secondByte)
-	case 0x8:
-		switch secondByte & 0xf {
-		case 0x0:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "MOV", secondNib, thirdNib)
-		case 0x1:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "OR", secondNib, thirdNib)
-		case 0x2:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "AND", secondNib, thirdNib)
-		case 0x3:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "XOR", secondNib, thirdNib)
-		case 0x4:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "ADD.", secondNib, thirdNib)
-		case 0x5:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "SUB.", secondNib, thirdNib)
-		case 0x6:
-			return fmt.Sprintf("%-10s V%01X", "SHR.", secondNib)
-		case 0x7:
-			return fmt.Sprintf("%-10s V%01X,V%01X", "SUBB.", secondNib, thirdNib)
-		case 0xe:
-			return fmt.Sprintf("%-10s V%01X", "SHL.", secondNib)
-		}
-	case 0x9:
-		return fmt.Sprintf("%-10s V%01X,V%01X", "SKIP.NE", secondNib, thirdNib)
-	case 0xa:
-		return fmt.Sprintf("%-10s I,#$%01x%02x", "MVI", secondNib, secondByte)
-	case 0xb:
-		return fmt.Sprintf("%-10s $%01x%02x(V0)", "JUMP", secondNib, secondByte)
-	case 0xc:
-		return fmt.Sprintf("%-10s V%01X,#$%02x", "RND", secondNib, secondByte)
-	case 0xd:
-		return fmt.Sprintf("%-10s V%01X,V%01X,#$%01X", "SPRITE.", secondNib, thirdNib, fourthNib)
-	case 0xe:
-		switch secondByte {
-		case 0x9E:
-			return fmt.Sprintf("%-10s V%01X", "SKIP.KEY", secondNib)
-		case 0xA1:
-			return fmt.Sprintf("%-10s V%01X", "SKIP.NOKEY", secondNib)
-		}
-	case 0xf:
-		switch secondByte {
-		case 0x07:
-			return fmt.Sprintf("%-10s V%01X,DELAY", "MOV", secondNib)
-		case 0x0a:
-			return fmt.Sprintf("%-10s V%01X", "WAITKEY", secondNib)
-		case 0x15:
-			return fmt.Sprintf("%-10s DELAY,V%01X", "MOV", secondNib)
-		case 0x18:
-			return fmt.Sprintf("%-10s SOUND,V%01X", "MOV", secondNib)
-		case 0x1e:
-			return fmt.Sprintf("%-10s I,V%01X", "ADD", secondNib)
-		case 0x29:
-			return fmt.Sprintf("%-10s V%01X", "SPRITECHAR", secondNib)
-		case 0x33:
-			return fmt.Sprintf("%-10s V%01X", "MOVBCD", secondNib)
-		case 0x55:
-			return fmt.Sprintf("%-10s (I),V0-V%01X", "MOVM", secondNib)
-		case 0x65:
-			return fmt.Sprintf("%-10s V0-V%01X,(I)", "MOVM", secondNib)
-		}
+// Instruction returns the Opcode's name and instruction in the Classic
+// syntax. Unknown opcodes are written as UNK. The operand of a 4 byte
+// instruction isn't part of the Opcode, so it is written as nnnn; use
+// LongInstruction to include it.
+func (o Opcode) Instruction() string {
+	in := Decode(o)
+	if in.Length() == 4 {
+		return fmt.Sprintf("%-10s I,#$nnnn", "MVIL")
 	}
-	return fmt.Sprintf("%-10s 0x%02x%02x", "UNK", firstByte, secondByte)
+	return Classic.Format(in, nil)
 }
@@ -25,6 +25,36 @@ func TestOpcode_Instruction(t *testing.T) {
 			opcode:              0x00EE,
 			expectedInstruction: "RTS       ",
 		},
+		{
+			label:               "00Cn scroll down n pixels",
+			opcode:              0x00C4,
+			expectedInstruction: "SCROLL.DN  #$4",
+		},
+		{
+			label:               "00FB scroll right",
+			opcode:              0x00FB,
+			expectedInstruction: "SCROLL.RT ",
+		},
+		{
+			label:               "00FC scroll left",
+			opcode:              0x00FC,
+			expectedInstruction: "SCROLL.LT ",
+		},
+		{
+			label:               "00FD exit",
+			opcode:              0x00FD,
+			expectedInstruction: "EXIT      ",
+		},
+		{
+			label:               "00FE low resolution",
+			opcode:              0x00FE,
+			expectedInstruction: "LORES     ",
+		},
+		{
+			label:               "00FF high resolution",
+			opcode:              0x00FF,
+			expectedInstruction: "HIRES     ",
+		},
 		{
 			label:               "1nnn jump to location nnn",
 			opcode:              0x128A,
@@ -93,7 +123,7 @@ func TestOpcode_Instruction(t *testing.T) {
 		{
 			label:               "8xy6 set Vx = Vx SHR 1",
 			opcode:              0x83B6,
-			expectedInstruction: "SHR.       V3",
+			expectedInstruction: "SHR.       V3,VB",
 		},
 		{
 			label:               "8xy7 set Vx = Vy - Vx, set VF = NOT borrow",
@@ -103,7 +133,7 @@ func TestOpcode_Instruction(t *testing.T) {
 		{
 			label:               "8xyE set Vx = Vx SHL 1",
 			opcode:              0x83BE,
-			expectedInstruction: "SHL.       V3",
+			expectedInstruction: "SHL.       V3,VB",
 		},
 		{
 			label:               "9xy0 skip next instruction if Vx != Vy",
@@ -170,6 +200,11 @@ func TestOpcode_Instruction(t *testing.T) {
 			opcode:              0xFD29,
 			expectedInstruction: "SPRITECHAR VD",
 		},
+		{
+			label:               "Fx30 set I = location of large sprite for digit Vx",
+			opcode:              0xFD30,
+			expectedInstruction: "SPRITEBIG  VD",
+		},
 		{
 			label:               "Fx33 store BCD representation of Vx in memory locations I, I+1, and I+2",
 			opcode:              0xF533,
@@ -185,6 +220,51 @@ func TestOpcode_Instruction(t *testing.T) {
 			opcode:              0xFD65,
 			expectedInstruction: "MOVM       V0-VD,(I)",
 		},
+		{
+			label:               "00Dn scroll up n pixels",
+			opcode:              0x00D4,
+			expectedInstruction: "SCROLL.UP  #$4",
+		},
+		{
+			label:               "5xy2 store Vx through Vy in memory starting at location I",
+			opcode:              0x5362,
+			expectedInstruction: "MOVR       (I),V3-V6",
+		},
+		{
+			label:               "5xy3 read Vx through Vy from memory starting at location I",
+			opcode:              0x5633,
+			expectedInstruction: "MOVR       V6-V3,(I)",
+		},
+		{
+			label:               "F000 set I = nnnn",
+			opcode:              0xF000,
+			expectedInstruction: "MVIL       I,#$nnnn",
+		},
+		{
+			label:               "Fn01 select bit-planes n",
+			opcode:              0xF301,
+			expectedInstruction: "PLANE      #$3",
+		},
+		{
+			label:               "F002 load the audio pattern",
+			opcode:              0xF002,
+			expectedInstruction: "AUDIO     ",
+		},
+		{
+			label:               "Fx3A set the pitch register = Vx",
+			opcode:              0xF43A,
+			expectedInstruction: "PITCH      V4",
+		},
+		{
+			label:               "Fx75 store V0 through Vx in the RPL flags",
+			opcode:              0xF775,
+			expectedInstruction: "MOVM       RPL,V0-V7",
+		},
+		{
+			label:               "Fx85 read V0 through Vx from the RPL flags",
+			opcode:              0xF785,
+			expectedInstruction: "MOVM       V0-V7,RPL",
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.label, func(t *testing.T) {
@@ -193,6 +273,14 @@ func TestOpcode_Instruction(t *testing.T) {
 	}
 }
 
+func TestOpcode_LongInstruction(t *testing.T) {
+	assert.Equal(t, 4, cpu.Opcode(0xF000).Size())
+	assert.Equal(t, "MVIL       I,#$1234", cpu.Opcode(0xF000).LongInstruction(0x1234))
+
+	assert.Equal(t, 2, cpu.Opcode(0xA123).Size())
+	assert.Equal(t, cpu.Opcode(0xA123).Instruction(), cpu.Opcode(0xA123).LongInstruction(0x1234))
+}
+
 func TestOpcode_Instruction_UnknownOpcode(t *testing.T) {
 	type testCase struct {
 		label               string
@@ -215,10 +303,20 @@ func TestOpcode_Instruction_UnknownOpcode(t *testing.T) {
 			opcode:              0xe000,
 			expectedInstruction: "UNK        0xe000",
 		},
+		{
+			label:               "unknown 5 code",
+			opcode:              0x5121,
+			expectedInstruction: "UNK        0x5121",
+		},
 		{
 			label:               "unknown f code",
-			opcode:              0xf000,
-			expectedInstruction: "UNK        0xf000",
+			opcode:              0xf0ff,
+			expectedInstruction: "UNK        0xf0ff",
+		},
+		{
+			label:               "F000 with a register is unknown",
+			opcode:              0xf100,
+			expectedInstruction: "UNK        0xf100",
 		},
 	}
 	for _, c := range cases {
@@ -0,0 +1,92 @@
+package cpu
+
+import "sort"
+
+// IndexIncrement is how far Fx55 and Fx65 move I after storing or loading
+// registers.
+type IndexIncrement int
+
+const (
+	// IncrementNone leaves I unchanged.
+	IncrementNone IndexIncrement = iota
+	// IncrementX adds x to I, an off by one of the CHIP-48.
+	IncrementX
+	// IncrementXPlusOne adds x + 1 to I, leaving it just past the last
+	// register stored or loaded.
+	IncrementXPlusOne
+)
+
+// Quirks are the behaviours that differ between CHIP-8 interpreters, which
+// programs written for a particular interpreter rely on. The zero value
+// follows Cowgod's technical reference.
+type Quirks struct {
+	// ShiftUsesVy makes 8xy6 and 8xyE shift Vy into Vx, instead of shifting
+	// Vx in place.
+	ShiftUsesVy bool
+	// LoadStoreIncrement is how far Fx55 and Fx65 move I.
+	LoadStoreIncrement IndexIncrement
+	// JumpUsesVx makes Bnnn jump to nnn + Vx, where x is the highest nibble
+	// of nnn, instead of nnn + V0.
+	JumpUsesVx bool
+	// LogicResetsVF makes 8xy1, 8xy2, and 8xy3 set VF to 0.
+	LogicResetsVF bool
+	// ClipSprites makes sprites that cross the edge of the screen clip,
+	// instead of wrapping around to the other side.
+	ClipSprites bool
+}
+
+var (
+	// QuirksCOSMACVIP matches the original COSMAC VIP interpreter.
+	QuirksCOSMACVIP = Quirks{
+		ShiftUsesVy:        true,
+		LoadStoreIncrement: IncrementXPlusOne,
+		LogicResetsVF:      true,
+		ClipSprites:        true,
+	}
+
+	// QuirksCHIP48 matches CHIP-48 on the HP-48 calculators.
+	QuirksCHIP48 = Quirks{
+		LoadStoreIncrement: IncrementX,
+		JumpUsesVx:         true,
+		ClipSprites:        true,
+	}
+
+	// QuirksSCHIP11 matches SUPER-CHIP 1.1.
+	QuirksSCHIP11 = Quirks{
+		LoadStoreIncrement: IncrementNone,
+		JumpUsesVx:         true,
+		ClipSprites:        true,
+	}
+
+	// QuirksXOCHIP matches XO-CHIP as implemented by Octo.
+	QuirksXOCHIP = Quirks{
+		ShiftUsesVy:        true,
+		LoadStoreIncrement: IncrementXPlusOne,
+	}
+)
+
+// profiles maps the names quirk profiles are chosen by to their quirks.
+var profiles = map[string]Quirks{
+	"cowgod": {},
+	"vip":    QuirksCOSMACVIP,
+	"chip48": QuirksCHIP48,
+	"schip":  QuirksSCHIP11,
+	"xochip": QuirksXOCHIP,
+}
+
+// ProfileByName returns the quirks of the profile with the name, as listed
+// by ProfileNames.
+func ProfileByName(name string) (Quirks, bool) {
+	quirks, ok := profiles[name]
+	return quirks, ok
+}
+
+// ProfileNames returns the names of the quirk profiles in sorted order.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
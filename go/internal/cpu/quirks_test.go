@@ -0,0 +1,180 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCPU_Quirks(t *testing.T) {
+	type testCase struct {
+		label  string
+		quirks Quirks
+		opcode Opcode
+		setup  func(c *CPU)
+		check  func(t *testing.T, c *CPU)
+	}
+	cases := []testCase{
+		{
+			label:  "8xy6 shifts Vx by default",
+			opcode: 0x8126,
+			setup:  func(c *CPU) { c.V[1], c.V[2] = 0x03, 0x10 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x01), c.V[1])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy6 shifts Vy with ShiftUsesVy",
+			quirks: Quirks{ShiftUsesVy: true},
+			opcode: 0x8126,
+			setup:  func(c *CPU) { c.V[1], c.V[2] = 0x03, 0x10 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x08), c.V[1])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xyE shifts Vy with ShiftUsesVy",
+			quirks: Quirks{ShiftUsesVy: true},
+			opcode: 0x812E,
+			setup:  func(c *CPU) { c.V[1], c.V[2] = 0x01, 0x81 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0x02), c.V[1])
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "Fx55 leaves I by default",
+			opcode: 0xF255,
+			setup:  func(c *CPU) { c.I = 0x300 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x300), c.I)
+			},
+		},
+		{
+			label:  "Fx55 adds x to I with IncrementX",
+			quirks: Quirks{LoadStoreIncrement: IncrementX},
+			opcode: 0xF255,
+			setup:  func(c *CPU) { c.I = 0x300 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x302), c.I)
+			},
+		},
+		{
+			label:  "Fx65 adds x + 1 to I with IncrementXPlusOne",
+			quirks: Quirks{LoadStoreIncrement: IncrementXPlusOne},
+			opcode: 0xF265,
+			setup:  func(c *CPU) { c.I = 0x300 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x303), c.I)
+			},
+		},
+		{
+			label:  "Bnnn adds V0 by default",
+			opcode: 0xB234,
+			setup:  func(c *CPU) { c.V[0], c.V[2] = 0x01, 0x10 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x235), c.pc)
+			},
+		},
+		{
+			label:  "Bxnn adds Vx with JumpUsesVx",
+			quirks: Quirks{JumpUsesVx: true},
+			opcode: 0xB234,
+			setup:  func(c *CPU) { c.V[0], c.V[2] = 0x01, 0x10 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x244), c.pc)
+			},
+		},
+		{
+			label:  "8xy1 keeps VF by default",
+			opcode: 0x8121,
+			setup:  func(c *CPU) { c.V[0xf] = 1 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.V[0xf])
+			},
+		},
+		{
+			label:  "8xy3 resets VF with LogicResetsVF",
+			quirks: Quirks{LogicResetsVF: true},
+			opcode: 0x8123,
+			setup:  func(c *CPU) { c.V[0xf] = 1 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "Dxyn wraps by default",
+			opcode: 0xD011,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300] = 0xff
+				c.V[0], c.V[1] = ScreenWidth-4, ScreenHeight-1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[(ScreenHeight-1)*ScreenWidth+ScreenWidth-1])
+				assert.Equal(t, byte(1), c.screen[(ScreenHeight-1)*ScreenWidth])
+			},
+		},
+		{
+			label:  "Dxyn clips with ClipSprites",
+			quirks: Quirks{ClipSprites: true},
+			opcode: 0xD012,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0xff, 0xff
+				c.V[0], c.V[1] = ScreenWidth-4, ScreenHeight-1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[(ScreenHeight-1)*ScreenWidth+ScreenWidth-1])
+				assert.Equal(t, byte(0), c.screen[(ScreenHeight-1)*ScreenWidth])
+				assert.Equal(t, byte(0), c.screen[ScreenWidth-4])
+			},
+		},
+		{
+			label:  "Dxyn wraps the starting position with ClipSprites",
+			quirks: Quirks{ClipSprites: true},
+			opcode: 0xD011,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300] = 0x80
+				c.V[0], c.V[1] = ScreenWidth+1, ScreenHeight+2
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[2*ScreenWidth+1])
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := newTestCPU(tc.opcode)
+			c.Quirks = tc.quirks
+			if tc.setup != nil {
+				tc.setup(c)
+			}
+			if assert.NoError(t, c.Cycle()) {
+				tc.check(t, c)
+			}
+		})
+	}
+}
+
+func TestProfileByName(t *testing.T) {
+	for _, name := range ProfileNames() {
+		_, ok := ProfileByName(name)
+		assert.True(t, ok, name)
+	}
+
+	quirks, ok := ProfileByName("vip")
+	assert.True(t, ok)
+	assert.Equal(t, QuirksCOSMACVIP, quirks)
+
+	quirks, ok = ProfileByName("cowgod")
+	assert.True(t, ok)
+	assert.Equal(t, Quirks{}, quirks)
+
+	_, ok = ProfileByName("nope")
+	assert.False(t, ok)
+}
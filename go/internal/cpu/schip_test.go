@@ -0,0 +1,205 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPU_SuperChip(t *testing.T) {
+	type testCase struct {
+		label  string
+		opcode Opcode
+		setup  func(c *CPU)
+		check  func(t *testing.T, c *CPU)
+	}
+	cases := []testCase{
+		{
+			label:  "00FF switches to high resolution and clears the display",
+			opcode: 0x00FF,
+			setup:  func(c *CPU) { c.screen[5] = 1 },
+			check: func(t *testing.T, c *CPU) {
+				width, height := c.Resolution()
+				assert.Equal(t, HiResWidth, width)
+				assert.Equal(t, HiResHeight, height)
+				assert.Len(t, c.Screen(), HiResWidth*HiResHeight)
+				assert.Equal(t, byte(0), c.screen[5])
+			},
+		},
+		{
+			label:  "00FE switches to low resolution and clears the display",
+			opcode: 0x00FE,
+			setup: func(c *CPU) {
+				c.hires = true
+				c.screen[5] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				width, height := c.Resolution()
+				assert.Equal(t, ScreenWidth, width)
+				assert.Equal(t, ScreenHeight, height)
+				assert.Len(t, c.Screen(), ScreenWidth*ScreenHeight)
+				assert.Equal(t, byte(0), c.screen[5])
+			},
+		},
+		{
+			label:  "00Cn scrolls the display down n pixels",
+			opcode: 0x00C3,
+			setup: func(c *CPU) {
+				c.screen[0*ScreenWidth+7] = 1
+				c.screen[30*ScreenWidth+1] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.screen[0*ScreenWidth+7])
+				assert.Equal(t, byte(1), c.screen[3*ScreenWidth+7])
+				assert.Equal(t, byte(0), c.screen[30*ScreenWidth+1])
+				assert.Equal(t, byte(0), c.screen[1*ScreenWidth+1], "scrolled off pixels don't wrap")
+			},
+		},
+		{
+			label:  "00FB scrolls the display right 4 pixels",
+			opcode: 0x00FB,
+			setup: func(c *CPU) {
+				c.hires = true
+				c.screen[2*HiResWidth+1] = 1
+				c.screen[2*HiResWidth+HiResWidth-2] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.screen[2*HiResWidth+1])
+				assert.Equal(t, byte(1), c.screen[2*HiResWidth+5])
+				assert.Equal(t, byte(0), c.screen[2*HiResWidth+HiResWidth-2])
+				assert.Equal(t, byte(0), c.screen[3*HiResWidth+2], "scrolled off pixels don't wrap")
+			},
+		},
+		{
+			label:  "00FC scrolls the display left 4 pixels",
+			opcode: 0x00FC,
+			setup: func(c *CPU) {
+				c.screen[2*ScreenWidth+5] = 1
+				c.screen[2*ScreenWidth+1] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[2*ScreenWidth+1])
+				assert.Equal(t, byte(0), c.screen[2*ScreenWidth+5])
+				assert.Equal(t, byte(0), c.screen[1*ScreenWidth+ScreenWidth-3], "scrolled off pixels don't wrap")
+			},
+		},
+		{
+			label:  "00FD exits",
+			opcode: 0x00FD,
+			check: func(t *testing.T, c *CPU) {
+				assert.True(t, c.Exited())
+				assert.Equal(t, uint16(0x200), c.pc)
+			},
+		},
+		{
+			label:  "Dxy0 draws a 16x16 sprite",
+			opcode: 0xD010,
+			setup: func(c *CPU) {
+				c.hires = true
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0x80, 0x01
+				c.memory[0x31e], c.memory[0x31f] = 0xff, 0xff
+				c.V[0], c.V[1] = 100, 40
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[40*HiResWidth+100])
+				assert.Equal(t, byte(0), c.screen[40*HiResWidth+101])
+				assert.Equal(t, byte(1), c.screen[40*HiResWidth+115])
+				assert.Equal(t, byte(1), c.screen[55*HiResWidth+108])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:  "Dxy0 draws an 8x16 sprite in low resolution",
+			opcode: 0xD010,
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0x81, 0x01
+				c.memory[0x30f] = 0xff
+				c.V[0], c.V[1] = 10, 4
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[4*ScreenWidth+10])
+				assert.Equal(t, byte(1), c.screen[4*ScreenWidth+17])
+				assert.Equal(t, byte(0), c.screen[4*ScreenWidth+25], "the second byte is the next row")
+				assert.Equal(t, byte(1), c.screen[5*ScreenWidth+17])
+				assert.Equal(t, byte(1), c.screen[19*ScreenWidth+10])
+				assert.Equal(t, byte(0), c.screen[20*ScreenWidth+10])
+			},
+		},
+		{
+			label:  "Dxy0 draws a 16x16 sprite in low resolution in XO-CHIP mode",
+			opcode: 0xD010,
+			setup: func(c *CPU) {
+				c.SetXOCHIP(true)
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0x80, 0x01
+				c.V[0], c.V[1] = 10, 4
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[4*ScreenWidth+10])
+				assert.Equal(t, byte(1), c.screen[4*ScreenWidth+25])
+				assert.Equal(t, byte(0), c.screen[5*ScreenWidth+17])
+			},
+		},
+		{
+			label:  "Fx75 saves registers to the RPL flags",
+			opcode: 0xF275,
+			setup:  func(c *CPU) { c.V[0], c.V[1], c.V[2], c.V[3] = 1, 2, 3, 4 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, [16]byte{1, 2, 3}, c.RPLFlags())
+			},
+		},
+		{
+			label:  "Fx85 loads registers from the RPL flags",
+			opcode: 0xF185,
+			setup:  func(c *CPU) { c.SetRPLFlags([16]byte{5, 6, 7}) },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, [16]byte{5, 6}, c.V)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := newTestCPU(tc.opcode)
+			if tc.setup != nil {
+				tc.setup(c)
+			}
+			if assert.NoError(t, c.Cycle()) {
+				tc.check(t, c)
+			}
+		})
+	}
+}
+
+func TestCPU_RPLOutOfBounds(t *testing.T) {
+	for _, opcode := range []Opcode{0xF875, 0xFF85} {
+		c := newTestCPU(opcode)
+		err := c.Cycle()
+		require.Error(t, err)
+		assert.Equal(t, ErrRPLOutOfBounds, errors.Cause(err))
+		assert.Equal(t, opcode, c.Fault().Opcode)
+
+		c = newTestCPU(opcode)
+		c.SetXOCHIP(true)
+		assert.NoError(t, c.Cycle(), "XO-CHIP has 16 RPL flags")
+	}
+
+	c := newTestCPU(0xF775)
+	c.V[7] = 9
+	require.NoError(t, c.Cycle())
+	assert.Equal(t, byte(9), c.RPLFlags()[7])
+}
+
+func TestCPU_ExitedStaysOnExit(t *testing.T) {
+	c := newTestCPU(0x00FD, 0x6005)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.Cycle())
+	}
+	assert.True(t, c.Exited())
+	assert.Equal(t, uint16(0x200), c.pc)
+	assert.Equal(t, byte(0), c.V[0])
+}
@@ -0,0 +1,200 @@
+package cpu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+)
+
+// A snapshot is laid out as:
+//
+//	magic     4 bytes  "CH8S"
+//	version   2 bytes
+//	state     the fixed size snapshotState
+//	screen    one byte per pixel of the 128x64 display
+//	quirks    the fixed size snapshotQuirks
+//	memory    the addressable memory, 4 KiB or 64 KiB in XO-CHIP mode
+//	checksum  4 bytes  CRC-32 (IEEE) of everything before it
+//
+// Multi-byte values are big endian. The version is bumped whenever the
+// layout changes, and snapshots of other versions are rejected. The screen
+// and memory are copied as is rather than through encoding/binary, which is
+// slow for large arrays, so snapshots are cheap enough to take every cycle.
+const (
+	snapshotMagic   = "CH8S"
+	snapshotVersion = 1
+)
+
+var (
+	// ErrInvalidSnapshot is returned when restoring data that isn't a snapshot.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	// ErrSnapshotVersion is returned when restoring a snapshot written by an
+	// incompatible version.
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	// ErrSnapshotChecksum is returned when restoring a snapshot that has
+	// been corrupted.
+	ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+)
+
+type snapshotHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+// snapshotState is the machine state in a snapshot, other than the screen,
+// quirks, and memory. Every field is fixed size so it can be read and written
+// with encoding/binary.
+type snapshotState struct {
+	V        [16]byte
+	I        uint16
+	PC       uint16
+	SP       uint16
+	Stack    [16]uint16
+	Delay    byte
+	Sound    byte
+	Keyboard [16]byte
+	RPL      [16]byte
+
+	Hires  bool
+	Exited bool
+	XOCHIP bool
+	Plane  byte
+	Pitch  byte
+	Audio  [16]byte
+}
+
+// snapshotQuirks is the quirks in a snapshot.
+type snapshotQuirks struct {
+	ShiftUsesVy        bool
+	LoadStoreIncrement byte
+	JumpUsesVx         bool
+	LogicResetsVF      bool
+	ClipSprites        bool
+}
+
+// Snapshot returns the entire state of the machine, including its quirks,
+// in a versioned binary format that Restore reads back.
+func (c *CPU) Snapshot() []byte {
+	state := snapshotState{
+		V:        c.V,
+		I:        c.I,
+		PC:       c.pc,
+		SP:       c.sp,
+		Stack:    c.stack,
+		Delay:    c.delay,
+		Sound:    c.sound,
+		Keyboard: c.keyboard,
+		RPL:      c.rpl,
+
+		Hires:  c.hires,
+		Exited: c.exited,
+		XOCHIP: c.xochip,
+		Plane:  c.plane,
+		Pitch:  c.pitch,
+		Audio:  c.audio,
+	}
+	quirks := snapshotQuirks{
+		ShiftUsesVy:        c.Quirks.ShiftUsesVy,
+		LoadStoreIncrement: byte(c.Quirks.LoadStoreIncrement),
+		JumpUsesVx:         c.Quirks.JumpUsesVx,
+		LogicResetsVF:      c.Quirks.LogicResetsVF,
+		ClipSprites:        c.Quirks.ClipSprites,
+	}
+	header := snapshotHeader{Version: snapshotVersion}
+	copy(header.Magic[:], snapshotMagic)
+
+	buf := bytes.NewBuffer(make([]byte, 0, snapshotSize(c.memSize)))
+	// writes to a bytes.Buffer of fixed size values can't fail
+	_ = binary.Write(buf, binary.BigEndian, header)
+	_ = binary.Write(buf, binary.BigEndian, state)
+	buf.Write(c.screen[:])
+	_ = binary.Write(buf, binary.BigEndian, quirks)
+	buf.Write(c.memory[:c.memSize])
+	_ = binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// Restore replaces the entire state of the machine with a snapshot taken by
+// Snapshot, clearing any fault. If the snapshot can't be restored the CPU is
+// left untouched and the error is ErrInvalidSnapshot, ErrSnapshotVersion, or
+// ErrSnapshotChecksum.
+func (c *CPU) Restore(snapshot []byte) error {
+	var header snapshotHeader
+	r := bytes.NewReader(snapshot)
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil || string(header.Magic[:]) != snapshotMagic {
+		return ErrInvalidSnapshot
+	}
+	if header.Version != snapshotVersion {
+		return errors.Wrapf(ErrSnapshotVersion, "version %d", header.Version)
+	}
+
+	if len(snapshot) < 4 {
+		return ErrInvalidSnapshot
+	}
+	body, sum := snapshot[:len(snapshot)-4], snapshot[len(snapshot)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return ErrSnapshotChecksum
+	}
+
+	var state snapshotState
+	if err := binary.Read(r, binary.BigEndian, &state); err != nil {
+		return errors.Wrap(ErrInvalidSnapshot, "truncated state")
+	}
+	memSize := MemorySize
+	if state.XOCHIP {
+		memSize = XOCHIPMemorySize
+	}
+	if len(snapshot) != snapshotSize(memSize) {
+		return errors.Wrapf(ErrInvalidSnapshot, "%d bytes, expected %d", len(snapshot), snapshotSize(memSize))
+	}
+	var screen [len(c.screen)]byte
+	_, _ = r.Read(screen[:])
+	var quirks snapshotQuirks
+	_ = binary.Read(r, binary.BigEndian, &quirks)
+	if int(state.SP) > len(c.stack) {
+		return errors.Wrapf(ErrInvalidSnapshot, "stack pointer %d", state.SP)
+	}
+	if quirks.LoadStoreIncrement > byte(IncrementXPlusOne) {
+		return errors.Wrapf(ErrInvalidSnapshot, "load/store increment %d", quirks.LoadStoreIncrement)
+	}
+
+	c.V = state.V
+	c.I = state.I
+	c.pc = state.PC
+	c.sp = state.SP
+	c.stack = state.Stack
+	c.delay = state.Delay
+	c.sound = state.Sound
+	c.keyboard = state.Keyboard
+	c.rpl = state.RPL
+
+	c.hires = state.Hires
+	c.exited = state.Exited
+	c.SetXOCHIP(state.XOCHIP)
+	c.plane = state.Plane
+	c.pitch = state.Pitch
+	c.audio = state.Audio
+	c.screen = screen
+
+	c.Quirks = Quirks{
+		ShiftUsesVy:        quirks.ShiftUsesVy,
+		LoadStoreIncrement: IndexIncrement(quirks.LoadStoreIncrement),
+		JumpUsesVx:         quirks.JumpUsesVx,
+		LogicResetsVF:      quirks.LogicResetsVF,
+		ClipSprites:        quirks.ClipSprites,
+	}
+
+	c.memory = [len(c.memory)]byte{}
+	_, _ = r.Read(c.memory[:memSize])
+	c.fault = nil
+	return nil
+}
+
+// snapshotSize returns the length of a snapshot of a CPU with memSize bytes
+// of addressable memory.
+func snapshotSize(memSize int) int {
+	return binary.Size(snapshotHeader{}) + binary.Size(snapshotState{}) +
+		HiResWidth*HiResHeight + binary.Size(snapshotQuirks{}) + memSize + 4
+}
@@ -0,0 +1,98 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPU_SnapshotRestore(t *testing.T) {
+	c := newTestCPU(
+		0x6005, // MVI V0,#$05
+		0xA050, // MVI I,#$050
+		0xD005, // SPRITE. V0,V0,#$5
+		0x2208, // CALL $208
+		0xF018, // MOV SOUND,V0
+		0x00EE, // RTS
+	)
+	c.Quirks = QuirksSCHIP11
+	c.SetKey(0xa, true)
+	c.SetRPLFlags([16]byte{1, 2, 3})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.Cycle())
+	}
+
+	snapshot := c.Snapshot()
+	restored := NewCPU()
+	require.NoError(t, restored.Restore(snapshot))
+
+	assert.Equal(t, c.Registers(), restored.Registers())
+	assert.Equal(t, c.Screen(), restored.Screen())
+	assert.Equal(t, c.ReadMemory(0, MemorySize), restored.ReadMemory(0, MemorySize))
+	assert.Equal(t, c.Quirks, restored.Quirks)
+	assert.Equal(t, c.RPLFlags(), restored.RPLFlags())
+	assert.True(t, restored.AnyKeyPressed())
+	assert.Equal(t, snapshot, restored.Snapshot())
+
+	// both machines carry on identically
+	require.NoError(t, c.Cycle())
+	require.NoError(t, restored.Cycle())
+	assert.Equal(t, c.Registers(), restored.Registers())
+}
+
+func TestCPU_SnapshotRestore_XOCHIP(t *testing.T) {
+	c := newTestCPU(0xF301)
+	c.SetXOCHIP(true)
+	require.NoError(t, c.Cycle())
+	c.memory[0xfff0] = 0x42
+
+	restored := NewCPU()
+	require.NoError(t, restored.Restore(c.Snapshot()))
+
+	assert.Equal(t, []byte{0x42}, restored.ReadMemory(0xfff0, 1))
+	assert.Equal(t, byte(3), restored.plane)
+}
+
+func TestCPU_Restore_ClearsFault(t *testing.T) {
+	c := newTestCPU(0x00EE)
+	snapshot := c.Snapshot()
+	require.Error(t, c.Cycle())
+
+	require.NoError(t, c.Restore(snapshot))
+	assert.Nil(t, c.Fault())
+}
+
+func TestCPU_Restore_Errors(t *testing.T) {
+	snapshot := newTestCPU(0x6005).Snapshot()
+
+	corrupt := append([]byte(nil), snapshot...)
+	corrupt[100] ^= 0xff
+
+	version := append([]byte(nil), snapshot...)
+	version[5] = 99
+
+	type testCase struct {
+		label    string
+		snapshot []byte
+		expected error
+	}
+	cases := []testCase{
+		{label: "empty", snapshot: nil, expected: ErrInvalidSnapshot},
+		{label: "wrong magic", snapshot: []byte("XXXX\x00\x01"), expected: ErrInvalidSnapshot},
+		{label: "unknown version", snapshot: version, expected: ErrSnapshotVersion},
+		{label: "corrupted", snapshot: corrupt, expected: ErrSnapshotChecksum},
+		{label: "truncated", snapshot: snapshot[:len(snapshot)-10], expected: ErrSnapshotChecksum},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := newTestCPU()
+			before := c.Snapshot()
+			err := c.Restore(tc.snapshot)
+			assert.Equal(t, tc.expected, errors.Cause(err))
+			assert.Equal(t, before, c.Snapshot(), "a failed restore leaves the CPU untouched")
+		})
+	}
+}
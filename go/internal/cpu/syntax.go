@@ -0,0 +1,409 @@
+package cpu
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Labels names addresses, returning the label to write in place of addr, or
+// "" to write the address itself. A nil Labels names nothing.
+type Labels func(addr uint16) string
+
+// Syntax writes decoded instructions as assembly source in a particular
+// dialect.
+type Syntax interface {
+	// Format returns the instruction written in the syntax, naming the
+	// addresses it refers to with labels.
+	Format(in DecodedInstruction, labels Labels) string
+}
+
+var (
+	// Classic is the mnemonic dialect of the disassembler and assembler,
+	// such as "MVI V0,#$05" and "SPRITE. V0,V1,#$5".
+	Classic Syntax = classicSyntax{}
+	// Cowgod is the syntax of Cowgod's technical reference, such as
+	// "LD V0, #05" and "DRW V0, V1, 5".
+	Cowgod Syntax = cowgodSyntax{}
+	// Octo is the syntax of the Octo assembler, such as "v0 := 0x05" and
+	// "sprite v0 v1 0x5".
+	Octo Syntax = octoSyntax{}
+)
+
+// syntaxes maps the names syntaxes are chosen by to the syntaxes.
+var syntaxes = map[string]Syntax{
+	"classic": Classic,
+	"cowgod":  Cowgod,
+	"octo":    Octo,
+}
+
+// SyntaxByName returns the syntax with the name, as listed by SyntaxNames.
+func SyntaxByName(name string) (Syntax, bool) {
+	s, ok := syntaxes[name]
+	return s, ok
+}
+
+// SyntaxNames returns the names of the syntaxes in sorted order.
+func SyntaxNames() []string {
+	names := make([]string, 0, len(syntaxes))
+	for name := range syntaxes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// address returns the label of addr, or addr formatted with format if it
+// has none.
+func (l Labels) address(addr uint16, format string) string {
+	if l != nil {
+		if name := l(addr); name != "" {
+			return name
+		}
+	}
+	return fmt.Sprintf(format, addr)
+}
+
+type classicSyntax struct{}
+
+func (classicSyntax) Format(in DecodedInstruction, labels Labels) string {
+	x, y := in.X, in.Y
+
+	switch in.Mnemonic {
+	case Op00Cn:
+		return fmt.Sprintf("%-10s #$%01x", "SCROLL.DN", in.N)
+	case Op00Dn:
+		return fmt.Sprintf("%-10s #$%01x", "SCROLL.UP", in.N)
+	case Op00E0:
+		return fmt.Sprintf("%-10s", "CLS")
+	case Op00EE:
+		return fmt.Sprintf("%-10s", "RTS")
+	case Op00FB:
+		return fmt.Sprintf("%-10s", "SCROLL.RT")
+	case Op00FC:
+		return fmt.Sprintf("%-10s", "SCROLL.LT")
+	case Op00FD:
+		return fmt.Sprintf("%-10s", "EXIT")
+	case Op00FE:
+		return fmt.Sprintf("%-10s", "LORES")
+	case Op00FF:
+		return fmt.Sprintf("%-10s", "HIRES")
+	case Op1nnn:
+		return fmt.Sprintf("%-10s %s", "JUMP", labels.address(in.NNN, "$%03x"))
+	case Op2nnn:
+		return fmt.Sprintf("%-10s %s", "CALL", labels.address(in.NNN, "$%03x"))
+	case Op3xkk:
+		return fmt.Sprintf("%-10s V%01X,#$%02x", "SKIP.EQ", x, in.KK)
+	case Op4xkk:
+		return fmt.Sprintf("%-10s V%01X,#$%02x", "SKIP.NE", x, in.KK)
+	case Op5xy0:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "SKIP.EQ", x, y)
+	case Op5xy2:
+		return fmt.Sprintf("%-10s (I),V%01X-V%01X", "MOVR", x, y)
+	case Op5xy3:
+		return fmt.Sprintf("%-10s V%01X-V%01X,(I)", "MOVR", x, y)
+	case Op6xkk:
+		return fmt.Sprintf("%-10s V%01X,#$%02x", "MVI", x, in.KK)
+	case Op7xkk:
+		return fmt.Sprintf("%-10s V%01X,#$%02x", "ADI", x, in.KK)
+	case Op8xy0:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "MOV", x, y)
+	case Op8xy1:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "OR", x, y)
+	case Op8xy2:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "AND", x, y)
+	case Op8xy3:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "XOR", x, y)
+	case Op8xy4:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "ADD.", x, y)
+	case Op8xy5:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "SUB.", x, y)
+	case Op8xy6:
+		if y != x {
+			return fmt.Sprintf("%-10s V%01X,V%01X", "SHR.", x, y)
+		}
+		return fmt.Sprintf("%-10s V%01X", "SHR.", x)
+	case Op8xy7:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "SUBB.", x, y)
+	case Op8xyE:
+		if y != x {
+			return fmt.Sprintf("%-10s V%01X,V%01X", "SHL.", x, y)
+		}
+		return fmt.Sprintf("%-10s V%01X", "SHL.", x)
+	case Op9xy0:
+		return fmt.Sprintf("%-10s V%01X,V%01X", "SKIP.NE", x, y)
+	case OpAnnn:
+		return fmt.Sprintf("%-10s I,%s", "MVI", labels.address(in.NNN, "#$%03x"))
+	case OpBnnn:
+		return fmt.Sprintf("%-10s %s(V0)", "JUMP", labels.address(in.NNN, "$%03x"))
+	case OpCxkk:
+		return fmt.Sprintf("%-10s V%01X,#$%02x", "RND", x, in.KK)
+	case OpDxyn:
+		return fmt.Sprintf("%-10s V%01X,V%01X,#$%01X", "SPRITE.", x, y, in.N)
+	case OpEx9E:
+		return fmt.Sprintf("%-10s V%01X", "SKIP.KEY", x)
+	case OpExA1:
+		return fmt.Sprintf("%-10s V%01X", "SKIP.NOKEY", x)
+	case OpF000:
+		return fmt.Sprintf("%-10s I,%s", "MVIL", labels.address(in.Operand, "#$%04x"))
+	case OpFn01:
+		return fmt.Sprintf("%-10s #$%01x", "PLANE", x)
+	case OpF002:
+		return fmt.Sprintf("%-10s", "AUDIO")
+	case OpFx07:
+		return fmt.Sprintf("%-10s V%01X,DELAY", "MOV", x)
+	case OpFx0A:
+		return fmt.Sprintf("%-10s V%01X", "WAITKEY", x)
+	case OpFx15:
+		return fmt.Sprintf("%-10s DELAY,V%01X", "MOV", x)
+	case OpFx18:
+		return fmt.Sprintf("%-10s SOUND,V%01X", "MOV", x)
+	case OpFx1E:
+		return fmt.Sprintf("%-10s I,V%01X", "ADD", x)
+	case OpFx29:
+		return fmt.Sprintf("%-10s V%01X", "SPRITECHAR", x)
+	case OpFx30:
+		return fmt.Sprintf("%-10s V%01X", "SPRITEBIG", x)
+	case OpFx33:
+		return fmt.Sprintf("%-10s V%01X", "MOVBCD", x)
+	case OpFx3A:
+		return fmt.Sprintf("%-10s V%01X", "PITCH", x)
+	case OpFx55:
+		return fmt.Sprintf("%-10s (I),V0-V%01X", "MOVM", x)
+	case OpFx65:
+		return fmt.Sprintf("%-10s V0-V%01X,(I)", "MOVM", x)
+	case OpFx75:
+		return fmt.Sprintf("%-10s RPL,V0-V%01X", "MOVM", x)
+	case OpFx85:
+		return fmt.Sprintf("%-10s V0-V%01X,RPL", "MOVM", x)
+	}
+	// unknown opcodes, and the SYS instruction 0nnn, which the dialect has
+	// no name for
+	return fmt.Sprintf("%-10s 0x%04x", "UNK", uint16(in.Opcode))
+}
+
+type cowgodSyntax struct{}
+
+func (cowgodSyntax) Format(in DecodedInstruction, labels Labels) string {
+	x, y := in.X, in.Y
+
+	switch in.Mnemonic {
+	case Op0nnn:
+		return fmt.Sprintf("%-5s %s", "SYS", labels.address(in.NNN, "#%03x"))
+	case Op00Cn:
+		return fmt.Sprintf("%-5s %d", "SCD", in.N)
+	case Op00Dn:
+		return fmt.Sprintf("%-5s %d", "SCU", in.N)
+	case Op00E0:
+		return "CLS"
+	case Op00EE:
+		return "RET"
+	case Op00FB:
+		return "SCR"
+	case Op00FC:
+		return "SCL"
+	case Op00FD:
+		return "EXIT"
+	case Op00FE:
+		return "LOW"
+	case Op00FF:
+		return "HIGH"
+	case Op1nnn:
+		return fmt.Sprintf("%-5s %s", "JP", labels.address(in.NNN, "#%03x"))
+	case Op2nnn:
+		return fmt.Sprintf("%-5s %s", "CALL", labels.address(in.NNN, "#%03x"))
+	case Op3xkk:
+		return fmt.Sprintf("%-5s V%X, #%02x", "SE", x, in.KK)
+	case Op4xkk:
+		return fmt.Sprintf("%-5s V%X, #%02x", "SNE", x, in.KK)
+	case Op5xy0:
+		return fmt.Sprintf("%-5s V%X, V%X", "SE", x, y)
+	case Op5xy2:
+		return fmt.Sprintf("%-5s [I], V%X-V%X", "LD", x, y)
+	case Op5xy3:
+		return fmt.Sprintf("%-5s V%X-V%X, [I]", "LD", x, y)
+	case Op6xkk:
+		return fmt.Sprintf("%-5s V%X, #%02x", "LD", x, in.KK)
+	case Op7xkk:
+		return fmt.Sprintf("%-5s V%X, #%02x", "ADD", x, in.KK)
+	case Op8xy0:
+		return fmt.Sprintf("%-5s V%X, V%X", "LD", x, y)
+	case Op8xy1:
+		return fmt.Sprintf("%-5s V%X, V%X", "OR", x, y)
+	case Op8xy2:
+		return fmt.Sprintf("%-5s V%X, V%X", "AND", x, y)
+	case Op8xy3:
+		return fmt.Sprintf("%-5s V%X, V%X", "XOR", x, y)
+	case Op8xy4:
+		return fmt.Sprintf("%-5s V%X, V%X", "ADD", x, y)
+	case Op8xy5:
+		return fmt.Sprintf("%-5s V%X, V%X", "SUB", x, y)
+	case Op8xy6:
+		return fmt.Sprintf("%-5s V%X, V%X", "SHR", x, y)
+	case Op8xy7:
+		return fmt.Sprintf("%-5s V%X, V%X", "SUBN", x, y)
+	case Op8xyE:
+		return fmt.Sprintf("%-5s V%X, V%X", "SHL", x, y)
+	case Op9xy0:
+		return fmt.Sprintf("%-5s V%X, V%X", "SNE", x, y)
+	case OpAnnn:
+		return fmt.Sprintf("%-5s I, %s", "LD", labels.address(in.NNN, "#%03x"))
+	case OpBnnn:
+		return fmt.Sprintf("%-5s V0, %s", "JP", labels.address(in.NNN, "#%03x"))
+	case OpCxkk:
+		return fmt.Sprintf("%-5s V%X, #%02x", "RND", x, in.KK)
+	case OpDxyn:
+		return fmt.Sprintf("%-5s V%X, V%X, %d", "DRW", x, y, in.N)
+	case OpEx9E:
+		return fmt.Sprintf("%-5s V%X", "SKP", x)
+	case OpExA1:
+		return fmt.Sprintf("%-5s V%X", "SKNP", x)
+	case OpF000:
+		return fmt.Sprintf("%-5s I, %s", "LD", labels.address(in.Operand, "#%04x"))
+	case OpFn01:
+		return fmt.Sprintf("%-5s %d", "PLANE", x)
+	case OpF002:
+		return "AUDIO"
+	case OpFx07:
+		return fmt.Sprintf("%-5s V%X, DT", "LD", x)
+	case OpFx0A:
+		return fmt.Sprintf("%-5s V%X, K", "LD", x)
+	case OpFx15:
+		return fmt.Sprintf("%-5s DT, V%X", "LD", x)
+	case OpFx18:
+		return fmt.Sprintf("%-5s ST, V%X", "LD", x)
+	case OpFx1E:
+		return fmt.Sprintf("%-5s I, V%X", "ADD", x)
+	case OpFx29:
+		return fmt.Sprintf("%-5s F, V%X", "LD", x)
+	case OpFx30:
+		return fmt.Sprintf("%-5s HF, V%X", "LD", x)
+	case OpFx33:
+		return fmt.Sprintf("%-5s B, V%X", "LD", x)
+	case OpFx3A:
+		return fmt.Sprintf("%-5s PITCH, V%X", "LD", x)
+	case OpFx55:
+		return fmt.Sprintf("%-5s [I], V%X", "LD", x)
+	case OpFx65:
+		return fmt.Sprintf("%-5s V%X, [I]", "LD", x)
+	case OpFx75:
+		return fmt.Sprintf("%-5s R, V%X", "LD", x)
+	case OpFx85:
+		return fmt.Sprintf("%-5s V%X, R", "LD", x)
+	}
+	return fmt.Sprintf("%-5s #%04x", "DW", uint16(in.Opcode))
+}
+
+type octoSyntax struct{}
+
+// Octo has no instructions that skip, only conditions that are the inverse
+// of the skip: "if v0 != 5 then" skips the next instruction when V0 is 5.
+func (octoSyntax) Format(in DecodedInstruction, labels Labels) string {
+	x, y := in.X, in.Y
+
+	switch in.Mnemonic {
+	case Op00Cn:
+		return fmt.Sprintf("scroll-down %d", in.N)
+	case Op00Dn:
+		return fmt.Sprintf("scroll-up %d", in.N)
+	case Op00E0:
+		return "clear"
+	case Op00EE:
+		return "return"
+	case Op00FB:
+		return "scroll-right"
+	case Op00FC:
+		return "scroll-left"
+	case Op00FD:
+		return "exit"
+	case Op00FE:
+		return "lores"
+	case Op00FF:
+		return "hires"
+	case Op1nnn:
+		return fmt.Sprintf("jump %s", labels.address(in.NNN, "0x%03x"))
+	case Op2nnn:
+		return fmt.Sprintf(":call %s", labels.address(in.NNN, "0x%03x"))
+	case Op3xkk:
+		return fmt.Sprintf("if v%x != 0x%02x then", x, in.KK)
+	case Op4xkk:
+		return fmt.Sprintf("if v%x == 0x%02x then", x, in.KK)
+	case Op5xy0:
+		return fmt.Sprintf("if v%x != v%x then", x, y)
+	case Op5xy2:
+		return fmt.Sprintf("save v%x - v%x", x, y)
+	case Op5xy3:
+		return fmt.Sprintf("load v%x - v%x", x, y)
+	case Op6xkk:
+		return fmt.Sprintf("v%x := 0x%02x", x, in.KK)
+	case Op7xkk:
+		return fmt.Sprintf("v%x += 0x%02x", x, in.KK)
+	case Op8xy0:
+		return fmt.Sprintf("v%x := v%x", x, y)
+	case Op8xy1:
+		return fmt.Sprintf("v%x |= v%x", x, y)
+	case Op8xy2:
+		return fmt.Sprintf("v%x &= v%x", x, y)
+	case Op8xy3:
+		return fmt.Sprintf("v%x ^= v%x", x, y)
+	case Op8xy4:
+		return fmt.Sprintf("v%x += v%x", x, y)
+	case Op8xy5:
+		return fmt.Sprintf("v%x -= v%x", x, y)
+	case Op8xy6:
+		return fmt.Sprintf("v%x >>= v%x", x, y)
+	case Op8xy7:
+		return fmt.Sprintf("v%x =- v%x", x, y)
+	case Op8xyE:
+		return fmt.Sprintf("v%x <<= v%x", x, y)
+	case Op9xy0:
+		return fmt.Sprintf("if v%x == v%x then", x, y)
+	case OpAnnn:
+		return fmt.Sprintf("i := %s", labels.address(in.NNN, "0x%03x"))
+	case OpBnnn:
+		return fmt.Sprintf("jump0 %s", labels.address(in.NNN, "0x%03x"))
+	case OpCxkk:
+		return fmt.Sprintf("v%x := random 0x%02x", x, in.KK)
+	case OpDxyn:
+		return fmt.Sprintf("sprite v%x v%x 0x%x", x, y, in.N)
+	case OpEx9E:
+		return fmt.Sprintf("if v%x -key then", x)
+	case OpExA1:
+		return fmt.Sprintf("if v%x key then", x)
+	case OpF000:
+		return fmt.Sprintf("i := long %s", labels.address(in.Operand, "0x%04x"))
+	case OpFn01:
+		return fmt.Sprintf("plane %d", x)
+	case OpF002:
+		return "audio"
+	case OpFx07:
+		return fmt.Sprintf("v%x := delay", x)
+	case OpFx0A:
+		return fmt.Sprintf("v%x := key", x)
+	case OpFx15:
+		return fmt.Sprintf("delay := v%x", x)
+	case OpFx18:
+		return fmt.Sprintf("buzzer := v%x", x)
+	case OpFx1E:
+		return fmt.Sprintf("i += v%x", x)
+	case OpFx29:
+		return fmt.Sprintf("i := hex v%x", x)
+	case OpFx30:
+		return fmt.Sprintf("i := bighex v%x", x)
+	case OpFx33:
+		return fmt.Sprintf("bcd v%x", x)
+	case OpFx3A:
+		return fmt.Sprintf("pitch := v%x", x)
+	case OpFx55:
+		return fmt.Sprintf("save v%x", x)
+	case OpFx65:
+		return fmt.Sprintf("load v%x", x)
+	case OpFx75:
+		return fmt.Sprintf("saveflags v%x", x)
+	case OpFx85:
+		return fmt.Sprintf("loadflags v%x", x)
+	}
+	// Octo has no SYS instruction, so it and unknown opcodes are written as
+	// the bytes themselves
+	first, second := in.Opcode.Bytes()
+	return fmt.Sprintf("0x%02x 0x%02x", first, second)
+}
@@ -0,0 +1,115 @@
+package cpu_test
+
+import (
+	"testing"
+
+	"chip-8/internal/cpu"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntax_Format(t *testing.T) {
+	type testCase struct {
+		label   string
+		opcode  cpu.Opcode
+		operand uint16
+		classic string
+		cowgod  string
+		octo    string
+	}
+	cases := []testCase{
+		{
+			label:   "00E0 clear the display",
+			opcode:  0x00E0,
+			classic: "CLS       ",
+			cowgod:  "CLS",
+			octo:    "clear",
+		},
+		{
+			label:   "2nnn call a subroutine",
+			opcode:  0x2345,
+			classic: "CALL       $345",
+			cowgod:  "CALL  #345",
+			octo:    ":call 0x345",
+		},
+		{
+			label:   "3xkk skip if Vx equals kk",
+			opcode:  0x3A12,
+			classic: "SKIP.EQ    VA,#$12",
+			cowgod:  "SE    VA, #12",
+			octo:    "if va != 0x12 then",
+		},
+		{
+			label:   "8xy6 shift right",
+			opcode:  0x8126,
+			classic: "SHR.       V1,V2",
+			cowgod:  "SHR   V1, V2",
+			octo:    "v1 >>= v2",
+		},
+		{
+			label:   "Bnnn jump with offset",
+			opcode:  0xB300,
+			classic: "JUMP       $300(V0)",
+			cowgod:  "JP    V0, #300",
+			octo:    "jump0 0x300",
+		},
+		{
+			label:   "Dxyn draw a sprite",
+			opcode:  0xD12F,
+			classic: "SPRITE.    V1,V2,#$F",
+			cowgod:  "DRW   V1, V2, 15",
+			octo:    "sprite v1 v2 0xf",
+		},
+		{
+			label:   "ExA1 skip if the key isn't pressed",
+			opcode:  0xE4A1,
+			classic: "SKIP.NOKEY V4",
+			cowgod:  "SKNP  V4",
+			octo:    "if v4 key then",
+		},
+		{
+			label:   "F000 load a 16 bit address",
+			opcode:  0xF000,
+			operand: 0x1234,
+			classic: "MVIL       I,#$1234",
+			cowgod:  "LD    I, #1234",
+			octo:    "i := long 0x1234",
+		},
+		{
+			label:   "Fx65 load registers",
+			opcode:  0xF365,
+			classic: "MOVM       V0-V3,(I)",
+			cowgod:  "LD    V3, [I]",
+			octo:    "load v3",
+		},
+		{
+			label:   "unknown opcode",
+			opcode:  0x800F,
+			classic: "UNK        0x800f",
+			cowgod:  "DW    #800f",
+			octo:    "0x80 0x0f",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			in := cpu.DecodeLong(c.opcode, c.operand)
+			assert.Equal(t, c.classic, cpu.Classic.Format(in, nil))
+			assert.Equal(t, c.cowgod, cpu.Cowgod.Format(in, nil))
+			assert.Equal(t, c.octo, cpu.Octo.Format(in, nil))
+		})
+	}
+}
+
+func TestSyntax_Format_Labels(t *testing.T) {
+	labels := func(addr uint16) string {
+		if addr == 0x300 {
+			return "sprite"
+		}
+		return ""
+	}
+
+	assert.Equal(t, "MVI        I,sprite", cpu.Classic.Format(cpu.Decode(0xA300), labels))
+	assert.Equal(t, "LD    I, sprite", cpu.Cowgod.Format(cpu.Decode(0xA300), labels))
+	assert.Equal(t, "i := sprite", cpu.Octo.Format(cpu.Decode(0xA300), labels))
+	assert.Equal(t, "jump 0x302", cpu.Octo.Format(cpu.Decode(0x1302), labels))
+}
@@ -0,0 +1,96 @@
+package cpu
+
+const (
+	// VIPClockHz is the clock rate of the COSMAC VIP's CDP1802 processor.
+	VIPClockHz = 1760640
+	// VIPFrameCycles is the number of CDP1802 machine cycles, of 8 clock
+	// cycles each, in every 60 Hz frame of the COSMAC VIP.
+	VIPFrameCycles = VIPClockHz / 8 / 60
+	// VIPDisplayCycles is the number of machine cycles of each frame taken
+	// from the interpreter by the display: the DMA of 8 bytes for each of
+	// the 128 lines drawn, and about 50 more for the interrupt routine that
+	// sets it up and counts down the timers.
+	VIPDisplayCycles = 128*8 + 50
+
+	// vipFetchCycles is the number of machine cycles the interpreter takes to
+	// fetch an instruction and jump to the routine that executes it.
+	vipFetchCycles = 40
+)
+
+// vipCycles is the number of machine cycles the routine of each instruction
+// takes on the COSMAC VIP interpreter, not counting fetching it, or the extra
+// cycles of skipping, of the loops in clearing the screen, drawing, BCD, and
+// saving and loading registers, which are added by VIPCycles. Instructions
+// the VIP doesn't have cost nothing more than being fetched.
+var vipCycles = [numMnemonics]int{
+	Op00E0: 24,
+	Op00EE: 10,
+	Op1nnn: 12,
+	Op2nnn: 26,
+	Op3xkk: 10,
+	Op4xkk: 10,
+	Op5xy0: 14,
+	Op6xkk: 6,
+	Op7xkk: 10,
+	Op8xy0: 12,
+	Op8xy1: 44,
+	Op8xy2: 44,
+	Op8xy3: 44,
+	Op8xy4: 44,
+	Op8xy5: 44,
+	Op8xy6: 44,
+	Op8xy7: 44,
+	Op8xyE: 44,
+	Op9xy0: 14,
+	OpAnnn: 12,
+	OpBnnn: 22,
+	OpCxkk: 36,
+	OpDxyn: 26,
+	OpEx9E: 14,
+	OpExA1: 14,
+	OpFx07: 10,
+	OpFx0A: 18,
+	OpFx15: 10,
+	OpFx18: 10,
+	OpFx1E: 16,
+	OpFx29: 16,
+	OpFx33: 80,
+	OpFx55: 14,
+	OpFx65: 14,
+}
+
+// VIPCycles returns the number of machine cycles the COSMAC VIP interpreter
+// took to fetch and execute the instruction last executed by Cycle, and
+// whether the interpreter then waits for the vertical blank interrupt, as it
+// does after drawing a sprite so the sprite doesn't tear.
+//
+// The fixed costs follow Laurence Scotford's study of the VIP interpreter.
+// The costs of its loops are modelled on their shape: 12 cycles for each of
+// the 256 bytes of the display cleared, 34 for each sprite row drawn plus 6
+// for each place the row is shifted by to line it up with the display bytes,
+// 16 for each count of a BCD digit, and 14 for each register saved or loaded.
+// They are charged for the value Vx held before the instruction, which DFyn
+// overwrites with the collision flag.
+func (c *CPU) VIPCycles() (cycles int, vblank bool) {
+	in := &c.instruction
+	cycles = vipFetchCycles + vipCycles[in.Mnemonic]
+
+	switch in.Mnemonic {
+	case Op3xkk, Op4xkk, Op5xy0, Op9xy0, OpEx9E, OpExA1:
+		if c.skipped {
+			cycles += 4
+		}
+	case Op00E0:
+		cycles += 256 * 12
+	case OpDxyn:
+		shift := int(c.vx % 8)
+		cycles += int(in.N) * (34 + 6*shift)
+		vblank = true
+	case OpFx33:
+		v := c.vx
+		cycles += 16 * int(v/100+v/10%10+v%10)
+	case OpFx55, OpFx65:
+		cycles += 14 * (int(in.X) + 1)
+	}
+	return cycles, vblank
+}
@@ -0,0 +1,67 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPU_VIPCycles(t *testing.T) {
+	type testCase struct {
+		label          string
+		opcode         Opcode
+		setup          func(c *CPU)
+		expectedCycles int
+		expectedVBlank bool
+	}
+	cases := []testCase{
+		{label: "6xkk", opcode: 0x6012, expectedCycles: 46},
+		{label: "3xkk not skipping", opcode: 0x3001, expectedCycles: 50},
+		{label: "3xkk skipping", opcode: 0x3000, expectedCycles: 54},
+		{label: "00E0 clears every display byte", opcode: 0x00e0, expectedCycles: 40 + 24 + 256*12},
+		{
+			label:          "Dxyn aligned",
+			opcode:         0xd012,
+			expectedCycles: 40 + 26 + 2*34,
+			expectedVBlank: true,
+		},
+		{
+			label:          "Dxyn shifted",
+			opcode:         0xd011,
+			setup:          func(c *CPU) { c.V[0] = 11 },
+			expectedCycles: 40 + 26 + 34 + 3*6,
+			expectedVBlank: true,
+		},
+		{
+			label:          "DFyn shifted by VF before the collision flag",
+			opcode:         0xdf11,
+			setup:          func(c *CPU) { c.V[0xf] = 11 },
+			expectedCycles: 40 + 26 + 34 + 3*6,
+			expectedVBlank: true,
+		},
+		{
+			label:          "Fx33 counts each digit",
+			opcode:         0xf033,
+			setup:          func(c *CPU) { c.V[0] = 123 },
+			expectedCycles: 40 + 80 + 6*16,
+		},
+		{label: "Fx55 saves each register", opcode: 0xf255, expectedCycles: 40 + 14 + 3*14},
+		{label: "Fx65 loads each register", opcode: 0xf065, expectedCycles: 40 + 14 + 14},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := newTestCPU(tc.opcode)
+			c.I = 0x300
+			if tc.setup != nil {
+				tc.setup(c)
+			}
+			require.NoError(t, c.Cycle())
+
+			cycles, vblank := c.VIPCycles()
+			assert.Equal(t, tc.expectedCycles, cycles)
+			assert.Equal(t, tc.expectedVBlank, vblank)
+		})
+	}
+}
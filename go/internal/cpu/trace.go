@@ -0,0 +1,98 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tracer is told about every instruction the CPU executes, for logging
+// execution traces.
+type Tracer interface {
+	Trace(e TraceEvent)
+}
+
+// TraceEvent describes an executed instruction.
+type TraceEvent struct {
+	// PC is the address of the instruction.
+	PC uint16
+	// Opcode is the instruction. The operand of a 4 byte XO-CHIP
+	// instruction is part of Instruction only.
+	Opcode Opcode
+	// Instruction is the disassembled instruction, as returned by
+	// Opcode.Instruction without the padding.
+	Instruction string
+	// Changes lists the registers the instruction changed, in the order V0
+	// to VF, I, DELAY, SOUND.
+	Changes []RegisterChange
+	// Err is the reason the instruction failed, or nil if it didn't.
+	Err error
+}
+
+// RegisterChange is a change to the value of a register, which is one of V0
+// to VF, I, DELAY, or SOUND.
+type RegisterChange struct {
+	Register string
+	Old      uint16
+	New      uint16
+}
+
+func (r RegisterChange) String() string {
+	if r.Register == "I" {
+		return fmt.Sprintf("I:%04x->%04x", r.Old, r.New)
+	}
+	return fmt.Sprintf("%s:%02x->%02x", r.Register, r.Old, r.New)
+}
+
+// traceState is the state compared before and after an instruction to find
+// the registers it changed.
+type traceState struct {
+	V     [16]byte
+	I     uint16
+	delay byte
+	sound byte
+}
+
+// SetTracer sets the Tracer told about every executed instruction, or turns
+// tracing off if it is nil.
+func (c *CPU) SetTracer(t Tracer) {
+	c.tracer = t
+}
+
+func (c *CPU) traceState() traceState {
+	return traceState{V: c.V, I: c.I, delay: c.delay, sound: c.sound}
+}
+
+// trace tells the tracer about the instruction just executed at pc.
+func (c *CPU) trace(pc uint16, opcode Opcode, before traceState, err error) {
+	after := c.traceState()
+	e := TraceEvent{PC: pc, Opcode: opcode, Err: err}
+
+	e.Instruction = opcode.Instruction()
+	if size := opcode.Size(); size > 2 {
+		switch {
+		case !c.xochip:
+			// the CPU ran it as a 2 byte unknown opcode
+			e.Instruction = Classic.Format(DecodedInstruction{Opcode: opcode}, nil)
+		case int(pc)+size <= c.memSize:
+			operand := uint16(c.memory[pc+2])<<8 | uint16(c.memory[pc+3])
+			e.Instruction = opcode.LongInstruction(operand)
+		}
+	}
+	e.Instruction = strings.TrimRight(e.Instruction, " ")
+
+	for x := range after.V {
+		if before.V[x] != after.V[x] {
+			e.Changes = append(e.Changes, RegisterChange{fmt.Sprintf("V%X", x), uint16(before.V[x]), uint16(after.V[x])})
+		}
+	}
+	if before.I != after.I {
+		e.Changes = append(e.Changes, RegisterChange{"I", before.I, after.I})
+	}
+	if before.delay != after.delay {
+		e.Changes = append(e.Changes, RegisterChange{"DELAY", uint16(before.delay), uint16(after.delay)})
+	}
+	if before.sound != after.sound {
+		e.Changes = append(e.Changes, RegisterChange{"SOUND", uint16(before.sound), uint16(after.sound)})
+	}
+	c.tracer.Trace(e)
+}
@@ -0,0 +1,72 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTracer []TraceEvent
+
+func (r *recordingTracer) Trace(e TraceEvent) {
+	*r = append(*r, e)
+}
+
+func TestCPU_SetTracer(t *testing.T) {
+	c := newTestCPU(0x6005, 0xa123, 0xf015, 0x8004, 0x00ee)
+	var events recordingTracer
+	c.SetTracer(&events)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, c.Cycle())
+	}
+	require.Error(t, c.Cycle())
+
+	require.Len(t, events, 5)
+	assert.Equal(t, TraceEvent{
+		PC:          0x200,
+		Opcode:      0x6005,
+		Instruction: "MVI        V0,#$05",
+		Changes:     []RegisterChange{{"V0", 0x00, 0x05}},
+	}, events[0])
+	assert.Equal(t, []RegisterChange{{"I", 0x000, 0x123}}, events[1].Changes)
+	assert.Equal(t, []RegisterChange{{"DELAY", 0x00, 0x05}}, events[2].Changes)
+	assert.Equal(t, []RegisterChange{{"V0", 0x05, 0x0a}}, events[3].Changes, "VF staying 0 is not a change")
+	assert.Equal(t, "I:0000->0123", events[1].Changes[0].String())
+	assert.Equal(t, "DELAY:00->05", events[2].Changes[0].String())
+
+	assert.Equal(t, uint16(0x208), events[4].PC)
+	assert.Equal(t, ErrStackUnderflow, errors.Cause(events[4].Err))
+	assert.Empty(t, events[4].Changes)
+
+	c.SetTracer(nil)
+	c.ClearFault()
+	c.pc = 0x200
+	require.NoError(t, c.Cycle())
+	assert.Len(t, events, 5, "tracing should stop once the tracer is removed")
+}
+
+func TestCPU_SetTracer_LongInstruction(t *testing.T) {
+	c := newTestCPU(0xf000, 0x1234)
+	c.SetXOCHIP(true)
+	var events recordingTracer
+	c.SetTracer(&events)
+
+	require.NoError(t, c.Cycle())
+	require.Len(t, events, 1)
+	assert.Equal(t, "MVIL       I,#$1234", events[0].Instruction)
+	assert.Equal(t, []RegisterChange{{"I", 0x0000, 0x1234}}, events[0].Changes)
+}
+
+func TestCPU_SetTracer_LongInstructionOutsideXOCHIP(t *testing.T) {
+	c := newTestCPU(0xf000, 0x1234)
+	var events recordingTracer
+	c.SetTracer(&events)
+
+	require.Error(t, c.Cycle())
+	require.Len(t, events, 1)
+	assert.Equal(t, "UNK        0xf000", events[0].Instruction, "F000 is a 2 byte unknown opcode outside XO-CHIP")
+	assert.Equal(t, ErrUnknownOpcode, errors.Cause(events[0].Err))
+}
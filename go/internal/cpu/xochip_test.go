@@ -0,0 +1,170 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPU_XOCHIP(t *testing.T) {
+	type testCase struct {
+		label   string
+		opcodes []Opcode
+		setup   func(c *CPU)
+		check   func(t *testing.T, c *CPU)
+	}
+	cases := []testCase{
+		{
+			label:   "F000 nnnn set I = nnnn",
+			opcodes: []Opcode{0xF000, 0x1234},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x1234), c.I)
+				assert.Equal(t, uint16(0x204), c.pc)
+			},
+		},
+		{
+			label:   "skips step over F000 nnnn",
+			opcodes: []Opcode{0x3000, 0xF000, 0x1234},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, uint16(0x206), c.pc)
+			},
+		},
+		{
+			label:   "5xy2 store Vx through Vy in memory",
+			opcodes: []Opcode{0x5132},
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.V[1], c.V[2], c.V[3] = 1, 2, 3
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{1, 2, 3, 0}, c.memory[0x300:0x304])
+				assert.Equal(t, uint16(0x300), c.I)
+			},
+		},
+		{
+			label:   "5xy2 stores in reverse order when x > y",
+			opcodes: []Opcode{0x5312},
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.V[1], c.V[2], c.V[3] = 1, 2, 3
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{3, 2, 1}, c.memory[0x300:0x303])
+			},
+		},
+		{
+			label:   "5xy3 read Vx through Vy from memory",
+			opcodes: []Opcode{0x5233},
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301], c.memory[0x302] = 7, 8, 9
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(0), c.V[1])
+				assert.Equal(t, byte(7), c.V[2])
+				assert.Equal(t, byte(8), c.V[3])
+				assert.Equal(t, uint16(0x300), c.I)
+			},
+		},
+		{
+			label:   "Dxyn draws to each selected plane",
+			opcodes: []Opcode{0xF301, 0xD011},
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x301] = 0xc0, 0x60
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{1, 3, 2, 0}, c.Screen()[:4])
+				assert.Equal(t, byte(0), c.V[0xf])
+			},
+		},
+		{
+			label:   "Dxyn only draws to the selected plane",
+			opcodes: []Opcode{0xF201, 0xD011},
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300] = 0x80
+				c.screen[0] = 1
+			},
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(3), c.screen[0])
+				assert.Equal(t, byte(0), c.V[0xf], "pixels in other planes don't collide")
+			},
+		},
+		{
+			label:   "00E0 only clears the selected plane",
+			opcodes: []Opcode{0xF201, 0x00E0},
+			setup:   func(c *CPU) { c.screen[0], c.screen[1] = 3, 2 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{1, 0}, c.screen[:2])
+			},
+		},
+		{
+			label:   "00Dn scrolls the selected plane up n pixels",
+			opcodes: []Opcode{0xF101, 0x00D2},
+			setup:   func(c *CPU) { c.screen[2*ScreenWidth], c.screen[3*ScreenWidth] = 3, 2 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, byte(1), c.screen[0])
+				assert.Equal(t, byte(2), c.screen[2*ScreenWidth])
+				assert.Equal(t, byte(2), c.screen[3*ScreenWidth])
+			},
+		},
+		{
+			label:   "F002 load the audio pattern and Fx3A set the pitch",
+			opcodes: []Opcode{0xF002, 0xF53A},
+			setup: func(c *CPU) {
+				c.I = 0x300
+				c.memory[0x300], c.memory[0x30f] = 0xaa, 0x55
+				c.V[5] = 112
+			},
+			check: func(t *testing.T, c *CPU) {
+				pattern, rate := c.AudioPattern()
+				assert.Equal(t, byte(0xaa), pattern[0])
+				assert.Equal(t, byte(0x55), pattern[15])
+				assert.InDelta(t, 8000, rate, 0.001)
+			},
+		},
+		{
+			label:   "memory is addressable past 4 KiB",
+			opcodes: []Opcode{0xF000, 0x8000, 0xF155},
+			setup:   func(c *CPU) { c.V[0], c.V[1] = 4, 5 },
+			check: func(t *testing.T, c *CPU) {
+				assert.Equal(t, []byte{4, 5}, c.ReadMemory(0x8000, 2))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := newTestCPU(tc.opcodes...)
+			c.SetXOCHIP(true)
+			if tc.setup != nil {
+				tc.setup(c)
+			}
+			for c.pc < programStart+uint16(2*len(tc.opcodes)) {
+				require.NoError(t, c.Cycle())
+			}
+			tc.check(t, c)
+		})
+	}
+}
+
+func TestCPU_XOCHIPDisabled(t *testing.T) {
+	for _, op := range []Opcode{0xF000, 0x5012, 0x5013, 0xF101, 0xF002, 0xF03A, 0x00D1} {
+		c := newTestCPU(op)
+		err := c.Cycle()
+		assert.Equal(t, ErrUnknownOpcode, errors.Cause(err), "%04x", uint16(op))
+	}
+
+	c := NewCPU()
+	assert.Equal(t, ErrProgramTooLarge, errors.Cause(c.LoadProgram(make([]byte, MemorySize))))
+	c.SetXOCHIP(true)
+	assert.NoError(t, c.LoadProgram(make([]byte, MemorySize)))
+}
+
+func TestCPU_AudioPatternDefaultRate(t *testing.T) {
+	_, rate := NewCPU().AudioPattern()
+	assert.InDelta(t, 4000, rate, 0.001)
+}
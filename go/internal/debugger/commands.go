@@ -0,0 +1,366 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+type command struct {
+	name    string
+	aliases []string
+	usage   string
+	help    string
+	run     func(d *Debugger, args []string) error
+}
+
+// commands is populated in init because the help command refers to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "step", aliases: []string{"s"}, usage: "step [count]",
+			help: "execute count instructions, 1 by default", run: cmdStep},
+		{name: "next", aliases: []string{"n"}, usage: "next",
+			help: "execute one instruction, stepping over subroutine calls", run: cmdNext},
+		{name: "continue", aliases: []string{"c"}, usage: "continue",
+			help: "execute until a breakpoint, watchpoint, or fault", run: cmdContinue},
+		{name: "reverse-step", aliases: []string{"rs"}, usage: "reverse-step [count]",
+			help: "step back count instructions, 1 by default", run: cmdReverseStep},
+		{name: "reverse-continue", aliases: []string{"rc"}, usage: "reverse-continue",
+			help: "step back until a breakpoint or watchpoint, or the start of the history", run: cmdReverseContinue},
+		{name: "break", aliases: []string{"b"}, usage: "break <addr> | break op <pattern>",
+			help: "stop at an address, or at opcodes matching a pattern such as 8xy4 or D**F", run: cmdBreak},
+		{name: "watch", aliases: []string{"w"}, usage: "watch V<x> | watch I | watch mem <addr> [len]",
+			help: "stop when a register or memory range changes", run: cmdWatch},
+		{name: "delete", aliases: []string{"d"}, usage: "delete [id]",
+			help: "delete a breakpoint or watchpoint, or all of them", run: cmdDelete},
+		{name: "info", aliases: []string{"i"}, usage: "info",
+			help: "list breakpoints and watchpoints", run: cmdInfo},
+		{name: "regs", aliases: []string{"r", "registers"}, usage: "regs",
+			help: "print the registers and timers", run: cmdRegs},
+		{name: "stack", aliases: []string{"bt"}, usage: "stack",
+			help: "print the call stack", run: cmdStack},
+		{name: "timers", aliases: []string{"t"}, usage: "timers",
+			help: "print the delay and sound timers", run: cmdTimers},
+		{name: "list", aliases: []string{"l"}, usage: "list [addr]",
+			help: "print the instructions around an address, pc by default", run: cmdList},
+		{name: "mem", aliases: []string{"x"}, usage: "mem <addr> [len]",
+			help: "print len bytes of memory, 16 by default", run: cmdMem},
+		{name: "key", aliases: []string{"k"}, usage: "key <key> [up|down]",
+			help: "press or release a keypad key", run: cmdKey},
+		{name: "help", aliases: []string{"h", "?"}, usage: "help",
+			help: "print this help", run: cmdHelp},
+		{name: "quit", aliases: []string{"q", "exit"}, usage: "quit",
+			help: "end the debugging session"},
+	}
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+func cmdStep(d *Debugger, args []string) error {
+	count := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return errors.Errorf("invalid count %q", args[0])
+		}
+		count = n
+	}
+	d.run(count, nil)
+	return nil
+}
+
+func cmdNext(d *Debugger, _ []string) error {
+	regs := d.cpu.Registers()
+	if cpu.Decode(d.opcodeAt(regs.PC)).Mnemonic != cpu.Op2nnn {
+		d.run(1, nil)
+		return nil
+	}
+
+	returnAddr, sp := regs.PC+2, regs.SP
+	d.run(-1, func() bool {
+		regs := d.cpu.Registers()
+		return regs.PC == returnAddr && regs.SP == sp
+	})
+	return nil
+}
+
+func cmdContinue(d *Debugger, _ []string) error {
+	d.run(-1, nil)
+	return nil
+}
+
+func cmdReverseStep(d *Debugger, args []string) error {
+	if d.config.Rewind == nil {
+		return errors.New("rewinding is disabled")
+	}
+	count := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return errors.Errorf("invalid count %q", args[0])
+		}
+		count = n
+	}
+	d.reverse(count)
+	return nil
+}
+
+func cmdReverseContinue(d *Debugger, _ []string) error {
+	if d.config.Rewind == nil {
+		return errors.New("rewinding is disabled")
+	}
+	d.reverse(-1)
+	return nil
+}
+
+func cmdBreak(d *Debugger, args []string) error {
+	var bp *breakpoint
+	switch {
+	case len(args) == 1:
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return err
+		}
+		bp = addressBreakpoint(addr)
+	case len(args) == 2 && args[0] == "op":
+		var err error
+		if bp, err = opcodeBreakpoint(args[1]); err != nil {
+			return err
+		}
+	default:
+		return errors.New("usage: break <addr> | break op <pattern>")
+	}
+
+	bp.id = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, bp)
+	d.printf("Breakpoint %d at %s\n", bp.id, bp.desc)
+	return nil
+}
+
+func cmdWatch(d *Debugger, args []string) error {
+	var w *watchpoint
+	switch {
+	case len(args) == 1 && strings.EqualFold(args[0], "I"):
+		w = indexWatchpoint()
+	case len(args) == 1 && len(args[0]) == 2 && strings.ToUpper(args[0])[0] == 'V':
+		x, err := strconv.ParseUint(args[0][1:], 16, 4)
+		if err != nil {
+			return errors.Errorf("invalid register %q", args[0])
+		}
+		w = registerWatchpoint(byte(x))
+	case (len(args) == 2 || len(args) == 3) && args[0] == "mem":
+		addr, err := parseAddress(args[1])
+		if err != nil {
+			return err
+		}
+		n := 1
+		if len(args) == 3 {
+			if n, err = parseCount(args[2]); err != nil {
+				return err
+			}
+		}
+		w = memoryWatchpoint(addr, n)
+	default:
+		return errors.New("usage: watch V<x> | watch I | watch mem <addr> [len]")
+	}
+
+	w.id = d.nextID
+	d.nextID++
+	d.watchpoints = append(d.watchpoints, w)
+	d.printf("Watchpoint %d on %s\n", w.id, w.desc)
+	return nil
+}
+
+func cmdDelete(d *Debugger, args []string) error {
+	if len(args) == 0 {
+		d.breakpoints, d.watchpoints = nil, nil
+		d.printf("Deleted all breakpoints and watchpoints\n")
+		return nil
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.Errorf("invalid id %q", args[0])
+	}
+	for i, bp := range d.breakpoints {
+		if bp.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			d.printf("Deleted breakpoint %d\n", id)
+			return nil
+		}
+	}
+	for i, w := range d.watchpoints {
+		if w.id == id {
+			d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+			d.printf("Deleted watchpoint %d\n", id)
+			return nil
+		}
+	}
+	return errors.Errorf("no breakpoint or watchpoint %d", id)
+}
+
+func cmdInfo(d *Debugger, _ []string) error {
+	if len(d.breakpoints) == 0 && len(d.watchpoints) == 0 {
+		d.printf("No breakpoints or watchpoints\n")
+		return nil
+	}
+	for _, bp := range d.breakpoints {
+		d.printf("%-3d breakpoint  %s\n", bp.id, bp.desc)
+	}
+	for _, w := range d.watchpoints {
+		d.printf("%-3d watchpoint  %s\n", w.id, w.desc)
+	}
+	return nil
+}
+
+func cmdRegs(d *Debugger, _ []string) error {
+	regs := d.cpu.Registers()
+	for x, v := range regs.V {
+		sep := " "
+		if x%8 == 7 {
+			sep = "\n"
+		}
+		d.printf("V%X=%02x%s", x, v, sep)
+	}
+	d.printf("I=%04x PC=%04x SP=%d\n", regs.I, regs.PC, regs.SP)
+	return cmdTimers(d, nil)
+}
+
+func cmdStack(d *Debugger, _ []string) error {
+	regs := d.cpu.Registers()
+	if len(regs.Stack) == 0 {
+		d.printf("Stack is empty\n")
+		return nil
+	}
+	for i := len(regs.Stack) - 1; i >= 0; i-- {
+		d.printf("#%-2d $%03x\n", i, regs.Stack[i])
+	}
+	return nil
+}
+
+func cmdTimers(d *Debugger, _ []string) error {
+	regs := d.cpu.Registers()
+	d.printf("DELAY=%02x SOUND=%02x\n", regs.Delay, regs.Sound)
+	return nil
+}
+
+func cmdList(d *Debugger, args []string) error {
+	pc := d.cpu.Registers().PC
+	addr := pc
+	if len(args) > 0 {
+		var err error
+		if addr, err = parseAddress(args[0]); err != nil {
+			return err
+		}
+	}
+
+	start := int(addr) - 2*listContext
+	for start < 0 {
+		start += 2
+	}
+	for a := start; a <= int(addr)+2*listContext; a += 2 {
+		d.printInstruction(uint16(a), uint16(a) == pc)
+	}
+	return nil
+}
+
+func cmdMem(d *Debugger, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: mem <addr> [len]")
+	}
+	addr, err := parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	n := 16
+	if len(args) > 1 {
+		if n, err = parseCount(args[1]); err != nil {
+			return err
+		}
+	}
+
+	mem := d.cpu.ReadMemory(addr, n)
+	for i := 0; i < len(mem); i += 8 {
+		end := i + 8
+		if end > len(mem) {
+			end = len(mem)
+		}
+		d.printf("%04x % x\n", int(addr)+i, mem[i:end])
+	}
+	return nil
+}
+
+func cmdKey(d *Debugger, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("usage: key <key> [up|down]")
+	}
+	key, err := strconv.ParseUint(args[0], 16, 4)
+	if err != nil {
+		return errors.Errorf("invalid key %q, must be 0-F", args[0])
+	}
+
+	pressed := true
+	if len(args) == 2 {
+		switch args[1] {
+		case "down":
+		case "up":
+			pressed = false
+		default:
+			return errors.Errorf("invalid key state %q, must be up or down", args[1])
+		}
+	}
+	d.cpu.SetKey(byte(key), pressed)
+	d.keysChanged = true
+
+	state := "down"
+	if !pressed {
+		state = "up"
+	}
+	d.printf("Key %X %s\n", key, state)
+	return nil
+}
+
+func cmdHelp(d *Debugger, _ []string) error {
+	for _, cmd := range commands {
+		d.printf("%-50s %s\n", fmt.Sprintf("%s (%s)", cmd.usage, strings.Join(cmd.aliases, ", ")), cmd.help)
+	}
+	return nil
+}
+
+// parseAddress parses a hex address, optionally prefixed with 0x or $.
+func parseAddress(s string) (uint16, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "0x"), "$")
+	addr, err := strconv.ParseUint(trimmed, 16, 16)
+	if err != nil {
+		return 0, errors.Errorf("invalid address %q", s)
+	}
+	return uint16(addr), nil
+}
+
+// parseCount parses a positive decimal count.
+func parseCount(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, errors.Errorf("invalid length %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,364 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rewind"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	prompt = "(chip8) "
+
+	// DefaultCyclesPerTick is the default number of instructions executed
+	// between each tick of the delay and sound timers.
+	DefaultCyclesPerTick = 10
+
+	// listContext is the number of instructions listed either side of pc.
+	listContext = 5
+)
+
+// Config holds the settings for a debugging session.
+type Config struct {
+	// CyclesPerTick is the number of instructions executed between each
+	// tick of the delay and sound timers.
+	CyclesPerTick int
+	// Rewind records a checkpoint of the state of the machine every
+	// CyclesPerTick instructions, and whenever a key is pressed or released,
+	// so the reverse-step and reverse-continue commands can step back
+	// through it by replaying forward from the checkpoint before. They are
+	// unavailable when it is nil.
+	Rewind *rewind.Buffer
+}
+
+// checkpoint is what the debugger needs to replay forward from a state it
+// pushed to the rewind buffer.
+type checkpoint struct {
+	// cycles is the value of Debugger.cycles when the state was recorded.
+	cycles int
+	// executed is the number of instructions executed since.
+	executed int
+	// randoms are the values stored by the Cxkk instructions executed since,
+	// in order, so replaying them stores the same values.
+	randoms []byte
+}
+
+// Debugger is an interactive step debugger for a CPU.
+type Debugger struct {
+	cpu    *cpu.CPU
+	out    io.Writer
+	config Config
+
+	// cycles counts the instructions executed since the timers last ticked.
+	cycles int
+	// checkpoints describes each state in the rewind buffer, oldest first.
+	checkpoints []checkpoint
+	// keysChanged is set when a key is pressed or released, which replaying
+	// from the last checkpoint wouldn't do, so a new one is needed.
+	keysChanged bool
+
+	breakpoints []*breakpoint
+	watchpoints []*watchpoint
+	nextID      int
+
+	lastLine string
+}
+
+// New constructs a Debugger for the CPU that writes its output to out.
+func New(c *cpu.CPU, out io.Writer, config Config) *Debugger {
+	if config.CyclesPerTick <= 0 {
+		config.CyclesPerTick = DefaultCyclesPerTick
+	}
+	return &Debugger{
+		cpu:    c,
+		out:    out,
+		config: config,
+		nextID: 1,
+	}
+}
+
+// Run reads commands from in until it is exhausted or the quit command is
+// given.
+func (d *Debugger) Run(in io.Reader) error {
+	d.printf("Type 'help' for a list of commands.\n")
+	d.printLocation()
+
+	scanner := bufio.NewScanner(in)
+	for {
+		d.printf(prompt)
+		if !scanner.Scan() {
+			d.printf("\n")
+			return errors.WithStack(scanner.Err())
+		}
+		if quit := d.Exec(scanner.Text()); quit {
+			return nil
+		}
+	}
+}
+
+// Exec executes a single command line and reports whether the session should
+// end. An empty line repeats the previous command.
+func (d *Debugger) Exec(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		line = d.lastLine
+	}
+	d.lastLine = line
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	cmd, ok := lookupCommand(fields[0])
+	if !ok {
+		d.printf("unknown command %q, type 'help' for a list of commands\n", fields[0])
+		return false
+	}
+	if cmd.name == "quit" {
+		return true
+	}
+	if err := cmd.run(d, fields[1:]); err != nil {
+		d.printf("error: %v\n", err)
+	}
+	return false
+}
+
+// run executes up to limit instructions, or without limit if limit is
+// negative, stopping early at breakpoints, watchpoints, faults, program
+// exit, or when until returns true. A breakpoint on the first instruction is ignored so
+// execution can continue from it.
+func (d *Debugger) run(limit int, until func() bool) {
+	defer d.printLocation()
+
+	for i := 0; limit < 0 || i < limit; i++ {
+		regs := d.cpu.Registers()
+		op := d.opcodeAt(regs.PC)
+
+		if i > 0 {
+			if bp := d.breakpointAt(regs.PC, op); bp != nil {
+				d.printf("Breakpoint %d, %s\n", bp.id, bp.desc)
+				return
+			}
+		}
+		if limit < 0 && d.stalled(regs.PC, op) {
+			return
+		}
+
+		for _, w := range d.watchpoints {
+			w.arm(d.cpu)
+		}
+		if err := d.cycle(); err != nil {
+			d.printf("Program halted: %v\n", err)
+			return
+		}
+		if d.cpu.Exited() {
+			d.printf("Program exited\n")
+			return
+		}
+		for _, w := range d.watchpoints {
+			if change, ok := w.changed(d.cpu); ok {
+				d.printf("Watchpoint %d, %s\n", w.id, change)
+				return
+			}
+		}
+
+		if until != nil && until() {
+			return
+		}
+	}
+}
+
+// reverse steps back up to limit instructions, or without limit if limit is
+// negative, stopping early at breakpoints, watchpoints, or when the recorded
+// history runs out.
+func (d *Debugger) reverse(limit int) {
+	defer d.printLocation()
+
+	for i := 0; limit < 0 || i < limit; i++ {
+		for _, w := range d.watchpoints {
+			w.arm(d.cpu)
+		}
+		if len(d.checkpoints) == 0 {
+			d.printf("No more history\n")
+			return
+		}
+		if err := d.stepBack(); err != nil {
+			d.printf("Failed to rewind: %v\n", err)
+			return
+		}
+
+		for _, w := range d.watchpoints {
+			if change, ok := w.changed(d.cpu); ok {
+				d.printf("Watchpoint %d, %s\n", w.id, change)
+				return
+			}
+		}
+		regs := d.cpu.Registers()
+		if bp := d.breakpointAt(regs.PC, d.opcodeAt(regs.PC)); bp != nil {
+			d.printf("Breakpoint %d, %s\n", bp.id, bp.desc)
+			return
+		}
+	}
+}
+
+// stepBack restores the state before the last instruction executed, by
+// restoring the last checkpoint and replaying the instructions after it but
+// one.
+func (d *Debugger) stepBack() error {
+	cp := &d.checkpoints[len(d.checkpoints)-1]
+	snapshot, _ := d.config.Rewind.Pop()
+	if err := d.cpu.Restore(snapshot); err != nil {
+		return err
+	}
+	d.cycles = cp.cycles
+
+	replay := cp.executed - 1
+	if replay == 0 {
+		// back at the checkpoint, which is taken again when stepping forward
+		d.checkpoints = d.checkpoints[:len(d.checkpoints)-1]
+		return nil
+	}
+	d.config.Rewind.Push(snapshot)
+
+	randoms := cp.randoms
+	for i := 0; i < replay; i++ {
+		in := cpu.Decode(d.opcodeAt(d.cpu.Registers().PC))
+		if err := d.step(); err != nil {
+			return errors.Wrap(err, "failed to replay")
+		}
+		if in.Mnemonic == cpu.OpCxkk {
+			d.cpu.V[in.X], randoms = randoms[0], randoms[1:]
+		}
+	}
+	cp.executed = replay
+	cp.randoms = cp.randoms[:len(cp.randoms)-len(randoms)]
+	return nil
+}
+
+// cycle executes one instruction, ticking the timers as needed, and records
+// what is needed to step back over it.
+func (d *Debugger) cycle() error {
+	if d.config.Rewind == nil {
+		return d.step()
+	}
+
+	d.checkpoint()
+	cp := &d.checkpoints[len(d.checkpoints)-1]
+	in := cpu.Decode(d.opcodeAt(d.cpu.Registers().PC))
+	if err := d.step(); err != nil {
+		if cp.executed == 0 {
+			// the instruction didn't execute, so the checkpoint isn't needed
+			d.config.Rewind.Pop()
+			d.checkpoints = d.checkpoints[:len(d.checkpoints)-1]
+		}
+		return err
+	}
+	cp.executed++
+	if in.Mnemonic == cpu.OpCxkk {
+		cp.randoms = append(cp.randoms, d.cpu.V[in.X])
+	}
+	return nil
+}
+
+// checkpoint pushes the state of the machine to the rewind buffer if the last
+// checkpoint is CyclesPerTick instructions old, or the keys have changed
+// since it, dropping the descriptions of checkpoints the buffer has dropped.
+func (d *Debugger) checkpoint() {
+	if n := len(d.checkpoints); n > 0 && !d.keysChanged && d.checkpoints[n-1].executed < d.config.CyclesPerTick {
+		return
+	}
+	d.config.Rewind.Push(d.cpu.Snapshot())
+	d.checkpoints = append(d.checkpoints, checkpoint{cycles: d.cycles})
+	d.keysChanged = false
+	if dropped := len(d.checkpoints) - d.config.Rewind.Len(); dropped > 0 {
+		d.checkpoints = append(d.checkpoints[:0], d.checkpoints[dropped:]...)
+	}
+}
+
+// step executes one instruction, ticking the timers as needed.
+func (d *Debugger) step() error {
+	if err := d.cpu.Cycle(); err != nil {
+		return err
+	}
+	d.cycles++
+	if d.cycles >= d.config.CyclesPerTick {
+		d.cycles = 0
+		d.cpu.TickTimers()
+	}
+	return nil
+}
+
+// stalled reports whether running on would never reach a stopping point,
+// because the program is jumping to itself or is waiting for a key press
+// and no key is held.
+func (d *Debugger) stalled(pc uint16, op cpu.Opcode) bool {
+	in := cpu.Decode(op)
+	if in.Mnemonic == cpu.Op1nnn && in.NNN == pc {
+		d.printf("Program is spinning at $%03x\n", pc)
+		return true
+	}
+	if in.Mnemonic == cpu.OpFx0A && !d.cpu.AnyKeyPressed() {
+		d.printf("Program is waiting for a key press, use 'key' to press one\n")
+		return true
+	}
+	return false
+}
+
+func (d *Debugger) breakpointAt(pc uint16, op cpu.Opcode) *breakpoint {
+	for _, bp := range d.breakpoints {
+		if bp.match(pc, op) {
+			return bp
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) opcodeAt(addr uint16) cpu.Opcode {
+	b := d.cpu.ReadMemory(addr, 2)
+	if len(b) < 2 {
+		return 0
+	}
+	return cpu.OpcodeFromBytes(b)
+}
+
+// printLocation prints the instruction at pc.
+func (d *Debugger) printLocation() {
+	d.printInstruction(d.cpu.Registers().PC, true)
+}
+
+// printInstruction prints the instruction at addr in the same format as the
+// disassembler, marking it with an arrow if it is the current instruction.
+func (d *Debugger) printInstruction(addr uint16, current bool) {
+	b := d.cpu.ReadMemory(addr, 2)
+	if len(b) < 2 {
+		return
+	}
+	marker := "  "
+	if current {
+		marker = "=>"
+	}
+	op := cpu.OpcodeFromBytes(b)
+	if op.Size() == 4 && !d.cpu.XOCHIP() {
+		// outside XO-CHIP mode the CPU runs it as a 2 byte unknown opcode
+		instruction := cpu.Classic.Format(cpu.DecodedInstruction{Opcode: op}, nil)
+		d.printf("%s %04x %02x %02x %s\n", marker, addr, b[0], b[1], strings.TrimRight(instruction, " "))
+		return
+	}
+	if long := d.cpu.ReadMemory(addr, op.Size()); len(long) == 4 {
+		operand := uint16(long[2])<<8 | uint16(long[3])
+		d.printf("%s %04x %02x %02x %02x %02x %s\n", marker, addr, long[0], long[1], long[2], long[3],
+			strings.TrimRight(op.LongInstruction(operand), " "))
+		return
+	}
+	d.printf("%s %04x %02x %02x %s\n", marker, addr, b[0], b[1], strings.TrimRight(op.Instruction(), " "))
+}
+
+func (d *Debugger) printf(format string, a ...interface{}) {
+	_, _ = fmt.Fprintf(d.out, format, a...)
+}
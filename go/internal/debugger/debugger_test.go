@@ -0,0 +1,214 @@
+package debugger_test
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/debugger"
+	"chip-8/internal/rewind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testProgram = []byte{
+	0x60, 0x01, // 0x200: MVI        V0,#$01
+	0x22, 0x0a, // 0x202: CALL       $20a
+	0xa3, 0x00, // 0x204: MVI        I,#$300
+	0xf1, 0x55, // 0x206: MOVM       (I),V0-V1
+	0x12, 0x08, // 0x208: JUMP       $208
+	0x61, 0x02, // 0x20a: MVI        V1,#$02
+	0x70, 0x01, // 0x20c: ADI        V0,#$01
+	0x00, 0xee, // 0x20e: RTS
+}
+
+func newTestDebugger(t *testing.T, program []byte) (*debugger.Debugger, *cpu.CPU, *bytes.Buffer) {
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram(program))
+	out := bytes.NewBuffer(nil)
+	return debugger.New(c, out, debugger.Config{}), c, out
+}
+
+// exec runs a command and returns its output.
+func exec(d *debugger.Debugger, out *bytes.Buffer, line string) string {
+	out.Reset()
+	d.Exec(line)
+	return out.String()
+}
+
+func TestDebugger_Step(t *testing.T) {
+	d, c, out := newTestDebugger(t, testProgram)
+
+	assert.Equal(t, "=> 0202 22 0a CALL       $20a\n", exec(d, out, "step"))
+	assert.Equal(t, byte(1), c.V[0])
+
+	assert.Equal(t, "=> 020c 70 01 ADI        V0,#$01\n", exec(d, out, "s 2"))
+	assert.Equal(t, "=> 0204 a3 00 MVI        I,#$300\n", exec(d, out, ""), "empty line should repeat the last command")
+}
+
+func TestDebugger_Next(t *testing.T) {
+	d, c, out := newTestDebugger(t, testProgram)
+
+	exec(d, out, "step")
+	assert.Equal(t, "=> 0204 a3 00 MVI        I,#$300\n", exec(d, out, "next"))
+	assert.Equal(t, byte(2), c.V[0])
+	assert.Equal(t, byte(2), c.V[1])
+}
+
+func TestDebugger_Breakpoints(t *testing.T) {
+	d, _, out := newTestDebugger(t, testProgram)
+
+	assert.Equal(t, "Breakpoint 1 at address $20c\n", exec(d, out, "break 20c"))
+	assert.Equal(t, "Breakpoint 2 at opcode F*55\n", exec(d, out, "b op f*55"))
+
+	assert.Equal(t, "Breakpoint 1, address $20c\n=> 020c 70 01 ADI        V0,#$01\n", exec(d, out, "continue"))
+	assert.Equal(t, "Breakpoint 2, opcode F*55\n=> 0206 f1 55 MOVM       (I),V0-V1\n", exec(d, out, "c"))
+	assert.Equal(t, "Program is spinning at $208\n=> 0208 12 08 JUMP       $208\n", exec(d, out, "c"))
+
+	assert.Equal(t, "1   breakpoint  address $20c\n2   breakpoint  opcode F*55\n", exec(d, out, "info"))
+	assert.Equal(t, "Deleted breakpoint 1\n", exec(d, out, "delete 1"))
+	assert.Equal(t, "2   breakpoint  opcode F*55\n", exec(d, out, "info"))
+
+	assert.Equal(t, "error: opcode pattern \"8xz4\" has invalid character 'z'\n", exec(d, out, "b op 8xz4"))
+}
+
+func TestDebugger_Watchpoints(t *testing.T) {
+	d, _, out := newTestDebugger(t, testProgram)
+
+	assert.Equal(t, "Watchpoint 1 on V1\n", exec(d, out, "watch v1"))
+	assert.Equal(t, "Watchpoint 2 on memory $301-$301\n", exec(d, out, "watch mem 301"))
+	assert.Equal(t, "Watchpoint 3 on I\n", exec(d, out, "watch I"))
+
+	assert.Equal(t, "Watchpoint 1, V1 changed from 00 to 02\n=> 020c 70 01 ADI        V0,#$01\n", exec(d, out, "c"))
+	assert.Equal(t, "Watchpoint 3, I changed from 0000 to 0300\n=> 0206 f1 55 MOVM       (I),V0-V1\n", exec(d, out, "c"))
+	assert.Equal(t, "Watchpoint 2, memory $301-$301 changed from 00 to 02\n=> 0208 12 08 JUMP       $208\n", exec(d, out, "c"))
+}
+
+func TestDebugger_Inspect(t *testing.T) {
+	d, _, out := newTestDebugger(t, testProgram)
+	exec(d, out, "s 3")
+
+	assert.Equal(t, "V0=01 V1=02 V2=00 V3=00 V4=00 V5=00 V6=00 V7=00\n"+
+		"V8=00 V9=00 VA=00 VB=00 VC=00 VD=00 VE=00 VF=00\n"+
+		"I=0000 PC=020c SP=1\n"+
+		"DELAY=00 SOUND=00\n", exec(d, out, "regs"))
+	assert.Equal(t, "#0  $204\n", exec(d, out, "stack"))
+	assert.Equal(t, "0200 60 01 22 0a a3 00 f1 55\n0208 12 08\n", exec(d, out, "mem 200 10"))
+
+	listing := exec(d, out, "list")
+	assert.Contains(t, listing, "   0202 22 0a CALL       $20a\n")
+	assert.Contains(t, listing, "=> 020c 70 01 ADI        V0,#$01\n")
+}
+
+func TestDebugger_Fault(t *testing.T) {
+	d, _, out := newTestDebugger(t, []byte{0x00, 0xee})
+
+	assert.Equal(t, "Program halted: execution fault at $200 (00ee RTS       ): stack underflow\n"+
+		"=> 0200 00 ee RTS\n", exec(d, out, "step"))
+}
+
+func TestDebugger_WaitKey(t *testing.T) {
+	d, c, out := newTestDebugger(t, []byte{0xf3, 0x0a, 0x12, 0x02})
+
+	assert.Equal(t, "Program is waiting for a key press, use 'key' to press one\n=> 0200 f3 0a WAITKEY    V3\n", exec(d, out, "c"))
+	assert.Equal(t, "Key B down\n", exec(d, out, "key b"))
+	exec(d, out, "c")
+	assert.Equal(t, byte(0xb), c.V[3])
+}
+
+func TestDebugger_Exit(t *testing.T) {
+	d, c, out := newTestDebugger(t, []byte{0x60, 0x01, 0x00, 0xfd})
+
+	assert.Equal(t, "Program exited\n=> 0202 00 fd EXIT\n", exec(d, out, "c"))
+	assert.True(t, c.Exited())
+}
+
+func TestDebugger_Reverse(t *testing.T) {
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram(testProgram))
+	out := bytes.NewBuffer(nil)
+	d := debugger.New(c, out, debugger.Config{Rewind: rewind.New(rewind.DefaultBudget)})
+
+	exec(d, out, "s 5")
+	assert.Equal(t, "=> 020c 70 01 ADI        V0,#$01\n", exec(d, out, "reverse-step 2"))
+	assert.Equal(t, byte(1), c.V[0])
+	assert.Equal(t, uint16(1), c.Registers().SP)
+
+	exec(d, out, "break 202")
+	assert.Equal(t, "Breakpoint 1, address $202\n=> 0202 22 0a CALL       $20a\n", exec(d, out, "reverse-continue"))
+	assert.Equal(t, "No more history\n=> 0200 60 01 MVI        V0,#$01\n", exec(d, out, "rc"))
+	assert.Equal(t, byte(0), c.V[0])
+
+	exec(d, out, "delete")
+	exec(d, out, "c")
+	assert.Equal(t, byte(2), c.V[0], "execution should continue normally after rewinding")
+}
+
+func TestDebugger_ReverseReplaysFromCheckpoints(t *testing.T) {
+	c := cpu.NewCPU()
+	c.SetRandom(rand.New(rand.NewSource(1)))
+	require.NoError(t, c.LoadProgram([]byte{
+		0xc0, 0xff, // 0x200: RND        V0,#$ff
+		0x71, 0x01, // 0x202: ADI        V1,#$01
+		0x12, 0x00, // 0x204: JUMP       $200
+	}))
+	out := bytes.NewBuffer(nil)
+	buffer := rewind.New(rewind.DefaultBudget)
+	d := debugger.New(c, out, debugger.Config{CyclesPerTick: 4, Rewind: buffer})
+
+	var history []cpu.Registers
+	for i := 0; i < 9; i++ {
+		history = append(history, c.Registers())
+		exec(d, out, "step")
+	}
+	assert.Equal(t, 3, buffer.Len(), "a checkpoint should be kept every 4 instructions")
+
+	exec(d, out, "key 5")
+	history = append(history, c.Registers())
+	exec(d, out, "step")
+	assert.Equal(t, 4, buffer.Len(), "pressing a key should take a checkpoint")
+
+	for i := len(history) - 1; i >= 0; i-- {
+		exec(d, out, "reverse-step")
+		assert.Equal(t, history[i], c.Registers(), "after stepping back to instruction %d", i)
+	}
+	assert.Equal(t, "No more history\n=> 0200 c0 ff RND        V0,#$ff\n", exec(d, out, "rs"))
+}
+
+func TestDebugger_ReverseDisabled(t *testing.T) {
+	d, _, out := newTestDebugger(t, testProgram)
+
+	exec(d, out, "step")
+	assert.Equal(t, "error: rewinding is disabled\n", exec(d, out, "reverse-step"))
+}
+
+func TestDebugger_Run(t *testing.T) {
+	d, _, out := newTestDebugger(t, testProgram)
+
+	err := d.Run(strings.NewReader("step\nbogus\nquit\nstep\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Type 'help' for a list of commands.\n"+
+		"=> 0200 60 01 MVI        V0,#$01\n"+
+		"(chip8) => 0202 22 0a CALL       $20a\n"+
+		"(chip8) unknown command \"bogus\", type 'help' for a list of commands\n"+
+		"(chip8) ", out.String())
+}
+
+func TestDebugger_LongInstruction(t *testing.T) {
+	d, c, out := newTestDebugger(t, []byte{0x60, 0x01, 0xf0, 0x00, 0x12, 0x34, 0x12, 0x06})
+	c.SetXOCHIP(true)
+
+	assert.Equal(t, "=> 0202 f0 00 12 34 MVIL       I,#$1234\n", exec(d, out, "step"))
+	assert.Equal(t, "=> 0206 12 06 JUMP       $206\n", exec(d, out, "step"))
+	assert.Equal(t, uint16(0x1234), c.Registers().I)
+}
+
+func TestDebugger_LongInstructionOutsideXOCHIP(t *testing.T) {
+	d, _, out := newTestDebugger(t, []byte{0x60, 0x01, 0xf0, 0x00, 0x12, 0x34})
+
+	assert.Equal(t, "=> 0202 f0 00 UNK        0xf000\n", exec(d, out, "step"))
+}
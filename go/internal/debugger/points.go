@@ -0,0 +1,115 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+// breakpoint stops execution before an instruction matching it is executed.
+type breakpoint struct {
+	id    int
+	desc  string
+	match func(pc uint16, op cpu.Opcode) bool
+}
+
+// watchpoint stops execution after an instruction changes the value it watches.
+type watchpoint struct {
+	id   int
+	desc string
+	read func(c *cpu.CPU) []byte
+	last []byte
+}
+
+// addressBreakpoint breaks when pc reaches addr.
+func addressBreakpoint(addr uint16) *breakpoint {
+	return &breakpoint{
+		desc: fmt.Sprintf("address $%03x", addr),
+		match: func(pc uint16, _ cpu.Opcode) bool {
+			return pc == addr
+		},
+	}
+}
+
+// opcodeBreakpoint breaks when the next opcode matches the pattern, which is
+// four characters that are each either a hex digit that must match, or one of
+// x, y, n, k, or * that matches any digit. For example 8xy4 matches every ADD.
+// and D**F matches every 15 byte sprite draw.
+func opcodeBreakpoint(pattern string) (*breakpoint, error) {
+	if len(pattern) != 4 {
+		return nil, errors.Errorf("opcode pattern %q must be 4 characters", pattern)
+	}
+
+	var mask, value uint16
+	for _, r := range strings.ToLower(pattern) {
+		mask <<= 4
+		value <<= 4
+		switch {
+		case r >= '0' && r <= '9':
+			mask |= 0xf
+			value |= uint16(r - '0')
+		case r >= 'a' && r <= 'f':
+			mask |= 0xf
+			value |= uint16(r-'a') + 0xa
+		case strings.ContainsRune("xynk*", r):
+		default:
+			return nil, errors.Errorf("opcode pattern %q has invalid character %q", pattern, r)
+		}
+	}
+
+	return &breakpoint{
+		desc: fmt.Sprintf("opcode %s", strings.ToUpper(pattern)),
+		match: func(_ uint16, op cpu.Opcode) bool {
+			return uint16(op)&mask == value
+		},
+	}, nil
+}
+
+// registerWatchpoint watches one of the V registers.
+func registerWatchpoint(x byte) *watchpoint {
+	return &watchpoint{
+		desc: fmt.Sprintf("V%X", x),
+		read: func(c *cpu.CPU) []byte {
+			return []byte{c.V[x]}
+		},
+	}
+}
+
+// indexWatchpoint watches the I register.
+func indexWatchpoint() *watchpoint {
+	return &watchpoint{
+		desc: "I",
+		read: func(c *cpu.CPU) []byte {
+			return []byte{byte(c.I >> 8), byte(c.I)}
+		},
+	}
+}
+
+// memoryWatchpoint watches n bytes of memory starting at addr.
+func memoryWatchpoint(addr uint16, n int) *watchpoint {
+	return &watchpoint{
+		desc: fmt.Sprintf("memory $%03x-$%03x", addr, int(addr)+n-1),
+		read: func(c *cpu.CPU) []byte {
+			return c.ReadMemory(addr, n)
+		},
+	}
+}
+
+// arm records the current value of the watched location.
+func (w *watchpoint) arm(c *cpu.CPU) {
+	w.last = w.read(c)
+}
+
+// changed reports whether the watched location differs from when it was
+// armed, and if so describes the change.
+func (w *watchpoint) changed(c *cpu.CPU) (string, bool) {
+	current := w.read(c)
+	if bytes.Equal(current, w.last) {
+		return "", false
+	}
+	return fmt.Sprintf("%s changed from %x to %x", w.desc, w.last, current), true
+}
@@ -0,0 +1,162 @@
+// Package movie records the input of a run so it can be replayed exactly.
+//
+// A run is deterministic given its ROM, CPU settings, random seed, and the
+// state of the keypad during each frame, so a movie holds just those, along
+// with the hash of the final screen so a replay can be checked against the
+// original run.
+package movie
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+// A movie is laid out as:
+//
+//	magic    4 bytes  "CH8M"
+//	version  2 bytes
+//	header   the fixed size movieHeader
+//	profile  1 byte length followed by the name of the quirk profile
+//	font     1 byte length followed by the name of the font
+//	keys     2 bytes for the keypad state of each frame
+//
+// Multi-byte values are big endian.
+const (
+	movieMagic   = "CH8M"
+	movieVersion = 1
+)
+
+var (
+	// ErrInvalidMovie is returned when reading data that isn't a movie.
+	ErrInvalidMovie = errors.New("invalid movie")
+	// ErrMovieVersion is returned when reading a movie written by an
+	// incompatible version.
+	ErrMovieVersion = errors.New("unsupported movie version")
+)
+
+type movieHeader struct {
+	Magic   [4]byte
+	Version uint16
+
+	Seed       int64
+	Speed      uint32
+	ROM        [sha1.Size]byte
+	ScreenHash [sha1.Size]byte
+	Frames     uint32
+}
+
+// Movie is the recorded input of a run.
+type Movie struct {
+	// Seed seeds the random number generator.
+	Seed int64
+	// Speed is the number of instructions executed per second.
+	Speed int
+	// Profile and Font are the names of the quirk profile and font set the
+	// CPU was configured with.
+	Profile string
+	Font    string
+	// ROM is the SHA-1 hash of the program that was run.
+	ROM [sha1.Size]byte
+	// Keys holds the keypad state at the start of each frame, as returned
+	// by cpu.CPU.Keys.
+	Keys []uint16
+	// ScreenHash is the cpu.CPU.ScreenHash of the display after the last
+	// frame.
+	ScreenHash [sha1.Size]byte
+}
+
+// RecordKeys appends the keypad state of a frame.
+func (m *Movie) RecordKeys(keys uint16) {
+	m.Keys = append(m.Keys, keys)
+}
+
+// Replay sets the keypad of the CPU to the recorded state of each frame in
+// turn and calls frame to advance it. The CPU must be configured as it was
+// for the recording and have the same program loaded.
+func (m *Movie) Replay(c *cpu.CPU, frame func() error) error {
+	for _, keys := range m.Keys {
+		c.SetKeys(keys)
+		if err := frame(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTo writes the movie to w.
+func (m *Movie) WriteTo(w io.Writer) (int64, error) {
+	if len(m.Profile) > 0xff || len(m.Font) > 0xff {
+		return 0, errors.New("profile and font names must be shorter than 256 bytes")
+	}
+	header := movieHeader{
+		Version:    movieVersion,
+		Seed:       m.Seed,
+		Speed:      uint32(m.Speed),
+		ROM:        m.ROM,
+		ScreenHash: m.ScreenHash,
+		Frames:     uint32(len(m.Keys)),
+	}
+	copy(header.Magic[:], movieMagic)
+
+	buf := bytes.NewBuffer(nil)
+	// writes to a bytes.Buffer of fixed size values can't fail
+	_ = binary.Write(buf, binary.BigEndian, header)
+	for _, s := range []string{m.Profile, m.Font} {
+		buf.WriteByte(byte(len(s)))
+		buf.WriteString(s)
+	}
+	_ = binary.Write(buf, binary.BigEndian, m.Keys)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), errors.Wrap(err, "failed to write movie")
+}
+
+// Read reads a movie written by WriteTo. If the data isn't a movie the error
+// is ErrInvalidMovie or ErrMovieVersion.
+func Read(r io.Reader) (*Movie, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read movie")
+	}
+
+	var header movieHeader
+	br := bytes.NewReader(data)
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil || string(header.Magic[:]) != movieMagic {
+		return nil, ErrInvalidMovie
+	}
+	if header.Version != movieVersion {
+		return nil, errors.Wrapf(ErrMovieVersion, "version %d", header.Version)
+	}
+
+	m := &Movie{
+		Seed:       header.Seed,
+		Speed:      int(header.Speed),
+		ROM:        header.ROM,
+		ScreenHash: header.ScreenHash,
+	}
+	for _, s := range []*string{&m.Profile, &m.Font} {
+		n, err := br.ReadByte()
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidMovie, "truncated header")
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, errors.Wrap(ErrInvalidMovie, "truncated header")
+		}
+		*s = string(name)
+	}
+
+	if br.Len() != 2*int(header.Frames) {
+		return nil, errors.Wrapf(ErrInvalidMovie, "%d bytes of keys, expected %d", br.Len(), 2*header.Frames)
+	}
+	m.Keys = make([]uint16, header.Frames)
+	_ = binary.Read(br, binary.BigEndian, m.Keys)
+	return m, nil
+}
@@ -0,0 +1,111 @@
+package movie_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/movie"
+	"chip-8/internal/runner"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProgram draws a random pixel every loop while key 0 is held.
+var testProgram = []byte{
+	0xa2, 0x0c, // MVI        I,#$20c
+	0xc0, 0x3f, // RND        V0,#$3f
+	0xc1, 0x1f, // RND        V1,#$1f
+	0xe2, 0xa1, // SKIP.NOKEY V2
+	0xd0, 0x11, // SPRITE.    V0,V1,#$1
+	0x12, 0x02, // JUMP       $202
+	0x80, //       sprite data
+}
+
+type nullDisplay struct{}
+
+func (nullDisplay) Draw([]byte, int, int) error { return nil }
+func (nullDisplay) Beep() error                 { return nil }
+func (nullDisplay) Keys() <-chan byte           { return nil }
+
+func newTestCPU(t *testing.T, seed int64) *cpu.CPU {
+	c := cpu.NewCPU()
+	c.SetRandom(rand.New(rand.NewSource(seed)))
+	require.NoError(t, c.LoadProgram(testProgram))
+	return c
+}
+
+// record runs the test program for 60 frames, holding key 0 for some of
+// them, and returns the movie.
+func record(t *testing.T, seed int64) *movie.Movie {
+	m := &movie.Movie{Seed: seed, Speed: 600}
+	c := newTestCPU(t, seed)
+	r := runner.New(c, nullDisplay{}, runner.Config{Speed: m.Speed, Recorder: m})
+	for i := 0; i < 60; i++ {
+		c.SetKey(0, i >= 10 && i < 40)
+		require.NoError(t, r.Frame())
+	}
+	m.ScreenHash = c.ScreenHash()
+	return m
+}
+
+// replay replays the movie and returns the resulting screen hash.
+func replay(t *testing.T, m *movie.Movie, seed int64) [20]byte {
+	c := newTestCPU(t, seed)
+	r := runner.New(c, nullDisplay{}, runner.Config{Speed: m.Speed})
+	require.NoError(t, m.Replay(c, r.Frame))
+	return c.ScreenHash()
+}
+
+func TestMovie_Replay(t *testing.T) {
+	m := record(t, 7)
+	require.Len(t, m.Keys, 60)
+	assert.Equal(t, uint16(0), m.Keys[9])
+	assert.Equal(t, uint16(1), m.Keys[10])
+
+	assert.Equal(t, m.ScreenHash, replay(t, m, m.Seed), "replay should end on the same screen")
+	assert.NotEqual(t, m.ScreenHash, replay(t, m, m.Seed+1), "a different seed should end on a different screen")
+}
+
+func TestMovie_WriteRead(t *testing.T) {
+	m := record(t, 7)
+	m.Profile, m.Font = "schip", "cosmac"
+	m.ROM[0] = 0xab
+
+	buf := bytes.NewBuffer(nil)
+	_, err := m.WriteTo(buf)
+	require.NoError(t, err)
+
+	read, err := movie.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, m, read)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	valid := bytes.NewBuffer(nil)
+	_, err := (&movie.Movie{Keys: []uint16{1, 2}}).WriteTo(valid)
+	require.NoError(t, err)
+
+	type testCase struct {
+		label string
+		data  []byte
+		err   error
+	}
+
+	cases := []testCase{
+		{label: "empty", data: nil, err: movie.ErrInvalidMovie},
+		{label: "bad magic", data: append([]byte("XXXX"), valid.Bytes()[4:]...), err: movie.ErrInvalidMovie},
+		{label: "bad version", data: append(append([]byte("CH8M"), 0, 9), valid.Bytes()[6:]...), err: movie.ErrMovieVersion},
+		{label: "truncated keys", data: valid.Bytes()[:valid.Len()-1], err: movie.ErrInvalidMovie},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			_, err := movie.Read(bytes.NewReader(tc.data))
+			assert.Equal(t, tc.err, errors.Cause(err))
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Package rewind keeps a bounded history of machine states so execution can
+// be stepped backwards.
+//
+// Only the most recent state is kept whole. Every older state is kept as a
+// delta holding just the bytes that differ from the state after it, which for
+// consecutive frames or instructions is usually a few registers, the timers,
+// and whatever the program drew or stored, so a long history fits in a small
+// memory budget.
+package rewind
+
+import "bytes"
+
+const (
+	// DefaultBudget is the default number of bytes a Buffer may use.
+	DefaultBudget = 16 << 20
+
+	// runOverhead is the approximate cost in bytes of keeping a run on top
+	// of its data, counted against the budget.
+	runOverhead = 32
+	// chunkSize is the size of the blocks states are compared in, so
+	// unchanged stretches are skipped with a single fast comparison.
+	chunkSize = 64
+	// maxGap is the longest stretch of unchanged bytes kept inside a run
+	// rather than starting a new one, since each run has an overhead.
+	maxGap = runOverhead
+)
+
+// run is a stretch of bytes of a state starting at offset.
+type run struct {
+	offset int
+	data   []byte
+}
+
+// delta turns a state into the one before it.
+type delta struct {
+	// length is the length of the earlier state.
+	length int
+	runs   []run
+	size   int
+}
+
+// Buffer is a bounded stack of states, such as CPU snapshots. When pushing a
+// state would take the buffer over its budget the oldest states are dropped.
+type Buffer struct {
+	budget int
+
+	latest []byte
+	// deltas is a ring of count deltas starting at head, from oldest to
+	// newest. The newest delta turns latest into the state pushed before it.
+	deltas []delta
+	head   int
+	count  int
+	size   int
+}
+
+// New constructs a Buffer that uses up to budget bytes. The most recent state
+// is always kept, even when it alone is larger than the budget.
+func New(budget int) *Buffer {
+	return &Buffer{budget: budget}
+}
+
+// Len returns the number of states in the buffer.
+func (b *Buffer) Len() int {
+	if b.latest == nil {
+		return 0
+	}
+	return b.count + 1
+}
+
+// Size returns the number of bytes the buffer is using.
+func (b *Buffer) Size() int {
+	return b.size + len(b.latest)
+}
+
+// Reset empties the buffer.
+func (b *Buffer) Reset() {
+	*b = Buffer{budget: b.budget}
+}
+
+// Push adds a state to the top of the buffer. The buffer keeps its own copy.
+func (b *Buffer) Push(state []byte) {
+	if b.latest != nil {
+		d := diff(state, b.latest)
+		b.append(d)
+		b.size += d.size
+	}
+	b.latest = append(b.latest[:0:0], state...)
+
+	for b.count > 0 && b.Size() > b.budget {
+		b.size -= b.deltas[b.head].size
+		b.deltas[b.head] = delta{}
+		b.head = (b.head + 1) % len(b.deltas)
+		b.count--
+	}
+}
+
+// Pop removes the state at the top of the buffer and returns it, or returns
+// false if the buffer is empty.
+func (b *Buffer) Pop() ([]byte, bool) {
+	state := b.latest
+	if state == nil {
+		return nil, false
+	}
+	if b.count == 0 {
+		b.latest = nil
+		return state, true
+	}
+
+	i := (b.head + b.count - 1) % len(b.deltas)
+	d := b.deltas[i]
+	b.deltas[i] = delta{}
+	b.count--
+	b.size -= d.size
+	b.latest = d.apply(state)
+	return state, true
+}
+
+// append adds a delta to the newest end of the ring, growing it if it is
+// full.
+func (b *Buffer) append(d delta) {
+	if b.count == len(b.deltas) {
+		grown := make([]delta, 2*len(b.deltas)+16)
+		for i := 0; i < b.count; i++ {
+			grown[i] = b.deltas[(b.head+i)%len(b.deltas)]
+		}
+		b.deltas, b.head = grown, 0
+	}
+	b.deltas[(b.head+b.count)%len(b.deltas)] = d
+	b.count++
+}
+
+// diff returns the delta that turns state into prev.
+func diff(state, prev []byte) delta {
+	d := delta{length: len(prev)}
+	if len(state) != len(prev) {
+		d.runs = []run{{data: append([]byte(nil), prev...)}}
+		d.size = len(prev) + runOverhead
+		return d
+	}
+
+	start, end := -1, -1
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		d.runs = append(d.runs, run{offset: start, data: append([]byte(nil), prev[start:end]...)})
+		d.size += end - start + runOverhead
+		start = -1
+	}
+	for chunk := 0; chunk < len(prev); chunk += chunkSize {
+		limit := chunk + chunkSize
+		if limit > len(prev) {
+			limit = len(prev)
+		}
+		if bytes.Equal(state[chunk:limit], prev[chunk:limit]) {
+			continue
+		}
+		for i := chunk; i < limit; i++ {
+			if state[i] == prev[i] {
+				continue
+			}
+			if start >= 0 && i-end > maxGap {
+				flush()
+			}
+			if start < 0 {
+				start = i
+			}
+			end = i + 1
+		}
+	}
+	flush()
+	return d
+}
+
+// apply returns the earlier state the delta was made from, given the state
+// after it.
+func (d delta) apply(state []byte) []byte {
+	prev := make([]byte, d.length)
+	copy(prev, state)
+	for _, r := range d.runs {
+		copy(prev[r.offset:], r.data)
+	}
+	return prev
+}
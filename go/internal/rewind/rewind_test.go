@@ -0,0 +1,91 @@
+package rewind_test
+
+import (
+	"testing"
+
+	"chip-8/internal/rewind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// states returns n states of size bytes, each differing from the one before
+// it in a single byte.
+func states(n, size int) [][]byte {
+	out := make([][]byte, n)
+	state := make([]byte, size)
+	for i := range out {
+		state[(i*37)%size]++
+		out[i] = append([]byte(nil), state...)
+	}
+	return out
+}
+
+func TestBuffer_PushPop(t *testing.T) {
+	type testCase struct {
+		label  string
+		states [][]byte
+	}
+
+	cases := []testCase{
+		{label: "single", states: states(1, 100)},
+		{label: "small changes", states: states(50, 4096)},
+		{label: "unchanged", states: [][]byte{{1, 2, 3}, {1, 2, 3}, {1, 2, 3}}},
+		{label: "changing length", states: [][]byte{{1, 2, 3}, {4, 5}, {6, 7, 8, 9}, {}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			b := rewind.New(rewind.DefaultBudget)
+			for _, state := range tc.states {
+				b.Push(state)
+			}
+			assert.Equal(t, len(tc.states), b.Len())
+
+			for i := len(tc.states) - 1; i >= 0; i-- {
+				state, ok := b.Pop()
+				require.True(t, ok)
+				assert.Equal(t, tc.states[i], state, "state %d", i)
+			}
+			_, ok := b.Pop()
+			assert.False(t, ok)
+			assert.Equal(t, 0, b.Len())
+		})
+	}
+}
+
+func TestBuffer_Budget(t *testing.T) {
+	all := states(1000, 4096)
+	b := rewind.New(16 << 10)
+	for _, state := range all {
+		b.Push(state)
+	}
+
+	assert.LessOrEqual(t, b.Size(), 16<<10)
+	assert.Less(t, b.Len(), len(all), "the oldest states should have been dropped")
+	assert.Greater(t, b.Len(), 100, "small deltas should be kept compactly")
+
+	n := b.Len()
+	for i := len(all) - 1; i >= len(all)-n; i-- {
+		state, ok := b.Pop()
+		require.True(t, ok)
+		assert.Equal(t, all[i], state, "state %d", i)
+	}
+	_, ok := b.Pop()
+	assert.False(t, ok)
+}
+
+func TestBuffer_Reset(t *testing.T) {
+	b := rewind.New(rewind.DefaultBudget)
+	for _, state := range states(10, 64) {
+		b.Push(state)
+	}
+	b.Reset()
+
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, 0, b.Size())
+	b.Push([]byte{1})
+	state, ok := b.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1}, state)
+}
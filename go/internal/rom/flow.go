@@ -0,0 +1,223 @@
+package rom
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+// label kinds, in order of precedence when an address is referenced in more
+// than one way.
+const (
+	dataLabel       = 'D'
+	jumpLabel       = 'L'
+	subroutineLabel = 'S'
+)
+
+// flow is the result of tracing a program's control flow.
+type flow struct {
+	romBytes []byte
+	// code marks the offsets instructions start at.
+	code []bool
+	// covered marks every byte that belongs to an instruction.
+	covered []bool
+	labels  map[int]byte
+}
+
+// DisassembleRecursive parses the passed ROM bytes into a human readable
+// assembly format in the syntax by following the program's control flow from its entry
+// point, rather than sweeping through it two bytes at a time. Jumps, calls,
+// skips, and returns are traced to separate code from data, so code at odd
+// addresses is found and data isn't mistaken for instructions.
+//
+// Jump, call, and I targets are given generated labels: Lnnn for jump targets,
+// Snnn for subroutines, and Dnnn for data. Bytes that are never reached are
+// written as data directives with a preview of the byte as a sprite row. The
+// output can be assembled back into the original ROM, by the asm package in
+// the Classic syntax and by Octo in the Octo syntax.
+func DisassembleRecursive(rom io.Reader, syntax cpu.Syntax) (io.Reader, error) {
+	romBytes, err := ioutil.ReadAll(rom)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	f := traceFlow(romBytes)
+
+	l := newListing(syntax)
+	for offset := 0; offset < len(romBytes); {
+		addr := offset + romMemStartOffset
+		if offset == 0 && l.layout.entry != "" {
+			l.label(l.layout.entry)
+		}
+		if kind, ok := f.labels[addr]; ok {
+			l.label(labelName(kind, addr))
+		}
+
+		if f.code[offset] {
+			in := f.decodeAt(offset)
+			l.instruction(addr, romBytes[offset:offset+in.Length()], in, f.labelName)
+			offset += in.Length()
+			continue
+		}
+
+		l.data(addr, romBytes[offset])
+		offset++
+	}
+
+	return &l.Buffer, nil
+}
+
+// traceFlow follows every path through the program from the entry point,
+// marking the instructions reached along the way.
+func traceFlow(romBytes []byte) *flow {
+	f := &flow{
+		romBytes: romBytes,
+		code:     make([]bool, len(romBytes)),
+		covered:  make([]bool, len(romBytes)),
+		labels:   map[int]byte{},
+	}
+
+	pending := []int{romMemStartOffset}
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for {
+			offset := addr - romMemStartOffset
+			if offset < 0 || offset+1 >= len(romBytes) || f.code[offset] {
+				break
+			}
+			in := f.decodeAt(offset)
+			size := in.Length()
+			if isUnknown(in) || offset+size > len(romBytes) {
+				break
+			}
+			// stop rather than decode an instruction overlapping another
+			if f.overlaps(offset, size) {
+				break
+			}
+			f.code[offset] = true
+			for i := 0; i < size; i++ {
+				f.covered[offset+i] = true
+			}
+
+			nnn := int(in.NNN)
+			next := addr + size
+			switch in.Mnemonic {
+			case cpu.OpF000:
+				f.label(int(in.Operand), dataLabel)
+			case cpu.Op00EE, cpu.Op00FD:
+				next = -1
+			case cpu.Op1nnn:
+				f.label(nnn, jumpLabel)
+				pending = append(pending, nnn)
+				next = -1
+			case cpu.Op2nnn:
+				f.label(nnn, subroutineLabel)
+				pending = append(pending, nnn)
+			case cpu.OpBnnn:
+				// the target depends on V0, but the table usually starts at nnn
+				f.label(nnn, jumpLabel)
+				pending = append(pending, nnn)
+				next = -1
+			case cpu.OpAnnn:
+				f.label(nnn, dataLabel)
+			case cpu.Op3xkk, cpu.Op4xkk, cpu.Op5xy0, cpu.Op9xy0, cpu.OpEx9E, cpu.OpExA1:
+				pending = append(pending, next+f.sizeAt(next))
+			}
+
+			if next < 0 {
+				break
+			}
+			addr = next
+		}
+	}
+
+	// labels are only kept if they can be written out, so not outside the
+	// ROM or in the middle of an instruction
+	for addr, kind := range f.labels {
+		offset := addr - romMemStartOffset
+		if offset < 0 || offset >= len(romBytes) || (f.covered[offset] && !f.code[offset]) {
+			delete(f.labels, addr)
+			continue
+		}
+		if kind != dataLabel && !f.code[offset] {
+			f.labels[addr] = dataLabel
+		}
+	}
+
+	return f
+}
+
+// overlaps reports whether any of the size bytes at offset belong to an
+// instruction already.
+func (f *flow) overlaps(offset, size int) bool {
+	for i := 0; i < size; i++ {
+		if f.covered[offset+i] {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeAt returns the size of the instruction at addr, which skips step over.
+func (f *flow) sizeAt(addr int) int {
+	offset := addr - romMemStartOffset
+	if offset < 0 || offset+1 >= len(f.romBytes) {
+		return 2
+	}
+	return cpu.Decode(cpu.OpcodeFromBytes(f.romBytes[offset : offset+2])).Length()
+}
+
+// label records a reference to addr, keeping the most significant kind.
+func (f *flow) label(addr int, kind byte) {
+	if existing, ok := f.labels[addr]; !ok || existing < kind {
+		f.labels[addr] = kind
+	}
+}
+
+// decodeAt decodes the instruction at offset, including the operand of a 4
+// byte instruction if it is inside the ROM.
+func (f *flow) decodeAt(offset int) cpu.DecodedInstruction {
+	in := cpu.Decode(cpu.OpcodeFromBytes(f.romBytes[offset : offset+2]))
+	if in.Length() == 4 && offset+4 <= len(f.romBytes) {
+		in = cpu.DecodeLong(in.Opcode, uint16(f.romBytes[offset+2])<<8|uint16(f.romBytes[offset+3]))
+	}
+	return in
+}
+
+// labelName returns the label of addr, or "" if it has none.
+func (f *flow) labelName(addr uint16) string {
+	kind, ok := f.labels[int(addr)]
+	if !ok {
+		return ""
+	}
+	return labelName(kind, int(addr))
+}
+
+func labelName(kind byte, addr int) string {
+	return fmt.Sprintf("%c%03x", kind, addr)
+}
+
+// isUnknown reports whether the instruction isn't a valid instruction. SYS
+// is counted as unknown since modern interpreters ignore it, and data is far
+// more likely to decode as it than code.
+func isUnknown(in cpu.DecodedInstruction) bool {
+	return in.Mnemonic == cpu.OpUnknown || in.Mnemonic == cpu.Op0nnn
+}
+
+// spriteRow draws the byte as a row of a sprite, with # for set bits.
+func spriteRow(b byte) string {
+	row := make([]byte, 8)
+	for i := range row {
+		row[i] = '.'
+		if b&(0x80>>uint(i)) != 0 {
+			row[i] = '#'
+		}
+	}
+	return string(row)
+}
@@ -0,0 +1,106 @@
+package rom
+
+import (
+	"bytes"
+	"fmt"
+
+	"chip-8/internal/cpu"
+)
+
+// layout is how a disassembly in a syntax is laid out around the
+// instructions the syntax formats.
+type layout struct {
+	// columns starts each line with the address and bytes it was
+	// disassembled from. Octo can't assemble source with them.
+	columns bool
+	comment string
+	label   func(name string) string
+	data    func(b byte) string
+	// entry is the label the entry point is always given, if any.
+	entry string
+}
+
+var (
+	classicLayout = layout{
+		columns: true,
+		comment: "; ",
+		label:   func(name string) string { return name + ":" },
+		data:    func(b byte) string { return fmt.Sprintf("%-10s $%02x", "db", b) },
+	}
+	cowgodLayout = layout{
+		columns: true,
+		comment: "; ",
+		label:   func(name string) string { return name + ":" },
+		data:    func(b byte) string { return fmt.Sprintf("%-5s #%02x", "DB", b) },
+	}
+	// Octo starts programs at the main label, so it is put at the entry
+	// point.
+	octoLayout = layout{
+		comment: "# ",
+		label:   func(name string) string { return ": " + name },
+		data:    func(b byte) string { return fmt.Sprintf(":byte 0x%02x", b) },
+		entry:   "main",
+	}
+)
+
+func layoutOf(syntax cpu.Syntax) layout {
+	switch syntax {
+	case cpu.Cowgod:
+		return cowgodLayout
+	case cpu.Octo:
+		return octoLayout
+	}
+	return classicLayout
+}
+
+// listing writes the lines of a disassembly.
+type listing struct {
+	bytes.Buffer
+	syntax cpu.Syntax
+	layout layout
+}
+
+func newListing(syntax cpu.Syntax) *listing {
+	return &listing{syntax: syntax, layout: layoutOf(syntax)}
+}
+
+// label writes a label line.
+func (l *listing) label(name string) {
+	l.WriteString(l.layout.label(name) + "\n")
+}
+
+// instruction writes an instruction disassembled from the bytes b at addr.
+// Opcodes that aren't the usual encoding of their instruction, like 9AB1 for
+// SKIP.NE VA,VB, are written as unknown so a Classic listing assembles back
+// into b.
+func (l *listing) instruction(addr int, b []byte, in cpu.DecodedInstruction, labels cpu.Labels) {
+	if in.Encode() != in.Opcode {
+		in = cpu.DecodedInstruction{Opcode: in.Opcode}
+	}
+	l.line(addr, b, l.syntax.Format(in, labels), "")
+}
+
+// data writes a byte that isn't part of an instruction, with a preview of the
+// byte as a sprite row.
+func (l *listing) data(addr int, b byte) {
+	l.line(addr, []byte{b}, l.layout.data(b), spriteRow(b))
+}
+
+func (l *listing) line(addr int, b []byte, text, comment string) {
+	if !l.layout.columns {
+		l.WriteString("\t")
+	} else {
+		cols := fmt.Sprintf("%04x", addr)
+		for _, v := range b {
+			cols += fmt.Sprintf(" %02x", v)
+		}
+		// single data bytes line up with instructions
+		fmt.Fprintf(l, "%-10s ", cols)
+	}
+
+	if comment == "" {
+		l.WriteString(text + "\n")
+		return
+	}
+	fmt.Fprintf(l, "%-22s%s%s\n", text, l.layout.comment, comment)
+}
@@ -0,0 +1,214 @@
+package rom
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Stdin is the path Load reads a ROM from standard input for.
+const Stdin = "-"
+
+var (
+	// ErrNoROMInArchive is returned when loading an archive that holds no
+	// .ch8, .sc8, or .xo8 file.
+	ErrNoROMInArchive = errors.New("archive has no ROM files")
+	// ErrMultipleROMsInArchive is returned when loading an archive that holds
+	// more than one ROM file without naming the one to load after a colon,
+	// as in "games.zip:pong.ch8". Archives read from standard input can't
+	// name one, so they must hold a single ROM file.
+	ErrMultipleROMsInArchive = errors.New("archive has more than one ROM file")
+	// ErrCartridge is returned when loading a GIF, such as an Octo cartridge,
+	// rather than running the image as a program. Cartridges keep their
+	// program as Octo source, which has to be exported from Octo as a ROM.
+	ErrCartridge = errors.New("Octo cartridges are not supported, export the program from Octo as a .ch8 ROM")
+)
+
+// romExtensions are the extensions of the ROM files looked for in archives.
+var romExtensions = []string{".ch8", ".sc8", ".xo8"}
+
+// archiveExtensions are the extensions of the archives Load reads a ROM from,
+// and the functions that list the files in them.
+var archiveExtensions = []struct {
+	ext   string
+	files func(data []byte) (map[string][]byte, error)
+}{
+	{".zip", zipFiles},
+	{".tar.gz", tarGzFiles},
+	{".tgz", tarGzFiles},
+}
+
+// Load reads the ROM at the path, which is one of:
+//
+//   - "-", for a ROM read from standard input,
+//   - a .zip, .tar.gz, or .tgz archive holding a single .ch8, .sc8, or .xo8
+//     file, or with the file to load added after a colon when it holds more,
+//     such as "games.zip:pong.ch8",
+//   - or a plain ROM file.
+//
+// GIF images, such as Octo cartridges, return ErrCartridge.
+//
+// The kind of a file read from standard input is found from its contents.
+func Load(filepath string) (*ROM, error) {
+	if filepath == Stdin {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read standard input")
+		}
+		return sniff(data)
+	}
+
+	file, member := splitMember(filepath)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lower := strings.ToLower(file)
+	for _, a := range archiveExtensions {
+		if strings.HasSuffix(lower, a.ext) {
+			return fromArchive(data, a.files, member)
+		}
+	}
+	if strings.HasSuffix(lower, ".gif") || isGIF(data) {
+		return nil, ErrCartridge
+	}
+	return New(data)
+}
+
+// sniff loads a ROM from data that may be an archive or a GIF, going by
+// the magic number it starts with.
+func sniff(data []byte) (*ROM, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return fromArchive(data, zipFiles, "")
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return fromArchive(data, tarGzFiles, "")
+	case isGIF(data):
+		return nil, ErrCartridge
+	}
+	return New(data)
+}
+
+// isGIF reports whether the data starts with the magic number of a GIF.
+func isGIF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("GIF8"))
+}
+
+// splitMember splits the name of a file in an archive, given after a colon,
+// from the path of the archive.
+func splitMember(filepath string) (string, string) {
+	lower := strings.ToLower(filepath)
+	for _, a := range archiveExtensions {
+		if i := strings.Index(lower, a.ext+":"); i >= 0 {
+			end := i + len(a.ext)
+			return filepath[:end], filepath[end+1:]
+		}
+	}
+	return filepath, ""
+}
+
+// fromArchive loads the ROM file in the archive named member, which can be
+// given by its base name alone, or the only ROM file if member is empty.
+func fromArchive(data []byte, list func([]byte) (map[string][]byte, error), member string) (*ROM, error) {
+	files, err := list(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range files {
+		if member == "" && isROMFile(name) || member != "" && (name == member || path.Base(name) == member) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	switch {
+	case len(names) == 1 && isGIF(files[names[0]]):
+		return nil, ErrCartridge
+	case len(names) == 1:
+		return New(files[names[0]])
+	case member != "" && len(names) == 0:
+		return nil, errors.Errorf("archive has no file %s", member)
+	case len(names) == 0:
+		return nil, ErrNoROMInArchive
+	}
+	return nil, errors.Wrapf(ErrMultipleROMsInArchive, "choose one of %s by adding it after a colon", strings.Join(names, ", "))
+}
+
+func isROMFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range romExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// maxArchiveFile is the size of the largest file read from an archive, which
+// is far larger than any ROM.
+const maxArchiveFile = 1 << 20
+
+func zipFiles(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read zip archive")
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || f.UncompressedSize64 > maxArchiveFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s from zip archive", f.Name)
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(rc, maxArchiveFile))
+		rc.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s from zip archive", f.Name)
+		}
+		files[f.Name] = b
+	}
+	return files, nil
+}
+
+func tarGzFiles(data []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read gzip archive")
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read tar archive")
+		}
+		if !h.FileInfo().Mode().IsRegular() || h.Size > maxArchiveFile {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s from tar archive", h.Name)
+		}
+		files[h.Name] = b
+	}
+}
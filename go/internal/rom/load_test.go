@@ -0,0 +1,115 @@
+package rom_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"chip-8/internal/rom"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	pong   = []byte{0x60, 0x01, 0x12, 0x00}
+	tetris = []byte{0x61, 0x02, 0x12, 0x00}
+)
+
+func writeZip(t *testing.T, file string, files map[string][]byte) {
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+}
+
+func writeTarGz(t *testing.T, file string, files map[string][]byte) {
+	buf := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+}
+
+func TestLoad_Formats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rom")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeZip(t, filepath.Join(dir, "pong.zip"), map[string][]byte{"README.txt": []byte("pong"), "games/pong.ch8": pong})
+	writeZip(t, filepath.Join(dir, "games.zip"), map[string][]byte{"pong.ch8": pong, "tetris.sc8": tetris})
+	writeTarGz(t, filepath.Join(dir, "tetris.tar.gz"), map[string][]byte{"tetris.xo8": tetris})
+	writeTarGz(t, filepath.Join(dir, "games.tgz"), map[string][]byte{"pong.ch8": pong, "tetris.ch8": tetris})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pong.ch8"), pong, 0644))
+
+	type testCase struct {
+		label        string
+		path         string
+		expectedData []byte
+	}
+	cases := []testCase{
+		{label: "plain", path: "pong.ch8", expectedData: pong},
+		{label: "zip", path: "pong.zip", expectedData: pong},
+		{label: "zip member", path: "games.zip:tetris.sc8", expectedData: tetris},
+		{label: "tar.gz", path: "tetris.tar.gz", expectedData: tetris},
+		{label: "tgz member", path: "games.tgz:pong.ch8", expectedData: pong},
+	}
+	for _, c := range cases {
+		r, err := rom.Load(filepath.Join(dir, c.path))
+		require.NoError(t, err, c.label)
+		assert.Equal(t, c.expectedData, r.Data, c.label)
+	}
+}
+
+func TestLoad_ArchiveErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rom")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeZip(t, filepath.Join(dir, "games.zip"), map[string][]byte{"pong.ch8": pong, "tetris.sc8": tetris})
+	writeZip(t, filepath.Join(dir, "readme.zip"), map[string][]byte{"README.txt": []byte("pong")})
+
+	_, err = rom.Load(filepath.Join(dir, "games.zip"))
+	assert.Equal(t, rom.ErrMultipleROMsInArchive, errors.Cause(err))
+	assert.Contains(t, err.Error(), "pong.ch8, tetris.sc8")
+
+	_, err = rom.Load(filepath.Join(dir, "readme.zip"))
+	assert.Equal(t, rom.ErrNoROMInArchive, errors.Cause(err))
+
+	_, err = rom.Load(filepath.Join(dir, "games.zip:breakout.ch8"))
+	assert.EqualError(t, err, "archive has no file breakout.ch8")
+}
+
+func TestLoad_Cartridge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rom")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	gif := []byte("GIF89a\x10\x00\x08\x00")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pong.gif"), pong, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cartridge.ch8"), gif, 0644))
+	writeZip(t, filepath.Join(dir, "games.zip"), map[string][]byte{"pong.gif": gif})
+
+	for _, path := range []string{"pong.gif", "cartridge.ch8", "games.zip:pong.gif"} {
+		_, err := rom.Load(filepath.Join(dir, path))
+		assert.Equal(t, rom.ErrCartridge, errors.Cause(err), path)
+	}
+}
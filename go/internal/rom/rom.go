@@ -1,8 +1,8 @@
 package rom
 
 import (
-	"bytes"
-	"fmt"
+	"crypto/sha1"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 
@@ -15,35 +15,154 @@ import (
 // so we skip ahead to that address.
 const romMemStartOffset = 0x200
 
-// Load opens a file and returns the rom bytes.
-func Load(filepath string) (io.Reader, error) {
-	romBytes, err := ioutil.ReadFile(filepath)
-	if err != nil {
-		return nil, errors.WithStack(err)
+var (
+	// ErrEmptyROM is returned when a ROM has no bytes.
+	ErrEmptyROM = errors.New("ROM is empty")
+	// ErrROMTooLarge is returned when a ROM doesn't fit in memory after the
+	// program start address.
+	ErrROMTooLarge = errors.New("ROM is too large")
+)
+
+// Platform is a CHIP-8 variant a ROM can be written for.
+type Platform int
+
+const (
+	// PlatformCHIP8 is the original COSMAC VIP interpreter, with 4 KiB of
+	// memory.
+	PlatformCHIP8 Platform = iota
+	// PlatformSCHIP is SUPER-CHIP, which adds the 128x64 high resolution
+	// mode, scrolling and the RPL user flags.
+	PlatformSCHIP
+	// PlatformXOCHIP is XO-CHIP, which adds 64 KiB of memory, a second
+	// bit-plane and audio patterns.
+	PlatformXOCHIP
+)
+
+func (p Platform) String() string {
+	switch p {
+	case PlatformSCHIP:
+		return "SUPER-CHIP"
+	case PlatformXOCHIP:
+		return "XO-CHIP"
+	}
+	return "CHIP-8"
+}
+
+// MaxSize returns the size in bytes of the largest ROM that fits in the
+// platform's memory after the program start address: 3584 bytes for CHIP-8
+// and SUPER-CHIP, and 65024 bytes for XO-CHIP.
+func (p Platform) MaxSize() int {
+	if p == PlatformXOCHIP {
+		return cpu.XOCHIPMemorySize - romMemStartOffset
+	}
+	return cpu.MemorySize - romMemStartOffset
+}
+
+// ROM is a CHIP-8 program and what is known about it.
+type ROM struct {
+	// Data is the program, which is loaded at the program start address.
+	Data []byte
+	// SHA1 and CRC32 are checksums of Data, for identifying the ROM.
+	SHA1  [sha1.Size]byte
+	CRC32 uint32
+	// OddLength is set when the ROM is an odd number of bytes long, so it
+	// ends in a byte that isn't a whole instruction.
+	OddLength bool
+	// Platform is a guess of the platform the ROM was written for, from the
+	// instructions reachable from the entry point.
+	Platform Platform
+}
+
+// New identifies the program and returns it as a ROM. It returns
+// ErrEmptyROM for an empty program, and ErrROMTooLarge for one that doesn't
+// fit in the memory of any platform.
+func New(data []byte) (*ROM, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyROM
+	}
+	if max := PlatformXOCHIP.MaxSize(); len(data) > max {
+		return nil, errors.Wrapf(ErrROMTooLarge, "%d bytes, the most any platform can load is %d", len(data), max)
+	}
+
+	return &ROM{
+		Data:      data,
+		SHA1:      sha1.Sum(data),
+		CRC32:     crc32.ChecksumIEEE(data),
+		OddLength: len(data)%2 != 0,
+		Platform:  guessPlatform(data),
+	}, nil
+}
+
+// Fits returns ErrROMTooLarge if the ROM doesn't fit in the platform's
+// memory.
+func (r *ROM) Fits(p Platform) error {
+	if len(r.Data) > p.MaxSize() {
+		return errors.Wrapf(ErrROMTooLarge, "%d bytes, the most %s can load is %d", len(r.Data), p, p.MaxSize())
+	}
+	return nil
+}
+
+// guessPlatform returns the newest platform that introduced any of the
+// instructions reachable from the entry point. ROMs too large for CHIP-8
+// memory can only be XO-CHIP.
+func guessPlatform(data []byte) Platform {
+	if len(data) > PlatformCHIP8.MaxSize() {
+		return PlatformXOCHIP
 	}
 
-	return bytes.NewReader(romBytes), nil
+	platform := PlatformCHIP8
+	f := traceFlow(data)
+	for offset, code := range f.code {
+		if !code {
+			continue
+		}
+		switch f.decodeAt(offset).Mnemonic.Extension() {
+		case cpu.ExtensionXOCHIP:
+			return PlatformXOCHIP
+		case cpu.ExtensionSCHIP:
+			platform = PlatformSCHIP
+		}
+	}
+	return platform
 }
 
-// Disassemble parses the passed ROM bytes into a human readable assembly format.
-// It parses 2 bytes at a time, maps instruction, then appends it to the returned io.Reader.
-func Disassemble(rom io.Reader) (io.Reader, error) {
+// Disassemble parses the passed ROM bytes into a human readable assembly format
+// in the syntax. It parses 2 bytes at a time, or 4 for the XO-CHIP F000 nnnn
+// instruction if the ROM appears to be written for XO-CHIP, maps instruction,
+// then appends it to the returned io.Reader. In other ROMs f0 00 is far more
+// likely to be sprite data, which mustn't swallow the word after it.
+func Disassemble(rom io.Reader, syntax cpu.Syntax) (io.Reader, error) {
 	romBytes, err := ioutil.ReadAll(rom)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	instructions := bytes.NewBuffer([]byte{})
+	xochip := guessPlatform(romBytes) == PlatformXOCHIP
+	l := newListing(syntax)
+	if l.layout.entry != "" {
+		l.label(l.layout.entry)
+	}
 
-	for pc := 0; pc < len(romBytes); pc += 2 {
+	for pc := 0; pc < len(romBytes); {
+		if pc+1 == len(romBytes) {
+			// an odd trailing byte can't be an instruction
+			l.data(pc+romMemStartOffset, romBytes[pc])
+			break
+		}
 		b := romBytes[pc : pc+2]
-		op := cpu.OpcodeFromBytes(b)
-		lineOut := fmt.Sprintf("%04x %02x %02x %s", pc+romMemStartOffset, b[0], b[1], op.Instruction())
-		_, err := instructions.WriteString(lineOut + "\n")
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to write instruction at byte %d", pc)
+		in := cpu.Decode(cpu.OpcodeFromBytes(b))
+		switch {
+		case in.Length() == 4 && xochip && pc+4 <= len(romBytes):
+			b = romBytes[pc : pc+4]
+			in = cpu.DecodeLong(in.Opcode, uint16(b[2])<<8|uint16(b[3]))
+		case in.Length() == 4:
+			// the operand is missing, or isn't one outside XO-CHIP, so it
+			// can't be written as an instruction
+			in = cpu.DecodedInstruction{Opcode: in.Opcode}
 		}
+		l.instruction(pc+romMemStartOffset, b, in, nil)
+		pc += len(b)
 	}
 
-	return instructions, nil
+	return &l.Buffer, nil
 }
@@ -1,11 +1,16 @@
 package rom_test
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"hash/crc32"
 	"io/ioutil"
 	"testing"
 
+	"chip-8/internal/cpu"
 	"chip-8/internal/rom"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,10 +51,81 @@ func TestLoad(t *testing.T) {
 	testRom, err := rom.Load(testFilepath)
 	require.NoError(t, err)
 
-	romBytes, err := ioutil.ReadAll(testRom)
-	require.NoError(t, err)
+	assert.Equal(t, expectedBytes, testRom.Data)
+	assert.Equal(t, sha1.Sum(expectedBytes), testRom.SHA1)
+	assert.Equal(t, crc32.ChecksumIEEE(expectedBytes), testRom.CRC32)
+	assert.False(t, testRom.OddLength)
+	assert.Equal(t, rom.PlatformCHIP8, testRom.Platform)
+}
 
-	assert.Equal(t, expectedBytes, romBytes)
+func TestNew(t *testing.T) {
+	type testCase struct {
+		label     string
+		data      []byte
+		err       error
+		oddLength bool
+		platform  rom.Platform
+	}
+	cases := []testCase{
+		{
+			label: "empty",
+			data:  []byte{},
+			err:   rom.ErrEmptyROM,
+		},
+		{
+			label: "larger than XO-CHIP memory",
+			data:  make([]byte, rom.PlatformXOCHIP.MaxSize()+1),
+			err:   rom.ErrROMTooLarge,
+		},
+		{
+			label:     "odd trailing byte",
+			data:      []byte{0x12, 0x00, 0xff},
+			oddLength: true,
+			platform:  rom.PlatformCHIP8,
+		},
+		{
+			label:    "reachable SUPER-CHIP instruction",
+			data:     []byte{0x00, 0xff, 0x12, 0x02},
+			platform: rom.PlatformSCHIP,
+		},
+		{
+			label:    "unreachable SUPER-CHIP instruction",
+			data:     []byte{0x12, 0x00, 0x00, 0xff},
+			platform: rom.PlatformCHIP8,
+		},
+		{
+			label:    "reachable XO-CHIP instruction",
+			data:     []byte{0x00, 0xff, 0xf0, 0x00, 0x02, 0x00, 0x12, 0x06},
+			platform: rom.PlatformXOCHIP,
+		},
+		{
+			label:    "larger than CHIP-8 memory",
+			data:     make([]byte, rom.PlatformCHIP8.MaxSize()+2),
+			platform: rom.PlatformXOCHIP,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			r, err := rom.New(c.data)
+			if c.err != nil {
+				assert.Equal(t, c.err, errors.Cause(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.oddLength, r.OddLength)
+			assert.Equal(t, c.platform, r.Platform)
+		})
+	}
+}
+
+func TestROM_Fits(t *testing.T) {
+	assert.Equal(t, 3584, rom.PlatformCHIP8.MaxSize())
+
+	r, err := rom.New(make([]byte, 3585))
+	require.NoError(t, err)
+	assert.Equal(t, rom.ErrROMTooLarge, errors.Cause(r.Fits(rom.PlatformCHIP8)))
+	assert.Equal(t, rom.ErrROMTooLarge, errors.Cause(r.Fits(rom.PlatformSCHIP)))
+	assert.NoError(t, r.Fits(rom.PlatformXOCHIP))
 }
 
 func TestDisassemble(t *testing.T) {
@@ -60,7 +136,7 @@ func TestDisassemble(t *testing.T) {
 	testRom, err := rom.Load(testFilepath)
 	require.NoError(t, err)
 
-	instructions, err := rom.Disassemble(testRom)
+	instructions, err := rom.Disassemble(bytes.NewReader(testRom.Data), cpu.Classic)
 	require.NoError(t, err)
 
 	instructionBytes, err := ioutil.ReadAll(instructions)
@@ -68,3 +144,223 @@ func TestDisassemble(t *testing.T) {
 
 	require.Equal(t, expectedInstructionBytes, instructionBytes)
 }
+
+func TestDisassembleRecursive(t *testing.T) {
+	testFilepath := "../../test/roms/test_opcode.ch8"
+	expectedInstructionBytes, err := ioutil.ReadFile("../../test/fixtures/test_opcode_recursive.asm")
+	require.NoError(t, err)
+
+	testRom, err := rom.Load(testFilepath)
+	require.NoError(t, err)
+
+	instructions, err := rom.DisassembleRecursive(bytes.NewReader(testRom.Data), cpu.Classic)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	require.Equal(t, string(expectedInstructionBytes), string(instructionBytes))
+}
+
+func TestDisassembleRecursive_OddAlignedCode(t *testing.T) {
+	romBytes := []byte{
+		0x22, 0x05, // 0x200: CALL $205
+		0x12, 0x02, // 0x202: JUMP $202
+		0xf0,       // 0x204: data
+		0x30, 0x01, // 0x205: SKIP.EQ V0,#$01
+		0xa2, 0x04, // 0x207: MVI I,$204
+		0x00, 0xee, // 0x209: RTS
+		0x03, //       0x20b: odd trailing byte
+	}
+	expected := "0200 22 05 CALL       S205\n" +
+		"L202:\n" +
+		"0202 12 02 JUMP       L202\n" +
+		"D204:\n" +
+		"0204 f0    db         $f0        ; ####....\n" +
+		"S205:\n" +
+		"0205 30 01 SKIP.EQ    V0,#$01\n" +
+		"0207 a2 04 MVI        I,D204\n" +
+		"0209 00 ee RTS       \n" +
+		"020b 03    db         $03        ; ......##\n"
+
+	instructions, err := rom.DisassembleRecursive(bytes.NewReader(romBytes), cpu.Classic)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(instructionBytes))
+}
+
+func TestDisassemble_LongInstruction(t *testing.T) {
+	romBytes := []byte{
+		0xf0, 0x00, 0x12, 0x34, // 0x200: MVIL I,#$1234
+		0xf0, 0x00, //             0x204: truncated MVIL
+	}
+	expected := "0200 f0 00 12 34 MVIL       I,#$1234\n" +
+		"0204 f0 00 UNK        0xf000\n"
+
+	instructions, err := rom.Disassemble(bytes.NewReader(romBytes), cpu.Classic)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(instructionBytes))
+}
+
+func TestDisassemble_SpriteDataLikeLongInstruction(t *testing.T) {
+	// a CHIP-8 ROM, where f0 00 can only be sprite data
+	romBytes := []byte{
+		0xa2, 0x06, // 0x200: MVI I,$206
+		0xd0, 0x12, // 0x202: SPRITE V0,V1,#$2
+		0x12, 0x04, // 0x204: JUMP $204
+		0xf0, 0x00, // 0x206: sprite data
+		0x60, 0x01, // 0x208: MVI V0,#$01
+	}
+	expected := "0200 a2 06 MVI        I,#$206\n" +
+		"0202 d0 12 SPRITE.    V0,V1,#$2\n" +
+		"0204 12 04 JUMP       $204\n" +
+		"0206 f0 00 UNK        0xf000\n" +
+		"0208 60 01 MVI        V0,#$01\n"
+
+	instructions, err := rom.Disassemble(bytes.NewReader(romBytes), cpu.Classic)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(instructionBytes))
+}
+
+func TestDisassembleRecursive_LongInstruction(t *testing.T) {
+	romBytes := []byte{
+		0x30, 0x00, //             0x200: SKIP.EQ V0,#$00
+		0xf0, 0x00, 0x02, 0x0a, // 0x202: MVIL I,D20a
+		0xf0, 0x02, //             0x206: AUDIO
+		0x00, 0xfd, //             0x208: EXIT
+		0xaa, //                   0x20a: data
+	}
+	expected := "0200 30 00 SKIP.EQ    V0,#$00\n" +
+		"0202 f0 00 02 0a MVIL       I,D20a\n" +
+		"0206 f0 02 AUDIO     \n" +
+		"0208 00 fd EXIT      \n" +
+		"D20a:\n" +
+		"020a aa    db         $aa        ; #.#.#.#.\n"
+
+	instructions, err := rom.DisassembleRecursive(bytes.NewReader(romBytes), cpu.Classic)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(instructionBytes))
+}
+
+func TestDisassembleRecursive_Syntax(t *testing.T) {
+	romBytes := []byte{
+		0x22, 0x06, //             0x200: CALL $206
+		0x12, 0x02, //             0x202: JUMP $202
+		0xf0,       //             0x204: data
+		0x03,       //             0x205: data
+		0x3a, 0x01, //             0x206: SKIP.EQ VA,#$01
+		0xa2, 0x04, //             0x208: MVI I,$204
+		0xf0, 0x00, 0x02, 0x05, // 0x20a: MVIL I,$205
+		0x01, 0xe0, //             0x20e: not CLS, as the second nibble isn't 0
+		0x00, 0xee, //             0x210: RTS, never reached
+	}
+	type testCase struct {
+		label    string
+		syntax   cpu.Syntax
+		expected string
+	}
+	cases := []testCase{
+		{
+			label:  "cowgod",
+			syntax: cpu.Cowgod,
+			expected: "0200 22 06 CALL  S206\n" +
+				"L202:\n" +
+				"0202 12 02 JP    L202\n" +
+				"D204:\n" +
+				"0204 f0    DB    #f0             ; ####....\n" +
+				"D205:\n" +
+				"0205 03    DB    #03             ; ......##\n" +
+				"S206:\n" +
+				"0206 3a 01 SE    VA, #01\n" +
+				"0208 a2 04 LD    I, D204\n" +
+				"020a f0 00 02 05 LD    I, D205\n" +
+				"020e 01    DB    #01             ; .......#\n" +
+				"020f e0    DB    #e0             ; ###.....\n" +
+				"0210 00    DB    #00             ; ........\n" +
+				"0211 ee    DB    #ee             ; ###.###.\n",
+		},
+		{
+			label:  "octo",
+			syntax: cpu.Octo,
+			expected: ": main\n" +
+				"\t:call S206\n" +
+				": L202\n" +
+				"\tjump L202\n" +
+				": D204\n" +
+				"\t:byte 0xf0            # ####....\n" +
+				": D205\n" +
+				"\t:byte 0x03            # ......##\n" +
+				": S206\n" +
+				"\tif va != 0x01 then\n" +
+				"\ti := D204\n" +
+				"\ti := long D205\n" +
+				"\t:byte 0x01            # .......#\n" +
+				"\t:byte 0xe0            # ###.....\n" +
+				"\t:byte 0x00            # ........\n" +
+				"\t:byte 0xee            # ###.###.\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			instructions, err := rom.DisassembleRecursive(bytes.NewReader(romBytes), c.syntax)
+			require.NoError(t, err)
+
+			instructionBytes, err := ioutil.ReadAll(instructions)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expected, string(instructionBytes))
+		})
+	}
+}
+
+func TestDisassemble_Octo(t *testing.T) {
+	romBytes := []byte{
+		0x60, 0x05, // 0x200: MVI V0,#$05
+		0x80, 0x0f, // 0x202: unknown
+		0xf0, 0x00, // 0x204: truncated MVIL
+	}
+	expected := ": main\n" +
+		"\tv0 := 0x05\n" +
+		"\t0x80 0x0f\n" +
+		"\t0xf0 0x00\n"
+
+	instructions, err := rom.Disassemble(bytes.NewReader(romBytes), cpu.Octo)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(instructionBytes))
+}
+
+func TestDisassemble_OddLength(t *testing.T) {
+	romBytes := []byte{
+		0x60, 0x05, // 0x200: MVI V0,#$05
+		0x81, //       0x202: odd trailing byte
+	}
+	expected := "0200 60 05 MVI        V0,#$05\n" +
+		"0202 81    db         $81        ; #......#\n"
+
+	instructions, err := rom.Disassemble(bytes.NewReader(romBytes), cpu.Classic)
+	require.NoError(t, err)
+
+	instructionBytes, err := ioutil.ReadAll(instructions)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(instructionBytes))
+}
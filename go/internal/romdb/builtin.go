@@ -0,0 +1,41 @@
+package romdb
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	builtinOnce sync.Once
+	builtin     *Database
+)
+
+// Builtin returns the database built into the emulator. It only lists the
+// test ROMs that come with it, not the chip-8-database programs, so real ROMs
+// are only recognised by reading a copy of its programs.json with Read.
+func Builtin() *Database {
+	builtinOnce.Do(func() {
+		db, err := Read(strings.NewReader(builtinPrograms))
+		if err != nil {
+			panic(err)
+		}
+		builtin = db
+	})
+	return builtin
+}
+
+// builtinPrograms is the built in database, in the chip-8-database
+// programs.json format.
+const builtinPrograms = `[
+  {
+    "title": "CHIP-8 Test ROM",
+    "description": "Checks the results of the arithmetic, logic, and memory instructions and shows OK or NO for each.",
+    "authors": ["corax89"],
+    "roms": {
+      "2f1ff813e1138f22f0156cf02010147f465e177e": {
+        "file": "test_opcode.ch8",
+        "platforms": ["modernChip8", "originalChip8"]
+      }
+    }
+  }
+]`
@@ -0,0 +1,198 @@
+// Package romdb looks up what is known about CHIP-8 programs, such as their
+// title and the settings they play best with, by the SHA-1 hash of their ROM.
+//
+// Databases are read in the programs.json format of the community
+// chip-8-database project, so its full database can be used in place of, or
+// alongside, the small one built in.
+package romdb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidDatabase is returned when reading data that isn't a ROM database.
+var ErrInvalidDatabase = errors.New("invalid ROM database")
+
+// Program is a program listed in a database, which may have been released as
+// several ROMs.
+type Program struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Release     string   `json:"release,omitempty"`
+	Authors     []string `json:"authors,omitempty"`
+	// ROMs maps the hex SHA-1 hash of each of the program's ROMs to its
+	// settings.
+	ROMs map[string]ROM `json:"roms"`
+}
+
+// ROM holds the settings a ROM of a program plays best with.
+type ROM struct {
+	File string `json:"file,omitempty"`
+	// Platforms are the ids of the platforms the ROM runs on, in order of
+	// preference, such as "originalChip8", "superchip", or "xochip".
+	Platforms []string `json:"platforms"`
+	// QuirkyPlatforms overrides the quirks of a platform for the ROM, keyed
+	// by platform id and then by quirk name.
+	QuirkyPlatforms map[string]map[string]bool `json:"quirkyPlatforms,omitempty"`
+	// TickRate is the number of instructions executed per frame.
+	TickRate int `json:"tickrate,omitempty"`
+	// Keys maps the controls the program uses, such as "up" or "a", to the
+	// keypad keys that they are.
+	Keys   map[string]int `json:"keys,omitempty"`
+	Colors *Colors        `json:"colors,omitempty"`
+}
+
+// Colors are the colours a ROM is meant to be shown in, as "#rrggbb" strings.
+type Colors struct {
+	// Pixels are the colours of each pixel value, starting with unlit.
+	Pixels  []string `json:"pixels,omitempty"`
+	Buzzer  string   `json:"buzzer,omitempty"`
+	Silence string   `json:"silence,omitempty"`
+}
+
+// Entry is a ROM found in a database along with the program it belongs to.
+type Entry struct {
+	Program Program
+	ROM     ROM
+}
+
+// Database is a set of programs indexed by the hashes of their ROMs.
+type Database struct {
+	entries map[[sha1.Size]byte]Entry
+}
+
+// Read reads a database in the chip-8-database programs.json format. It
+// returns ErrInvalidDatabase if the data isn't one.
+func Read(r io.Reader) (*Database, error) {
+	var programs []Program
+	if err := json.NewDecoder(r).Decode(&programs); err != nil {
+		return nil, errors.Wrap(ErrInvalidDatabase, err.Error())
+	}
+
+	db := &Database{entries: make(map[[sha1.Size]byte]Entry)}
+	for _, p := range programs {
+		for key, r := range p.ROMs {
+			var hash [sha1.Size]byte
+			if n, err := hex.Decode(hash[:], []byte(key)); err != nil || n != sha1.Size {
+				return nil, errors.Wrapf(ErrInvalidDatabase, "%q of %q is not a SHA-1 hash", key, p.Title)
+			}
+			db.entries[hash] = Entry{Program: p, ROM: r}
+		}
+	}
+	return db, nil
+}
+
+// Len returns the number of ROMs in the database.
+func (db *Database) Len() int {
+	return len(db.entries)
+}
+
+// Lookup returns the entry of the ROM with the SHA-1 hash, if it is in the
+// database.
+func (db *Database) Lookup(hash [sha1.Size]byte) (Entry, bool) {
+	e, ok := db.entries[hash]
+	return e, ok
+}
+
+// platformProfiles maps the chip-8-database platform ids to the quirk profiles
+// that emulate them.
+var platformProfiles = map[string]string{
+	"originalChip8": "vip",
+	"hybridVIP":     "vip",
+	"chip8x":        "vip",
+	"modernChip8":   "cowgod",
+	"chip48":        "chip48",
+	"superchip1":    "schip",
+	"superchip":     "schip",
+	"megachip8":     "schip",
+	"xochip":        "xochip",
+}
+
+// Profile returns the name of the quirk profile for the first of the ROM's
+// platforms that has one, and the profile's quirks with the ROM's overrides
+// for that platform applied. ok is false if none of the platforms can be
+// emulated.
+func (r ROM) Profile() (name string, quirks cpu.Quirks, ok bool) {
+	for _, platform := range r.Platforms {
+		if name, ok = platformProfiles[platform]; !ok {
+			continue
+		}
+		quirks, _ = cpu.ProfileByName(name)
+		overrides := r.QuirkyPlatforms[platform]
+		for _, quirk := range quirkNames {
+			if on, ok := overrides[quirk]; ok {
+				applyQuirk(&quirks, quirk, on)
+			}
+		}
+		return name, quirks, true
+	}
+	return "", cpu.Quirks{}, false
+}
+
+// quirkNames are the chip-8-database quirks the CPU has, in the order they are
+// applied, so leaving I unchanged wins over incrementing it by x.
+var quirkNames = []string{"shift", "memoryIncrementByX", "memoryLeaveIUnchanged", "wrap", "jump", "logic"}
+
+// applyQuirk turns a chip-8-database quirk on or off.
+func applyQuirk(q *cpu.Quirks, quirk string, on bool) {
+	switch quirk {
+	case "shift":
+		q.ShiftUsesVy = !on
+	case "memoryIncrementByX":
+		if on {
+			q.LoadStoreIncrement = cpu.IncrementX
+		} else if q.LoadStoreIncrement == cpu.IncrementX {
+			q.LoadStoreIncrement = cpu.IncrementXPlusOne
+		}
+	case "memoryLeaveIUnchanged":
+		if on {
+			q.LoadStoreIncrement = cpu.IncrementNone
+		} else if q.LoadStoreIncrement == cpu.IncrementNone {
+			q.LoadStoreIncrement = cpu.IncrementXPlusOne
+		}
+	case "wrap":
+		q.ClipSprites = !on
+	case "jump":
+		q.JumpUsesVx = on
+	case "logic":
+		q.LogicResetsVF = on
+	}
+}
+
+// PixelColors returns the colours of each pixel value the ROM is meant to be
+// shown in, starting with unlit, or nil if the ROM doesn't have any.
+func (r ROM) PixelColors() ([]color.RGBA, error) {
+	if r.Colors == nil || len(r.Colors.Pixels) == 0 {
+		return nil, nil
+	}
+
+	colors := make([]color.RGBA, len(r.Colors.Pixels))
+	for i, s := range r.Colors.Pixels {
+		c, err := ParseColor(s)
+		if err != nil {
+			return nil, err
+		}
+		colors[i] = c
+	}
+	return colors, nil
+}
+
+// ParseColor parses a colour written as "#rrggbb".
+func ParseColor(s string) (color.RGBA, error) {
+	hexDigits := strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(hexDigits, 16, 32)
+	if err != nil || len(hexDigits) != 6 {
+		return color.RGBA{}, errors.Errorf("colour %q must be written as #rrggbb", s)
+	}
+	return color.RGBA{R: byte(v >> 16), G: byte(v >> 8), B: byte(v), A: 0xff}, nil
+}
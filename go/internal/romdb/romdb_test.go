@@ -0,0 +1,148 @@
+package romdb_test
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rom"
+	"chip-8/internal/romdb"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDatabase = `[
+  {
+    "title": "Test Game",
+    "authors": ["Someone"],
+    "roms": {
+      "0123456789abcdef0123456789abcdef01234567": {
+        "platforms": ["megachip8", "superchip"],
+        "quirkyPlatforms": {
+          "superchip": {"memoryLeaveIUnchanged": false, "wrap": true, "vblank": true}
+        },
+        "tickrate": 30,
+        "keys": {"up": 5, "a": 6},
+        "colors": {"pixels": ["#102030", "#ffeedd"], "buzzer": "#ff0000"}
+      }
+    }
+  }
+]`
+
+func TestRead(t *testing.T) {
+	db, err := romdb.Read(strings.NewReader(testDatabase))
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.Len())
+
+	e, ok := db.Lookup([20]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67})
+	require.True(t, ok)
+	assert.Equal(t, "Test Game", e.Program.Title)
+	assert.Equal(t, []string{"Someone"}, e.Program.Authors)
+	assert.Equal(t, 30, e.ROM.TickRate)
+	assert.Equal(t, map[string]int{"up": 5, "a": 6}, e.ROM.Keys)
+
+	_, ok = db.Lookup([20]byte{})
+	assert.False(t, ok)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	type testCase struct {
+		label string
+		data  string
+	}
+	cases := []testCase{
+		{label: "not JSON", data: "CHIP-8"},
+		{label: "not a list", data: `{"title": "Test Game"}`},
+		{label: "bad hash", data: `[{"title": "Test Game", "roms": {"0123": {}}}]`},
+	}
+	for _, c := range cases {
+		_, err := romdb.Read(strings.NewReader(c.data))
+		assert.Equal(t, romdb.ErrInvalidDatabase, errors.Cause(err), c.label)
+	}
+}
+
+func TestROM_Profile(t *testing.T) {
+	type testCase struct {
+		label           string
+		rom             romdb.ROM
+		expectedProfile string
+		expectedQuirks  cpu.Quirks
+	}
+	cases := []testCase{
+		{
+			label:           "first platform",
+			rom:             romdb.ROM{Platforms: []string{"originalChip8", "modernChip8"}},
+			expectedProfile: "vip",
+			expectedQuirks:  cpu.QuirksCOSMACVIP,
+		},
+		{
+			label:           "unknown platform skipped",
+			rom:             romdb.ROM{Platforms: []string{"blinky", "xochip"}},
+			expectedProfile: "xochip",
+			expectedQuirks:  cpu.QuirksXOCHIP,
+		},
+		{
+			label: "quirky platform",
+			rom: romdb.ROM{
+				Platforms: []string{"superchip"},
+				QuirkyPlatforms: map[string]map[string]bool{
+					"superchip": {"shift": false, "memoryLeaveIUnchanged": false, "wrap": true, "jump": false, "logic": true},
+				},
+			},
+			expectedProfile: "schip",
+			expectedQuirks: cpu.Quirks{
+				ShiftUsesVy:        true,
+				LoadStoreIncrement: cpu.IncrementXPlusOne,
+				LogicResetsVF:      true,
+			},
+		},
+		{
+			label: "leaving I unchanged wins",
+			rom: romdb.ROM{
+				Platforms: []string{"modernChip8"},
+				QuirkyPlatforms: map[string]map[string]bool{
+					"modernChip8": {"memoryIncrementByX": true, "memoryLeaveIUnchanged": true},
+				},
+			},
+			expectedProfile: "cowgod",
+			expectedQuirks:  cpu.Quirks{LoadStoreIncrement: cpu.IncrementNone},
+		},
+	}
+	for _, c := range cases {
+		profile, quirks, ok := c.rom.Profile()
+		require.True(t, ok, c.label)
+		assert.Equal(t, c.expectedProfile, profile, c.label)
+		assert.Equal(t, c.expectedQuirks, quirks, c.label)
+	}
+
+	_, _, ok := romdb.ROM{Platforms: []string{"blinky"}}.Profile()
+	assert.False(t, ok)
+}
+
+func TestROM_PixelColors(t *testing.T) {
+	colors, err := romdb.ROM{}.PixelColors()
+	require.NoError(t, err)
+	assert.Nil(t, colors)
+
+	colors, err = romdb.ROM{Colors: &romdb.Colors{Pixels: []string{"#102030", "ffeedd"}}}.PixelColors()
+	require.NoError(t, err)
+	assert.Equal(t, []color.RGBA{
+		{R: 0x10, G: 0x20, B: 0x30, A: 0xff},
+		{R: 0xff, G: 0xee, B: 0xdd, A: 0xff},
+	}, colors)
+
+	_, err = romdb.ROM{Colors: &romdb.Colors{Pixels: []string{"#fff"}}}.PixelColors()
+	assert.Error(t, err)
+}
+
+func TestBuiltin(t *testing.T) {
+	r, err := rom.Load("../../test/roms/test_opcode.ch8")
+	require.NoError(t, err)
+
+	e, ok := romdb.Builtin().Lookup(r.SHA1)
+	require.True(t, ok)
+	assert.Equal(t, "test_opcode.ch8", e.ROM.File)
+}
@@ -0,0 +1,523 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rewind"
+	"chip-8/internal/terminal"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TimerHz is the rate the delay and sound timers count down at.
+	TimerHz = 60
+
+	// DefaultSpeed is the default number of instructions executed per second.
+	DefaultSpeed = 700
+
+	// keyHoldFrames is how many frames a key stays pressed after it is typed.
+	// Terminals only report key presses, never releases, so a held key is
+	// seen as a stream of repeated presses.
+	keyHoldFrames = 8
+
+	// ctrlC is the byte sent by the terminal for Ctrl-C while in raw mode.
+	ctrlC = 0x03
+	// ctrlS and ctrlL are the bytes sent for Ctrl-S and Ctrl-L, which save
+	// and load a state when followed by the digit of its slot.
+	ctrlS = 0x13
+	ctrlL = 0x0c
+	// backspace and ctrlH are the bytes terminals send for Backspace, which
+	// rewinds while it is held.
+	backspace = 0x7f
+	ctrlH     = 0x08
+	// ctrlP pauses and resumes, ctrlN advances a frame while paused, ctrlT
+	// turns turbo mode on and off, and ctrlW slows the emulation down.
+	ctrlP = 0x10
+	ctrlN = 0x0e
+	ctrlT = 0x14
+	ctrlW = 0x17
+
+	// minMultiplier is the slowest Ctrl-W slows the emulation down to before
+	// going back to the configured speed.
+	minMultiplier = 1.0 / 8
+)
+
+// Timing is how the runner decides how many instructions to execute each
+// frame.
+type Timing int
+
+const (
+	// TimingFixed executes Config.Speed instructions a second, treating every
+	// instruction as taking the same time.
+	TimingFixed Timing = iota
+	// TimingVIP charges each instruction the machine cycles it took on the
+	// COSMAC VIP, gives each frame the cycles the VIP's interpreter had, and
+	// ends the frame when a sprite is drawn, as the VIP waited for the
+	// vertical blank to draw. Config.Speed is ignored.
+	TimingVIP
+)
+
+// timings maps the names timing models are chosen by to them.
+var timings = map[string]Timing{
+	"fixed": TimingFixed,
+	"vip":   TimingVIP,
+}
+
+// TimingByName returns the timing model with the name, as listed by
+// TimingNames.
+func TimingByName(name string) (Timing, bool) {
+	t, ok := timings[name]
+	return t, ok
+}
+
+// TimingNames returns the names of the timing models in sorted order.
+func TimingNames() []string {
+	names := make([]string, 0, len(timings))
+	for name := range timings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Display is where the runner draws frames and reads key presses from.
+type Display interface {
+	Draw(pixels []byte, width, height int) error
+	Beep() error
+	Keys() <-chan byte
+}
+
+// StateStore keeps the numbered save state slots, 0 through 9.
+type StateStore interface {
+	// SaveState stores a snapshot in the slot.
+	SaveState(slot int, snapshot []byte) error
+	// LoadState returns the snapshot stored in the slot, or nil if the slot
+	// is empty.
+	LoadState(slot int) ([]byte, error)
+}
+
+// StatusDisplay is a Display that can also show a line of status text, which
+// Run shows the measured emulation speed, whether it is paused, and save
+// states that failed in.
+type StatusDisplay interface {
+	Display
+	Status(text string) error
+}
+
+// Recorder records the input of a run so it can be replayed.
+type Recorder interface {
+	// RecordKeys is given the state of the keypad at the start of every
+	// frame, as returned by cpu.CPU.Keys.
+	RecordKeys(keys uint16)
+}
+
+// Config holds the settings for running a program.
+type Config struct {
+	// Speed is the number of instructions executed per second.
+	Speed int
+	// Timing decides how many instructions are executed each frame.
+	Timing Timing
+	// Multiplier scales the real-time speed Run emulates at, so that below 1
+	// is slow motion. It is 1 when 0.
+	Multiplier float64
+	// Turbo makes Run emulate as fast as it can rather than in real time.
+	// Ctrl-T turns it on and off.
+	Turbo bool
+	// Keymap maps typed characters to keypad keys.
+	Keymap terminal.Keymap
+	// States keeps save states. Ctrl-S followed by a digit saves the state
+	// to that slot and Ctrl-L followed by a digit loads it. A save or load
+	// that fails is shown as the status and the run carries on. Save states
+	// are disabled when it is nil.
+	States StateStore
+	// Rewind records the state of the machine at the start of every frame.
+	// Holding Backspace steps back through it a frame at a time. Rewinding
+	// is disabled when it is nil.
+	Rewind *rewind.Buffer
+	// Recorder is given the keypad state of every frame. Nothing is
+	// recorded when it is nil.
+	Recorder Recorder
+}
+
+// Runner drives a CPU in real time, rendering its screen to a Display and
+// feeding it key presses.
+type Runner struct {
+	cpu     *cpu.CPU
+	display Display
+	config  Config
+
+	// cycleBudget carries fractional instructions over between frames so the
+	// instruction rate is honoured when it isn't a multiple of TimerHz.
+	cycleBudget float64
+	// keyTimers counts down the frames remaining until each key is released.
+	keyTimers [16]int
+	beeping   bool
+	// stateKey is Ctrl-S or Ctrl-L while waiting for the digit of a slot.
+	stateKey byte
+	// rewindTimer counts down the frames remaining until the rewind key is
+	// released.
+	rewindTimer int
+	// cyclesLeft is the number of instructions left to execute before
+	// stopping, or negative if there is no limit.
+	cyclesLeft int
+	// executed is the number of instructions executed, for measuring the
+	// emulation speed.
+	executed uint64
+
+	// scheduler decides when frames are due while Run is running.
+	scheduler *Scheduler
+	// skipDraw is set for frames that aren't drawn because another follows
+	// before the display would show them.
+	skipDraw bool
+	lastDraw time.Time
+}
+
+// New constructs a Runner for the CPU and display.
+func New(c *cpu.CPU, display Display, config Config) *Runner {
+	if config.Speed <= 0 {
+		config.Speed = DefaultSpeed
+	}
+	if config.Multiplier <= 0 {
+		config.Multiplier = 1
+	}
+	return &Runner{
+		cpu:        c,
+		display:    display,
+		config:     config,
+		cyclesLeft: -1,
+	}
+}
+
+// Run executes the program in real time, as scheduled by a Scheduler, until
+// Ctrl-C is typed, the display stops delivering key presses, the program
+// exits, or the CPU faults, in which case the *cpu.ExecutionError is
+// returned. Ctrl-P pauses and resumes, Ctrl-N advances a frame while paused,
+// Ctrl-T turns turbo mode on and off, and Ctrl-W slows the emulation down by
+// half each time it is typed, down to an eighth of its speed.
+func (r *Runner) Run() error {
+	now := time.Now()
+	r.scheduler = NewScheduler(now, r.config.Multiplier)
+	r.scheduler.SetTurbo(now, r.config.Turbo)
+	defer func() { r.scheduler = nil }()
+
+	// due is always ready to receive from, for when a frame is due at once
+	due := make(chan time.Time)
+	close(due)
+
+	keys := r.display.Keys()
+	for {
+		var tick <-chan time.Time
+		if wait, ok := r.scheduler.Wait(time.Now()); ok && wait > 0 {
+			tick = time.After(wait)
+		} else if ok {
+			tick = due
+		}
+
+		select {
+		case b, ok := <-keys:
+			if !ok || b == ctrlC {
+				return nil
+			}
+			if err := r.key(b); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := r.runDue(); err != nil {
+				return err
+			}
+			if r.cpu.Exited() {
+				return nil
+			}
+		}
+	}
+}
+
+// runDue runs the frames the scheduler has due, only drawing the last of
+// them, and no more often than TimerHz in turbo mode. It shows the measured
+// emulation speed when the scheduler reports it.
+func (r *Runner) runDue() error {
+	now := time.Now()
+	n := r.scheduler.Due(now)
+	defer func() { r.skipDraw = false }()
+	for i := 0; i < n; i++ {
+		r.skipDraw = i < n-1 || r.scheduler.Turbo() && now.Sub(r.lastDraw) < time.Second/TimerHz
+		if !r.skipDraw {
+			r.lastDraw = now
+		}
+		if err := r.Frame(); err != nil {
+			return err
+		}
+		if r.cpu.Exited() {
+			return nil
+		}
+	}
+
+	if stats, ok := r.scheduler.Report(now, r.executed); ok {
+		return r.status(&stats)
+	}
+	return nil
+}
+
+// Headless runs the program as fast as possible, without reading key presses,
+// until it has run frames frames or executed cycles instructions, whichever
+// comes first. A limit of 0 is no limit. The frame the instruction limit is
+// reached in is cut short, but its timers still tick and it is still drawn.
+// Like Run it also stops when the program exits or the CPU faults.
+func (r *Runner) Headless(frames, cycles int) error {
+	if cycles > 0 {
+		r.cyclesLeft = cycles
+		defer func() { r.cyclesLeft = -1 }()
+	}
+	for i := 0; frames <= 0 || i < frames; i++ {
+		if err := r.Frame(); err != nil {
+			return err
+		}
+		if r.cpu.Exited() || r.cyclesLeft == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Frame advances the emulation by one 60 Hz frame: it executes the frame's
+// share of instructions, ticks the timers, and draws the screen. While the
+// rewind key is held it steps back a frame instead.
+func (r *Runner) Frame() error {
+	r.releaseKeys()
+
+	if r.rewindTimer > 0 {
+		r.rewindTimer--
+		if _, err := r.Rewind(); err != nil {
+			return err
+		}
+		return r.present()
+	}
+
+	if r.config.Rewind != nil {
+		r.config.Rewind.Push(r.cpu.Snapshot())
+	}
+	if r.config.Recorder != nil {
+		r.config.Recorder.RecordKeys(r.cpu.Keys())
+	}
+	execute := r.executeFixed
+	if r.config.Timing == TimingVIP {
+		execute = r.executeVIP
+	}
+	if err := execute(); err != nil {
+		return err
+	}
+	r.cpu.TickTimers()
+	return r.present()
+}
+
+// executeFixed executes the frame's share of Config.Speed instructions a
+// second.
+func (r *Runner) executeFixed() error {
+	r.cycleBudget += float64(r.config.Speed) / TimerHz
+	for ; r.cycleBudget >= 1 && r.cyclesLeft != 0; r.cycleBudget-- {
+		if err := r.step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeVIP executes instructions until they have used up the machine cycles
+// the COSMAC VIP interpreter had each frame, or one draws a sprite. An
+// instruction that runs past the end of the frame takes its extra cycles from
+// the next one.
+func (r *Runner) executeVIP() error {
+	r.cycleBudget += cpu.VIPFrameCycles - cpu.VIPDisplayCycles
+	for r.cycleBudget > 0 && r.cyclesLeft != 0 {
+		if err := r.step(); err != nil {
+			return err
+		}
+		cycles, vblank := r.cpu.VIPCycles()
+		r.cycleBudget -= float64(cycles)
+		if vblank {
+			// the rest of the frame is spent waiting for the interrupt
+			r.cycleBudget = math.Min(r.cycleBudget, 0)
+			return nil
+		}
+	}
+	return nil
+}
+
+// step executes one instruction, counting it against the instruction limit.
+func (r *Runner) step() error {
+	if err := r.cpu.Cycle(); err != nil {
+		return err
+	}
+	if r.cyclesLeft > 0 {
+		r.cyclesLeft--
+	}
+	r.executed++
+	return nil
+}
+
+// Rewind restores the state of the machine at the start of the most recent
+// frame recorded by the rewind buffer, reporting whether there was one.
+func (r *Runner) Rewind() (bool, error) {
+	if r.config.Rewind == nil {
+		return false, nil
+	}
+	snapshot, ok := r.config.Rewind.Pop()
+	if !ok {
+		return false, nil
+	}
+	return true, errors.Wrap(r.cpu.Restore(snapshot), "failed to rewind")
+}
+
+// present sounds the buzzer if it has just turned on and draws the screen.
+func (r *Runner) present() error {
+	if sound := r.cpu.SoundActive(); sound != r.beeping {
+		r.beeping = sound
+		if sound {
+			if err := r.display.Beep(); err != nil {
+				return errors.Wrap(err, "failed to sound buzzer")
+			}
+		}
+	}
+
+	if r.skipDraw {
+		return nil
+	}
+	width, height := r.cpu.Resolution()
+	err := r.display.Draw(r.cpu.Screen(), width, height)
+	return errors.Wrap(err, "failed to draw frame")
+}
+
+// status shows the state of the scheduler and the measured emulation speed,
+// if there is one, on displays that can show it.
+func (r *Runner) status(stats *Stats) error {
+	d, ok := r.display.(StatusDisplay)
+	if !ok {
+		return nil
+	}
+
+	var text string
+	switch {
+	case r.scheduler.Paused():
+		text = "Paused, Ctrl-N advances a frame"
+	case stats != nil:
+		text = fmt.Sprintf("%.0f%% speed, %.0f instructions/s", 100*stats.Speed(), stats.IPS)
+	}
+	if r.scheduler.Turbo() {
+		text += " [turbo]"
+	} else if m := r.scheduler.Multiplier(); m != r.config.Multiplier {
+		text += fmt.Sprintf(" [slow motion x%g]", m/r.config.Multiplier)
+	}
+	return errors.Wrap(d.Status(text), "failed to show status")
+}
+
+// statusError shows an error from a hotkey on displays that can show it, so
+// a save state that fails, such as on a full disk or from a corrupt slot,
+// doesn't end the run.
+func (r *Runner) statusError(err error) error {
+	d, ok := r.display.(StatusDisplay)
+	if !ok || err == nil {
+		return nil
+	}
+	return errors.Wrap(d.Status(err.Error()), "failed to show status")
+}
+
+// control handles the hotkeys that control the scheduler while Run is
+// running, reporting whether the byte was one.
+func (r *Runner) control(b byte) (bool, error) {
+	if r.scheduler == nil {
+		return false, nil
+	}
+	s, now := r.scheduler, time.Now()
+	switch b {
+	case ctrlP:
+		s.SetPaused(now, !s.Paused())
+	case ctrlN:
+		if s.Paused() {
+			s.Advance()
+			return true, nil
+		}
+		s.SetPaused(now, true)
+	case ctrlT:
+		s.SetTurbo(now, !s.Turbo())
+	case ctrlW:
+		m := s.Multiplier() / 2
+		if m < r.config.Multiplier*minMultiplier {
+			m = r.config.Multiplier
+		}
+		s.SetMultiplier(now, m)
+	default:
+		return false, nil
+	}
+	return true, r.status(nil)
+}
+
+// key handles a typed byte, which is either a hotkey, part of a save state
+// hotkey, or a keypad key press.
+func (r *Runner) key(b byte) error {
+	if ok, err := r.control(b); ok || err != nil {
+		r.stateKey = 0
+		return err
+	}
+	if (b == backspace || b == ctrlH) && r.config.Rewind != nil {
+		r.stateKey = 0
+		r.rewindTimer = keyHoldFrames
+		return nil
+	}
+	if r.config.States == nil {
+		r.press(b)
+		return nil
+	}
+
+	stateKey := r.stateKey
+	r.stateKey = 0
+	switch {
+	case b == ctrlS || b == ctrlL:
+		r.stateKey = b
+		return nil
+	case stateKey == 0:
+		r.press(b)
+		return nil
+	case b < '0' || b > '9':
+		// not a slot, so the hotkey is abandoned
+		return nil
+	}
+
+	slot := int(b - '0')
+	if stateKey == ctrlS {
+		return r.statusError(errors.Wrapf(r.config.States.SaveState(slot, r.cpu.Snapshot()), "failed to save state to slot %d", slot))
+	}
+	snapshot, err := r.config.States.LoadState(slot)
+	if err != nil {
+		return r.statusError(errors.Wrapf(err, "failed to load state from slot %d", slot))
+	}
+	if snapshot == nil {
+		return nil
+	}
+	return r.statusError(errors.Wrapf(r.cpu.Restore(snapshot), "failed to restore state from slot %d", slot))
+}
+
+func (r *Runner) press(b byte) {
+	key, ok := r.config.Keymap.Key(b)
+	if !ok {
+		return
+	}
+	r.keyTimers[key] = keyHoldFrames
+	r.cpu.SetKey(key, true)
+}
+
+func (r *Runner) releaseKeys() {
+	for key, frames := range r.keyTimers {
+		if frames == 0 {
+			continue
+		}
+		r.keyTimers[key]--
+		if r.keyTimers[key] == 0 {
+			r.cpu.SetKey(byte(key), false)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package runner_test
+
+import (
+	"errors"
+	"testing"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/rewind"
+	"chip-8/internal/runner"
+	"chip-8/internal/terminal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDisplay struct {
+	frames [][]byte
+	beeps  int
+	keys   chan byte
+}
+
+func (d *fakeDisplay) Draw(pixels []byte, width, height int) error {
+	d.frames = append(d.frames, pixels)
+	return nil
+}
+
+func (d *fakeDisplay) Beep() error {
+	d.beeps++
+	return nil
+}
+
+func (d *fakeDisplay) Keys() <-chan byte {
+	return d.keys
+}
+
+func TestRunner_Frame(t *testing.T) {
+	c := cpu.NewCPU()
+	err := c.LoadProgram([]byte{
+		0x60, 0x05, // MVI V0,#$05
+		0xf0, 0x18, // MOV SOUND,V0
+		0xa2, 0x0a, // MVI I,#$20a
+		0xd0, 0x01, // SPRITE. V0,V0,#$1
+		0x12, 0x08, // JUMP $208
+		0x80, //       sprite data
+	})
+	require.NoError(t, err)
+	display := &fakeDisplay{}
+
+	r := runner.New(c, display, runner.Config{Speed: 240})
+	require.NoError(t, r.Frame())
+
+	require.Len(t, display.frames, 1)
+	assert.Equal(t, byte(1), display.frames[0][5*cpu.ScreenWidth+5])
+	assert.Equal(t, 1, display.beeps)
+	assert.True(t, c.SoundActive())
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, r.Frame())
+	}
+	assert.False(t, c.SoundActive())
+	assert.Equal(t, 1, display.beeps)
+}
+
+func TestRunner_Headless(t *testing.T) {
+	// ADI V0,#$01; JUMP $200
+	count := []byte{0x70, 0x01, 0x12, 0x00}
+
+	type testCase struct {
+		label  string
+		frames int
+		cycles int
+		v0     byte
+		drawn  int
+	}
+
+	cases := []testCase{
+		{label: "frames", frames: 3, v0: 6, drawn: 3},
+		{label: "cycles", cycles: 9, v0: 5, drawn: 3},
+		{label: "cycles within a frame", cycles: 3, v0: 2, drawn: 1},
+		{label: "frames reached first", frames: 2, cycles: 100, v0: 4, drawn: 2},
+		{label: "cycles reached first", frames: 10, cycles: 3, v0: 2, drawn: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := cpu.NewCPU()
+			require.NoError(t, c.LoadProgram(count))
+			display := &fakeDisplay{}
+
+			r := runner.New(c, display, runner.Config{Speed: 240})
+			require.NoError(t, r.Headless(tc.frames, tc.cycles))
+
+			assert.Equal(t, tc.v0, c.V[0])
+			assert.Len(t, display.frames, tc.drawn)
+		})
+	}
+}
+
+func TestRunner_Frame_VIPTiming(t *testing.T) {
+	type testCase struct {
+		label      string
+		program    []byte
+		frames     int
+		expectedV0 byte
+	}
+
+	cases := []testCase{
+		{
+			// 50 and 52 cycles, 25 times round in the 2594 cycles of a frame
+			// and into the add once more
+			label:      "counting",
+			program:    []byte{0x70, 0x01, 0x12, 0x00}, // ADI V0,#$01; JUMP $200
+			frames:     1,
+			expectedV0: 26,
+		},
+		{
+			label: "drawing ends the frame",
+			program: []byte{
+				0x70, 0x01, // ADI V0,#$01
+				0xd1, 0x11, // SPRITE V1,V1,#$1
+				0x12, 0x00, // JUMP $200
+			},
+			frames:     3,
+			expectedV0: 3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			c := cpu.NewCPU()
+			require.NoError(t, c.LoadProgram(tc.program))
+			display := &fakeDisplay{}
+
+			r := runner.New(c, display, runner.Config{Timing: runner.TimingVIP})
+			for i := 0; i < tc.frames; i++ {
+				require.NoError(t, r.Frame())
+			}
+
+			assert.Equal(t, tc.expectedV0, c.V[0])
+			assert.Len(t, display.frames, tc.frames)
+		})
+	}
+}
+
+func TestRunner_Run_Quit(t *testing.T) {
+	keymap, err := terminal.ParseKeymap(terminal.DefaultLayout)
+	require.NoError(t, err)
+	display := &fakeDisplay{keys: make(chan byte, 2)}
+	display.keys <- 'q'
+	display.keys <- 0x03
+
+	r := runner.New(cpu.NewCPU(), display, runner.Config{Keymap: keymap})
+	assert.NoError(t, r.Run())
+}
+
+func TestRunner_Run_Exit(t *testing.T) {
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram([]byte{
+		0x00, 0xff, // HIRES
+		0x00, 0xfd, // EXIT
+	}))
+	display := &fakeDisplay{keys: make(chan byte)}
+
+	r := runner.New(c, display, runner.Config{})
+	require.NoError(t, r.Run())
+
+	assert.True(t, c.Exited())
+	require.Len(t, display.frames, 1)
+	assert.Len(t, display.frames[0], cpu.HiResWidth*cpu.HiResHeight)
+}
+
+func TestRunner_Rewind(t *testing.T) {
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram([]byte{
+		0x70, 0x01, // ADI V0,#$01
+		0x12, 0x00, // JUMP $200
+	}))
+	display := &fakeDisplay{}
+
+	r := runner.New(c, display, runner.Config{Speed: 120, Rewind: rewind.New(rewind.DefaultBudget)})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, r.Frame())
+	}
+	assert.Equal(t, byte(3), c.V[0])
+
+	for _, want := range []byte{2, 1, 0} {
+		ok, err := r.Rewind()
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, want, c.V[0])
+	}
+	ok, err := r.Rewind()
+	require.NoError(t, err)
+	assert.False(t, ok, "there should be nothing before the first frame")
+}
+
+type memoryStates map[int][]byte
+
+func (s memoryStates) SaveState(slot int, snapshot []byte) error {
+	s[slot] = snapshot
+	return nil
+}
+
+func (s memoryStates) LoadState(slot int) ([]byte, error) {
+	return s[slot], nil
+}
+
+func TestRunner_Run_SaveStates(t *testing.T) {
+	spin := []byte{0x12, 0x00} // JUMP $200
+
+	saved := cpu.NewCPU()
+	require.NoError(t, saved.LoadProgram(spin))
+	saved.V[0] = 9
+	states := memoryStates{4: saved.Snapshot()}
+
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram(spin))
+	display := &fakeDisplay{keys: make(chan byte, 16)}
+	for _, b := range []byte{0x13, '3', 0x0c, '4', 0x0c, '7', 0x0c, 'x', 0x03} {
+		display.keys <- b
+	}
+
+	r := runner.New(c, display, runner.Config{States: states})
+	require.NoError(t, r.Run())
+
+	assert.NotNil(t, states[3], "Ctrl-S 3 should save to slot 3")
+	assert.Equal(t, byte(9), c.V[0], "Ctrl-L 4 should load slot 4")
+}
+
+type failingStates struct{ err error }
+
+func (s failingStates) SaveState(slot int, snapshot []byte) error {
+	return s.err
+}
+
+func (s failingStates) LoadState(slot int) ([]byte, error) {
+	return nil, s.err
+}
+
+type statusDisplay struct {
+	fakeDisplay
+	statuses []string
+}
+
+func (d *statusDisplay) Status(text string) error {
+	d.statuses = append(d.statuses, text)
+	return nil
+}
+
+func TestRunner_Run_SaveStateErrors(t *testing.T) {
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram([]byte{0x12, 0x00})) // JUMP $200
+	display := &statusDisplay{fakeDisplay: fakeDisplay{keys: make(chan byte, 16)}}
+	for _, b := range []byte{0x13, '3', 0x0c, '4', 0x03} {
+		display.keys <- b
+	}
+
+	r := runner.New(c, display, runner.Config{States: failingStates{errors.New("disk full")}})
+	require.NoError(t, r.Run(), "a failed save or load shouldn't end the run")
+
+	assert.Contains(t, display.statuses, "failed to save state to slot 3: disk full")
+	assert.Contains(t, display.statuses, "failed to load state from slot 4: disk full")
+}
+
+func TestRunner_Run_RestoreError(t *testing.T) {
+	c := cpu.NewCPU()
+	require.NoError(t, c.LoadProgram([]byte{0x12, 0x00})) // JUMP $200
+	c.V[0] = 9
+	display := &statusDisplay{fakeDisplay: fakeDisplay{keys: make(chan byte, 16)}}
+	for _, b := range []byte{0x0c, '1', 0x03} {
+		display.keys <- b
+	}
+
+	r := runner.New(c, display, runner.Config{States: memoryStates{1: []byte("corrupt")}})
+	require.NoError(t, r.Run(), "a corrupt slot shouldn't end the run")
+
+	require.NotEmpty(t, display.statuses)
+	assert.Contains(t, display.statuses[0], "failed to restore state from slot 1")
+	assert.Equal(t, byte(9), c.V[0], "the machine should be left as it was")
+}
@@ -0,0 +1,210 @@
+package runner
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// maxLagFrames is how many frames the scheduler falls behind by before it
+	// gives up catching up, such as after the process was suspended, and
+	// carries on from the current time instead.
+	maxLagFrames = 6
+
+	// reportInterval is how often the scheduler measures the emulation speed.
+	reportInterval = time.Second
+)
+
+// Stats is the emulation speed measured by a Scheduler.
+type Stats struct {
+	// FPS is the number of frames emulated per second of real time.
+	FPS float64
+	// IPS is the number of instructions executed per second of real time.
+	IPS float64
+}
+
+// Speed returns the emulation speed as a multiple of the speed of the
+// machine, where 1 is full speed.
+func (s Stats) Speed() float64 {
+	return s.FPS / TimerHz
+}
+
+// Scheduler decides when the frames of a Runner are due in real time. Frames
+// are due TimerHz times a second scaled by a multiplier, so a multiplier
+// below 1 is slow motion. The time each frame is due is counted from a fixed
+// start rather than from the frame before, so frames run late don't push the
+// ones after them back and the rate doesn't drift. Times are read with
+// time.Now, whose monotonic clock reading isn't affected by changes to the
+// wall clock.
+//
+// A Scheduler can also be paused, advanced a frame at a time while paused, or
+// put in turbo mode, where frames are always due, and it measures the speed
+// frames are actually run at.
+type Scheduler struct {
+	// rate is the number of frames due per second.
+	rate float64
+	// start is when frame 0 is due, and frames the number of frames run
+	// since then.
+	start  time.Time
+	frames int64
+
+	paused  bool
+	advance bool
+	turbo   bool
+
+	// reportStart is when the current measurement began, and reportFrames
+	// and reportInstructions the frames run and instructions executed before
+	// it.
+	reportStart        time.Time
+	reportFrames       int64
+	reportInstructions uint64
+	// reportStale is set when the current measurement spans a pause.
+	reportStale bool
+	// ran is the total number of frames run.
+	ran int64
+}
+
+// NewScheduler returns a Scheduler whose first frame is due at now, running at
+// the speed multiplier, or full speed if it is 0.
+func NewScheduler(now time.Time, multiplier float64) *Scheduler {
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	return &Scheduler{
+		rate:        TimerHz * multiplier,
+		start:       now,
+		reportStart: now,
+	}
+}
+
+// Due returns the number of frames that are due to run at now, which the
+// caller is expected to run. When it has fallen behind by more than a few
+// frames it returns one and carries on from now.
+func (s *Scheduler) Due(now time.Time) int {
+	due := s.due(now)
+	s.ran += int64(due)
+	return due
+}
+
+func (s *Scheduler) due(now time.Time) int {
+	switch {
+	case s.advance:
+		s.advance = false
+		return 1
+	case s.paused:
+		return 0
+	case s.turbo:
+		return 1
+	}
+
+	elapsed := now.Sub(s.start)
+	if elapsed < 0 {
+		return 0
+	}
+	due := int64(elapsed.Seconds()*s.rate) + 1 - s.frames
+	if due > maxLagFrames {
+		s.restart(now)
+		return 1
+	}
+	s.frames += due
+	return int(due)
+}
+
+// Wait returns how long after now the next frame is due, and false if none
+// is because the scheduler is paused.
+func (s *Scheduler) Wait(now time.Time) (time.Duration, bool) {
+	switch {
+	case s.advance || s.turbo && !s.paused:
+		return 0, true
+	case s.paused:
+		return 0, false
+	}
+	// rounded up, so the frame is due by the time waited until
+	next := s.start.Add(time.Duration(math.Ceil(float64(s.frames) * float64(time.Second) / s.rate)))
+	if wait := next.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// restart makes the next frame due one frame after now.
+func (s *Scheduler) restart(now time.Time) {
+	s.start = now.Add(time.Duration(float64(time.Second) / s.rate))
+	s.frames = 0
+}
+
+// SetMultiplier changes the speed frames are due at to the multiplier of full
+// speed, starting from now.
+func (s *Scheduler) SetMultiplier(now time.Time, multiplier float64) {
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	s.rate = TimerHz * multiplier
+	s.restart(now)
+}
+
+// Multiplier returns the multiple of full speed frames are due at.
+func (s *Scheduler) Multiplier() float64 {
+	return s.rate / TimerHz
+}
+
+// SetPaused pauses or resumes the scheduler at now. No frames are due while
+// it is paused, except when advanced.
+func (s *Scheduler) SetPaused(now time.Time, paused bool) {
+	if paused == s.paused {
+		return
+	}
+	s.paused, s.advance = paused, false
+	if !paused {
+		s.restart(now)
+		s.reportStale = true
+	}
+}
+
+// Paused reports whether the scheduler is paused.
+func (s *Scheduler) Paused() bool {
+	return s.paused
+}
+
+// Advance makes a single frame due while the scheduler is paused.
+func (s *Scheduler) Advance() {
+	s.advance = s.paused
+}
+
+// SetTurbo turns turbo mode on or off at now. In turbo mode frames are always
+// due, so they run as fast as they can.
+func (s *Scheduler) SetTurbo(now time.Time, turbo bool) {
+	if turbo == s.turbo {
+		return
+	}
+	s.turbo = turbo
+	if !turbo {
+		s.restart(now)
+	}
+}
+
+// Turbo reports whether the scheduler is in turbo mode.
+func (s *Scheduler) Turbo() bool {
+	return s.turbo
+}
+
+// Report measures the emulation speed since the last report, given the
+// total number of instructions executed by now. It only reports once a
+// second and while running, returning false otherwise.
+func (s *Scheduler) Report(now time.Time, instructions uint64) (Stats, bool) {
+	elapsed := now.Sub(s.reportStart)
+	if s.paused || s.reportStale {
+		s.reportStart, s.reportFrames, s.reportInstructions = now, s.ran, instructions
+		s.reportStale = false
+		return Stats{}, false
+	}
+	if elapsed < reportInterval {
+		return Stats{}, false
+	}
+	stats := Stats{
+		FPS: float64(s.ran-s.reportFrames) / elapsed.Seconds(),
+		IPS: float64(instructions-s.reportInstructions) / elapsed.Seconds(),
+	}
+	s.reportStart, s.reportFrames, s.reportInstructions = now, s.ran, instructions
+	return stats, true
+}
@@ -0,0 +1,123 @@
+package runner_test
+
+import (
+	"testing"
+	"time"
+
+	"chip-8/internal/runner"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestScheduler_Due(t *testing.T) {
+	type testCase struct {
+		label       string
+		multiplier  float64
+		at          time.Duration
+		expectedDue int
+	}
+
+	cases := []testCase{
+		{label: "first frame", at: 0, expectedDue: 1},
+		{label: "within a frame", at: 10 * time.Millisecond, expectedDue: 1},
+		{label: "two frames", at: time.Second/30 + time.Microsecond, expectedDue: 3},
+		{label: "fallen behind", at: time.Second, expectedDue: 1},
+		{label: "slow motion", multiplier: 0.5, at: time.Second/15 + time.Microsecond, expectedDue: 3},
+		{label: "fast forward", multiplier: 2, at: time.Second/30 + time.Microsecond, expectedDue: 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			s := runner.NewScheduler(start, tc.multiplier)
+			assert.Equal(t, tc.expectedDue, s.Due(start.Add(tc.at)))
+		})
+	}
+}
+
+func TestScheduler_NoDrift(t *testing.T) {
+	s := runner.NewScheduler(start, 0)
+
+	// waking up late every frame doesn't slow the frames down
+	frames := 0
+	now := start
+	for now.Before(start.Add(10 * time.Second)) {
+		frames += s.Due(now)
+		wait, ok := s.Wait(now)
+		assert.True(t, ok)
+		now = now.Add(wait + 3*time.Millisecond)
+	}
+	assert.InDelta(t, 600, frames, 1)
+}
+
+func TestScheduler_Wait(t *testing.T) {
+	s := runner.NewScheduler(start, 0)
+	s.Due(start)
+
+	wait, ok := s.Wait(start)
+	assert.True(t, ok)
+	assert.Equal(t, 16666667*time.Nanosecond, wait)
+	assert.Equal(t, 0, s.Due(start.Add(wait-time.Nanosecond)))
+	assert.Equal(t, 1, s.Due(start.Add(wait)))
+}
+
+func TestScheduler_Pause(t *testing.T) {
+	s := runner.NewScheduler(start, 0)
+	s.Due(start)
+
+	s.SetPaused(start, true)
+	assert.True(t, s.Paused())
+	_, ok := s.Wait(start)
+	assert.False(t, ok)
+	assert.Equal(t, 0, s.Due(start.Add(time.Second)))
+
+	s.Advance()
+	wait, ok := s.Wait(start.Add(time.Second))
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+	assert.Equal(t, 1, s.Due(start.Add(time.Second)))
+	assert.Equal(t, 0, s.Due(start.Add(time.Second)))
+
+	// the frames missed while paused are not caught up
+	resumed := start.Add(2 * time.Second)
+	s.SetPaused(resumed, false)
+	assert.Equal(t, 0, s.Due(resumed))
+	assert.Equal(t, 1, s.Due(resumed.Add(time.Second/60)))
+}
+
+func TestScheduler_Turbo(t *testing.T) {
+	s := runner.NewScheduler(start, 0)
+	s.SetTurbo(start, true)
+	assert.True(t, s.Turbo())
+
+	for i := 0; i < 100; i++ {
+		wait, ok := s.Wait(start)
+		assert.True(t, ok)
+		assert.Zero(t, wait)
+		assert.Equal(t, 1, s.Due(start))
+	}
+
+	s.SetTurbo(start, false)
+	assert.Equal(t, 0, s.Due(start))
+}
+
+func TestScheduler_Report(t *testing.T) {
+	s := runner.NewScheduler(start, 0)
+	for at := time.Duration(0); at < time.Second; at += time.Second / 60 {
+		s.Due(start.Add(at))
+	}
+
+	_, ok := s.Report(start.Add(time.Second/2), 350)
+	assert.False(t, ok)
+
+	stats, ok := s.Report(start.Add(time.Second), 700)
+	assert.True(t, ok)
+	assert.InDelta(t, 60, stats.FPS, 0.001)
+	assert.InDelta(t, 700, stats.IPS, 0.001)
+	assert.InDelta(t, 1, stats.Speed(), 0.001)
+
+	s.SetPaused(start.Add(time.Second), true)
+	_, ok = s.Report(start.Add(3*time.Second), 700)
+	assert.False(t, ok)
+}
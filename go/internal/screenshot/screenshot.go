@@ -0,0 +1,129 @@
+// Package screenshot encodes the CHIP-8 display as image files.
+package screenshot
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Palette is the default colour of each pixel value in an Image. An unlit pixel is
+// black and a pixel lit in the first bit-plane only is white, so ordinary
+// CHIP-8 screens are black and white; the other colours only appear in
+// XO-CHIP programs that draw to several bit-planes.
+var Palette = color.Palette{
+	color.Gray{Y: 0x00}, color.Gray{Y: 0xff}, color.Gray{Y: 0xaa}, color.Gray{Y: 0x55},
+	color.RGBA{R: 0xff, A: 0xff}, color.RGBA{G: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}, color.RGBA{R: 0xff, G: 0xff, A: 0xff},
+	color.RGBA{R: 0xff, B: 0xff, A: 0xff}, color.RGBA{G: 0xff, B: 0xff, A: 0xff}, color.RGBA{R: 0x80, A: 0xff}, color.RGBA{G: 0x80, A: 0xff},
+	color.RGBA{B: 0x80, A: 0xff}, color.RGBA{R: 0x80, G: 0x80, A: 0xff}, color.RGBA{R: 0x80, B: 0x80, A: 0xff}, color.RGBA{G: 0x80, B: 0x80, A: 0xff},
+}
+
+// Image returns the display as an image with one image pixel per display
+// pixel, coloured from Palette. pixels holds one byte per pixel in row-major
+// order, as returned by cpu.CPU.Screen, whose low four bits are the
+// bit-planes the pixel is lit in.
+func Image(pixels []byte, width, height int) *image.Paletted {
+	return ImagePalette(pixels, width, height, Palette)
+}
+
+// ImagePalette returns the display as an image like Image, but coloured from
+// the palette, which should have a colour for each of the 16 pixel values.
+func ImagePalette(pixels []byte, width, height int, palette color.Palette) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	for i := 0; i < width*height && i < len(pixels); i++ {
+		img.Pix[i] = pixels[i] & 0xf
+	}
+	return img
+}
+
+// WritePNG writes the display to w as a PNG image.
+func WritePNG(w io.Writer, pixels []byte, width, height int) error {
+	return WritePNGPalette(w, pixels, width, height, Palette)
+}
+
+// WritePNGPalette writes the display to w as a PNG image coloured from the
+// palette, as with ImagePalette.
+func WritePNGPalette(w io.Writer, pixels []byte, width, height int, palette color.Palette) error {
+	return errors.Wrap(png.Encode(w, ImagePalette(pixels, width, height, palette)), "failed to encode PNG")
+}
+
+// WritePBM writes the display to w as a plain text PBM image, in which a lit
+// pixel is 1 and an unlit one 0.
+func WritePBM(w io.Writer, pixels []byte, width, height int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P1\n%d %d\n", width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x > 0 {
+				bw.WriteByte(' ')
+			}
+			bit := byte('0')
+			if i := y*width + x; i < len(pixels) && pixels[i] != 0 {
+				bit = '1'
+			}
+			bw.WriteByte(bit)
+		}
+		bw.WriteByte('\n')
+	}
+	return errors.Wrap(bw.Flush(), "failed to write PBM")
+}
+
+// DiffScale is how many times larger than the screens a Diff image is, so
+// individual pixels are easy to make out.
+const DiffScale = 8
+
+// Diff compares two screenshots pixel by pixel and returns an image of the
+// differences along with the number of pixels that differ. Pixels that match
+// are drawn dimmed, pixels lit in want but not got are red, pixels lit in got
+// but not want are green, and pixels that are lit in both but in different
+// colours are yellow. Screenshots of different sizes are compared over the
+// larger of the two, as if the smaller were padded with unlit pixels.
+func Diff(want, got image.Image) (*image.RGBA, int) {
+	bounds := want.Bounds().Union(got.Bounds())
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx()*DiffScale, bounds.Dy()*DiffScale))
+	differ := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			w, g := colourAt(want, x, y), colourAt(got, x, y)
+			c := color.RGBA{A: 0xff}
+			switch {
+			case w == g && w != 0:
+				c.R, c.G, c.B = 0x60, 0x60, 0x60
+			case w == g:
+			case g == 0:
+				c.R = 0xff
+			case w == 0:
+				c.G = 0xff
+			default:
+				c.R, c.G = 0xff, 0xff
+			}
+			if w != g {
+				differ++
+			}
+			for dy := 0; dy < DiffScale; dy++ {
+				for dx := 0; dx < DiffScale; dx++ {
+					img.SetRGBA((x-bounds.Min.X)*DiffScale+dx, (y-bounds.Min.Y)*DiffScale+dy, c)
+				}
+			}
+		}
+	}
+	return img, differ
+}
+
+// colourAt returns the colour of a pixel packed into an integer that is zero
+// only for unlit pixels, which is what pixels outside the image are.
+func colourAt(img image.Image, x, y int) uint32 {
+	if !(image.Point{X: x, Y: y}).In(img.Bounds()) {
+		return 0
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+	if r == 0 && g == 0 && b == 0 {
+		return 0
+	}
+	return 1<<24 | (r>>8)<<16 | (g>>8)<<8 | b>>8
+}
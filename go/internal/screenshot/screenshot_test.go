@@ -0,0 +1,93 @@
+package screenshot_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"chip-8/internal/screenshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPixels = []byte{
+	0, 1, 0,
+	3, 0, 2,
+}
+
+func TestImage(t *testing.T) {
+	img := screenshot.Image(testPixels, 3, 2)
+
+	assert.Equal(t, 3, img.Bounds().Dx())
+	assert.Equal(t, 2, img.Bounds().Dy())
+	assert.Equal(t, screenshot.Palette[0], img.At(0, 0))
+	assert.Equal(t, screenshot.Palette[1], img.At(1, 0))
+	assert.Equal(t, screenshot.Palette[3], img.At(0, 1))
+	assert.Equal(t, screenshot.Palette[2], img.At(2, 1))
+}
+
+func TestWritePNG(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, screenshot.WritePNG(buf, testPixels, 3, 2))
+
+	img, err := png.Decode(buf)
+	require.NoError(t, err)
+	r, g, b, _ := img.At(1, 0).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+	r, g, b, _ = img.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{0, 0, 0}, []uint32{r, g, b})
+}
+
+func TestWritePNGPalette(t *testing.T) {
+	palette := append(color.Palette(nil), screenshot.Palette...)
+	palette[1] = color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, screenshot.WritePNGPalette(buf, testPixels, 3, 2, palette))
+
+	img, err := png.Decode(buf)
+	require.NoError(t, err)
+	r, g, b, _ := img.At(1, 0).RGBA()
+	assert.Equal(t, []uint32{0x1010, 0x2020, 0x3030}, []uint32{r, g, b})
+	assert.Equal(t, color.Gray{Y: 0xff}, screenshot.Palette[1], "the default palette shouldn't change")
+}
+
+func TestWritePBM(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, screenshot.WritePBM(buf, testPixels, 3, 2))
+
+	assert.Equal(t, "P1\n3 2\n0 1 0\n1 0 1\n", buf.String())
+}
+
+func TestDiff(t *testing.T) {
+	want := screenshot.Image([]byte{
+		1, 1, 0,
+		0, 2, 0,
+	}, 3, 2)
+	got := screenshot.Image([]byte{
+		1, 0, 1,
+		0, 3, 0,
+	}, 3, 2)
+
+	img, differ := screenshot.Diff(want, got)
+	assert.Equal(t, 3, differ)
+	assert.Equal(t, 3*screenshot.DiffScale, img.Bounds().Dx())
+	assert.Equal(t, 2*screenshot.DiffScale, img.Bounds().Dy())
+
+	at := func(x, y int) color.RGBA {
+		return img.RGBAAt(x*screenshot.DiffScale, y*screenshot.DiffScale)
+	}
+	assert.Equal(t, color.RGBA{R: 0x60, G: 0x60, B: 0x60, A: 0xff}, at(0, 0), "matching lit pixel")
+	assert.Equal(t, color.RGBA{A: 0xff}, at(0, 1), "matching unlit pixel")
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, at(1, 0), "missing pixel")
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, at(2, 0), "extra pixel")
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, A: 0xff}, at(1, 1), "wrong colour")
+
+	_, differ = screenshot.Diff(want, want)
+	assert.Zero(t, differ)
+
+	_, differ = screenshot.Diff(want, screenshot.Image([]byte{1, 1, 0, 0, 2, 0, 0, 1, 0}, 3, 3))
+	assert.Equal(t, 1, differ, "extra rows should be compared with unlit pixels")
+}
@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// keypadOrder is the layout of the original COSMAC VIP hex keypad, read left to
+// right and top to bottom.
+var keypadOrder = [16]byte{
+	0x1, 0x2, 0x3, 0xc,
+	0x4, 0x5, 0x6, 0xd,
+	0x7, 0x8, 0x9, 0xe,
+	0xa, 0x0, 0xb, 0xf,
+}
+
+// DefaultLayout maps the left hand side of a QWERTY keyboard onto the keypad.
+const DefaultLayout = "1234qwerasdfzxcv"
+
+// Bytes the Terminal delivers for the arrow keys, which terminals send as
+// escape sequences. They are outside ASCII so they never clash with a layout.
+const (
+	KeyUp byte = 0x80 + iota
+	KeyDown
+	KeyRight
+	KeyLeft
+)
+
+// controls are the keys standing in for the controls of a game pad, by the
+// names the chip-8-database gives them.
+var controls = map[string]byte{
+	"up":    KeyUp,
+	"down":  KeyDown,
+	"left":  KeyLeft,
+	"right": KeyRight,
+	"a":     ' ',
+	"b":     '\r',
+}
+
+// Keymap maps typed characters to CHIP-8 keypad keys.
+type Keymap map[byte]byte
+
+// ParseKeymap builds a Keymap from a 16 character layout string, where each
+// character is the key that stands in for the keypad key in the same position
+// of the 4x4 hex keypad (1 2 3 C / 4 5 6 D / 7 8 9 E / A 0 B F).
+func ParseKeymap(layout string) (Keymap, error) {
+	if len(layout) != len(keypadOrder) || utf8.RuneCountInString(layout) != len(layout) {
+		return nil, errors.Errorf("keymap layout %q must be exactly 16 ASCII characters", layout)
+	}
+
+	keymap := make(Keymap, len(keypadOrder))
+	for i := 0; i < len(layout); i++ {
+		if _, ok := keymap[layout[i]]; ok {
+			return nil, errors.Errorf("keymap layout %q maps %q more than once", layout, layout[i])
+		}
+		keymap[layout[i]] = keypadOrder[i]
+	}
+	return keymap, nil
+}
+
+// Key returns the keypad key mapped to the typed character. Letters are
+// matched case-insensitively so caps lock does not break input.
+func (k Keymap) Key(b byte) (byte, bool) {
+	if key, ok := k[b]; ok {
+		return key, true
+	}
+	if b >= 'A' && b <= 'Z' {
+		key, ok := k[b+'a'-'A']
+		return key, ok
+	}
+	return 0, false
+}
+
+// BindControls maps the arrow keys, Space, and Enter onto the keypad keys a
+// program uses for up, down, left, right, a, and b, as given in keys. Other
+// controls are ignored.
+func (k Keymap) BindControls(keys map[string]int) {
+	for name, key := range keys {
+		if b, ok := controls[name]; ok {
+			k[b] = byte(key) & 0xf
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package terminal_test
+
+import (
+	"testing"
+
+	"chip-8/internal/terminal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeymap(t *testing.T) {
+	keymap, err := terminal.ParseKeymap(terminal.DefaultLayout)
+	require.NoError(t, err)
+
+	type testCase struct {
+		typed       byte
+		expectedKey byte
+	}
+	cases := []testCase{
+		{typed: '1', expectedKey: 0x1},
+		{typed: '4', expectedKey: 0xc},
+		{typed: 'w', expectedKey: 0x5},
+		{typed: 'W', expectedKey: 0x5},
+		{typed: 'f', expectedKey: 0xe},
+		{typed: 'z', expectedKey: 0xa},
+		{typed: 'x', expectedKey: 0x0},
+		{typed: 'v', expectedKey: 0xf},
+	}
+	for _, c := range cases {
+		key, ok := keymap.Key(c.typed)
+		assert.True(t, ok, "key %q", c.typed)
+		assert.Equal(t, c.expectedKey, key, "key %q", c.typed)
+	}
+
+	_, ok := keymap.Key('p')
+	assert.False(t, ok)
+}
+
+func TestParseKeymap_Invalid(t *testing.T) {
+	layouts := []string{
+		"",
+		"1234qwerasdfzxc",
+		"1234qwerasdfzxcvb",
+		"1234qwerasdfzxcc",
+	}
+	for _, layout := range layouts {
+		_, err := terminal.ParseKeymap(layout)
+		assert.Error(t, err, "layout %q", layout)
+	}
+}
+
+func TestKeymap_BindControls(t *testing.T) {
+	keymap, err := terminal.ParseKeymap(terminal.DefaultLayout)
+	require.NoError(t, err)
+
+	keymap.BindControls(map[string]int{"up": 2, "left": 4, "a": 6, "player2Up": 0xc})
+
+	type testCase struct {
+		typed       byte
+		expectedKey byte
+	}
+	cases := []testCase{
+		{typed: terminal.KeyUp, expectedKey: 0x2},
+		{typed: terminal.KeyLeft, expectedKey: 0x4},
+		{typed: ' ', expectedKey: 0x6},
+		{typed: 'w', expectedKey: 0x5},
+	}
+	for _, c := range cases {
+		key, ok := keymap.Key(c.typed)
+		assert.True(t, ok, "key %q", c.typed)
+		assert.Equal(t, c.expectedKey, key, "key %q", c.typed)
+	}
+
+	_, ok := keymap.Key(terminal.KeyDown)
+	assert.False(t, ok)
+}
@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Half-block characters used to pack two rows of pixels into one line of text,
+// indexed by (top << 1) | bottom.
+var halfBlocks = [4]rune{' ', '▄', '▀', '█'}
+
+// Render writes the framebuffer to w as text, using Unicode half-block
+// characters so each line of output covers two rows of pixels. Lines are
+// terminated with "\r\n" so output is correct in raw mode.
+func Render(w io.Writer, pixels []byte, width, height int) error {
+	if len(pixels) != width*height {
+		return errors.Errorf("framebuffer has %d pixels, expected %dx%d", len(pixels), width, height)
+	}
+
+	bw := bufio.NewWriter(w)
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			var top, bottom int
+			if pixels[y*width+x] != 0 {
+				top = 1
+			}
+			if y+1 < height && pixels[(y+1)*width+x] != 0 {
+				bottom = 1
+			}
+			_, _ = bw.WriteRune(halfBlocks[top<<1|bottom])
+		}
+		_, _ = bw.WriteString("\r\n")
+	}
+	return errors.WithStack(bw.Flush())
+}
+
+// RenderPalette writes the framebuffer to w like Render, but in 24-bit colour,
+// colouring each pixel by indexing the palette with its low four bits. Each
+// character is an upper half block whose foreground is the top pixel and
+// background the bottom one.
+func RenderPalette(w io.Writer, pixels []byte, width, height int, palette color.Palette) error {
+	if len(pixels) != width*height {
+		return errors.Errorf("framebuffer has %d pixels, expected %dx%d", len(pixels), width, height)
+	}
+
+	colour := func(p byte) color.Color {
+		if int(p&0xf) < len(palette) {
+			return palette[p&0xf]
+		}
+		return palette[len(palette)-1]
+	}
+
+	bw := bufio.NewWriter(w)
+	for y := 0; y < height; y += 2 {
+		var fg, bg color.Color
+		for x := 0; x < width; x++ {
+			top, bottom := colour(pixels[y*width+x]), colour(0)
+			if y+1 < height {
+				bottom = colour(pixels[(y+1)*width+x])
+			}
+			// only changes of colour are written, to keep frames small
+			if top != fg {
+				fg = top
+				r, g, b, _ := fg.RGBA()
+				fmt.Fprintf(bw, "\x1b[38;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+			}
+			if bottom != bg {
+				bg = bottom
+				r, g, b, _ := bg.RGBA()
+				fmt.Fprintf(bw, "\x1b[48;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+			}
+			_, _ = bw.WriteRune(halfBlocks[2])
+		}
+		_, _ = bw.WriteString("\x1b[0m\r\n")
+	}
+	return errors.WithStack(bw.Flush())
+}
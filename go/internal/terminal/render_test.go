@@ -0,0 +1,51 @@
+package terminal_test
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"chip-8/internal/terminal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	pixels := []byte{
+		1, 0, 1, 0,
+		1, 1, 0, 0,
+		0, 1, 0, 0,
+	}
+	out := bytes.NewBuffer(nil)
+
+	err := terminal.Render(out, pixels, 4, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, "█▄▀ \r\n ▀  \r\n", out.String())
+}
+
+func TestRender_SizeMismatch(t *testing.T) {
+	err := terminal.Render(bytes.NewBuffer(nil), make([]byte, 10), 4, 3)
+	assert.Error(t, err)
+}
+
+func TestRenderPalette(t *testing.T) {
+	pixels := []byte{
+		1, 0,
+		1, 1,
+		0, 2,
+	}
+	palette := color.Palette{
+		color.RGBA{A: 0xff},
+		color.RGBA{R: 0xff, A: 0xff},
+		color.RGBA{B: 0xff, A: 0xff},
+	}
+	out := bytes.NewBuffer(nil)
+
+	err := terminal.RenderPalette(out, pixels, 2, 3, palette)
+	require.NoError(t, err)
+
+	assert.Equal(t, "\x1b[38;2;255;0;0m\x1b[48;2;255;0;0m▀\x1b[38;2;0;0;0m▀\x1b[0m\r\n"+
+		"\x1b[38;2;0;0;0m\x1b[48;2;0;0;0m▀\x1b[38;2;0;0;255m▀\x1b[0m\r\n", out.String())
+}
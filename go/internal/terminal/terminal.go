@@ -0,0 +1,157 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	clearScreen = "\x1b[2J"
+	cursorHome  = "\x1b[H"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	clearLine   = "\x1b[K"
+	bell        = "\a"
+
+	escape = 0x1b
+)
+
+// Terminal is an interactive terminal window that frames can be rendered to
+// and key presses read from.
+type Terminal struct {
+	in  *os.File
+	out *bufio.Writer
+
+	sttyState string
+	keys      chan byte
+
+	// palette colours the frames drawn, which are drawn in monochrome when it
+	// is nil.
+	palette color.Palette
+
+	// width and height are the dimensions of the last frame drawn, so the
+	// screen can be cleared when they change.
+	width, height int
+	// status is the line of text shown below the frame.
+	status string
+}
+
+// Open puts the terminal attached to stdin into raw mode and starts reading
+// key presses from it. Close must be called to restore the terminal.
+func Open() (*Terminal, error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, errors.Wrap(err, "stdin is not a terminal")
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, errors.Wrap(err, "failed to put terminal into raw mode")
+	}
+
+	t := &Terminal{
+		in:        os.Stdin,
+		out:       bufio.NewWriter(os.Stdout),
+		sttyState: strings.TrimSpace(state),
+		keys:      make(chan byte, 64),
+	}
+	go t.readKeys()
+
+	_, _ = t.out.WriteString(clearScreen + hideCursor)
+	return t, t.out.Flush()
+}
+
+// Close restores the terminal to the state it was in before Open was called.
+func (t *Terminal) Close() error {
+	_, _ = t.out.WriteString(showCursor + "\r\n")
+	_ = t.out.Flush()
+	_, err := stty(t.sttyState)
+	return errors.WithStack(err)
+}
+
+// Keys returns a channel that receives each byte typed into the terminal.
+func (t *Terminal) Keys() <-chan byte {
+	return t.keys
+}
+
+// Draw renders the framebuffer to the top left corner of the terminal,
+// clearing it first when the size of the framebuffer changes.
+func (t *Terminal) Draw(pixels []byte, width, height int) error {
+	resized := width != t.width || height != t.height
+	if resized {
+		t.width, t.height = width, height
+		_, _ = t.out.WriteString(clearScreen)
+	}
+	_, _ = t.out.WriteString(cursorHome)
+	render := Render
+	if t.palette != nil {
+		render = func(w io.Writer, pixels []byte, width, height int) error {
+			return RenderPalette(w, pixels, width, height, t.palette)
+		}
+	}
+	if err := render(t.out, pixels, width, height); err != nil {
+		return err
+	}
+	if resized {
+		t.writeStatus()
+	}
+	return errors.WithStack(t.out.Flush())
+}
+
+// Status shows a line of text below the frame, replacing the last one.
+func (t *Terminal) Status(text string) error {
+	t.status = text
+	t.writeStatus()
+	return errors.WithStack(t.out.Flush())
+}
+
+// writeStatus writes the status line to the line below the frame, which takes
+// a line for every two rows of pixels.
+func (t *Terminal) writeStatus() {
+	_, _ = fmt.Fprintf(t.out, "\x1b[%d;1H%s%s", (t.height+1)/2+1, t.status, clearLine)
+}
+
+// SetPalette sets the colours frames are drawn in, as with RenderPalette. A nil
+// palette draws them in monochrome with Render.
+func (t *Terminal) SetPalette(palette color.Palette) {
+	t.palette = palette
+}
+
+// Beep rings the terminal bell.
+func (t *Terminal) Beep() error {
+	_, _ = t.out.WriteString(bell)
+	return errors.WithStack(t.out.Flush())
+}
+
+func (t *Terminal) readKeys() {
+	buf := make([]byte, 16)
+	for {
+		n, err := t.in.Read(buf)
+		if err != nil {
+			close(t.keys)
+			return
+		}
+		for i := 0; i < n; i++ {
+			b := buf[i]
+			// arrow keys are sent as ESC [ followed by A to D
+			if b == escape && i+2 < n && buf[i+1] == '[' && buf[i+2] >= 'A' && buf[i+2] <= 'D' {
+				b = KeyUp + buf[i+2] - 'A'
+				i += 2
+			}
+			t.keys <- b
+		}
+	}
+}
+
+// stty runs stty against the terminal attached to stdin.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
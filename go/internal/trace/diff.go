@@ -0,0 +1,58 @@
+package trace
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Divergence is where two traces first differ.
+type Divergence struct {
+	// Line is the number of the first line that differs, counting from 1.
+	Line int
+	// Context holds the lines the traces have in common just before Line.
+	Context []string
+	// A and B are the differing lines of each trace. When one trace ends
+	// before the other its line is empty and its End flag set.
+	A, B       string
+	AEnd, BEnd bool
+}
+
+// Diff compares two traces written in the same format line by line, and
+// returns where they first differ, or nil if they are the same. Up to
+// context of the matching lines before the difference are kept.
+func Diff(a, b io.Reader, context int) (*Divergence, error) {
+	sa, sb := bufio.NewScanner(a), bufio.NewScanner(b)
+	var recent []string
+	for line := 1; ; line++ {
+		okA, okB := sa.Scan(), sb.Scan()
+		if err := sa.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read first trace")
+		}
+		if err := sb.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read second trace")
+		}
+		if !okA && !okB {
+			return nil, nil
+		}
+		if okA && okB && sa.Text() == sb.Text() {
+			if context > 0 {
+				if len(recent) == context {
+					recent = recent[1:]
+				}
+				recent = append(recent, sa.Text())
+			}
+			continue
+		}
+
+		d := &Divergence{Line: line, Context: recent, AEnd: !okA, BEnd: !okB}
+		if okA {
+			d.A = sa.Text()
+		}
+		if okB {
+			d.B = sb.Text()
+		}
+		return d, nil
+	}
+}
@@ -0,0 +1,186 @@
+// Package trace writes execution traces of the instructions a CPU executes,
+// and compares traces of two runs.
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+// Format is the layout of a trace file.
+type Format int
+
+const (
+	// FormatText writes one line per instruction with its address, opcode,
+	// disassembly, and register changes, such as:
+	//
+	//	0200 6005 MVI        V0,#$05  V0:00->05
+	FormatText Format = iota
+	// FormatJSON writes one JSON object per line.
+	FormatJSON
+)
+
+// formats maps the names of the formats to their values.
+var formats = map[string]Format{
+	"text": FormatText,
+	"json": FormatJSON,
+}
+
+// ParseFormat returns the format with the name text or json.
+func ParseFormat(name string) (Format, error) {
+	format, ok := formats[name]
+	if !ok {
+		return 0, errors.Errorf("unknown trace format %q, must be text or json", name)
+	}
+	return format, nil
+}
+
+// Filter selects the instructions written to a trace.
+type Filter struct {
+	// Start and End are the first and last addresses of the instructions
+	// traced. End is ignored when it is zero.
+	Start uint16
+	End   uint16
+	// Classes is a bitmask of the opcode classes traced, where bit n
+	// selects the opcodes whose first nibble is n. Every class is traced
+	// when it is zero.
+	Classes uint16
+}
+
+// Match reports whether the instruction passes the filter.
+func (f Filter) Match(e cpu.TraceEvent) bool {
+	if e.PC < f.Start || (f.End != 0 && e.PC > f.End) {
+		return false
+	}
+	return f.Classes == 0 || f.Classes&(1<<(uint16(e.Opcode)>>12)) != 0
+}
+
+// ParseRange parses an inclusive range of hex addresses, such as 200-2ff,
+// for Filter.Start and Filter.End.
+func ParseRange(s string) (start, end uint16, err error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("invalid address range %q, must be <start>-<end>", s)
+	}
+	var addrs [2]uint16
+	for i, part := range parts {
+		addr, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(part), "0x"), 16, 16)
+		if err != nil {
+			return 0, 0, errors.Errorf("invalid address %q in range %q", part, s)
+		}
+		addrs[i] = uint16(addr)
+	}
+	if addrs[0] > addrs[1] {
+		return 0, 0, errors.Errorf("invalid address range %q, start is after end", s)
+	}
+	return addrs[0], addrs[1], nil
+}
+
+// ParseClasses parses a comma separated list of opcode classes, each the
+// first hex digit of the opcodes in it such as 8 or D, for Filter.Classes.
+func ParseClasses(s string) (uint16, error) {
+	var classes uint16
+	for _, class := range strings.Split(s, ",") {
+		class = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(class)), "xxx")
+		n, err := strconv.ParseUint(class, 16, 4)
+		if err != nil {
+			return 0, errors.Errorf("invalid opcode class %q, must be a hex digit such as 8 or D", class)
+		}
+		classes |= 1 << n
+	}
+	return classes, nil
+}
+
+// jsonEvent is the layout of a line of a JSON trace.
+type jsonEvent struct {
+	PC          uint16       `json:"pc"`
+	Opcode      uint16       `json:"opcode"`
+	Instruction string       `json:"instruction"`
+	Changes     []jsonChange `json:"changes,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+type jsonChange struct {
+	Register string `json:"register"`
+	Old      uint16 `json:"old"`
+	New      uint16 `json:"new"`
+}
+
+// Writer is a cpu.Tracer that writes the instructions passing its filter.
+type Writer struct {
+	w      *bufio.Writer
+	format Format
+	filter Filter
+	// err is the first error writing the trace, after which nothing more is
+	// written.
+	err error
+}
+
+// NewWriter constructs a Writer that writes a trace in the format to w.
+func NewWriter(w io.Writer, format Format, filter Filter) *Writer {
+	return &Writer{
+		w:      bufio.NewWriter(w),
+		format: format,
+		filter: filter,
+	}
+}
+
+// Trace writes the instruction if it passes the filter.
+func (w *Writer) Trace(e cpu.TraceEvent) {
+	if w.err != nil || !w.filter.Match(e) {
+		return
+	}
+	if w.format == FormatJSON {
+		w.err = w.writeJSON(e)
+		return
+	}
+	w.err = w.writeText(e)
+}
+
+// Flush writes any buffered trace and returns the first error writing it.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return errors.Wrap(w.w.Flush(), "failed to write trace")
+}
+
+func (w *Writer) writeText(e cpu.TraceEvent) error {
+	line := fmt.Sprintf("%04x %04x %-24s", e.PC, uint16(e.Opcode), e.Instruction)
+	for _, change := range e.Changes {
+		line += " " + change.String()
+	}
+	if e.Err != nil {
+		line += " error: " + e.Err.Error()
+	}
+	_, err := w.w.WriteString(strings.TrimRight(line, " ") + "\n")
+	return errors.Wrap(err, "failed to write trace")
+}
+
+func (w *Writer) writeJSON(e cpu.TraceEvent) error {
+	je := jsonEvent{
+		PC:          e.PC,
+		Opcode:      uint16(e.Opcode),
+		Instruction: e.Instruction,
+	}
+	for _, change := range e.Changes {
+		je.Changes = append(je.Changes, jsonChange{Register: change.Register, Old: change.Old, New: change.New})
+	}
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+	data, err := json.Marshal(je)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = w.w.Write(append(data, '\n'))
+	return errors.Wrap(err, "failed to write trace")
+}
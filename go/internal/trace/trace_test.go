@@ -0,0 +1,149 @@
+package trace_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/trace"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvents = []cpu.TraceEvent{
+	{PC: 0x200, Opcode: 0x6005, Instruction: "MVI        V0,#$05", Changes: []cpu.RegisterChange{{Register: "V0", New: 5}}},
+	{PC: 0x202, Opcode: 0xa300, Instruction: "MVI        I,#$300", Changes: []cpu.RegisterChange{{Register: "I", New: 0x300}}},
+	{PC: 0x300, Opcode: 0x00ee, Instruction: "RTS", Err: errors.New("stack underflow")},
+}
+
+func write(t *testing.T, format trace.Format, filter trace.Filter) string {
+	buf := bytes.NewBuffer(nil)
+	w := trace.NewWriter(buf, format, filter)
+	for _, e := range testEvents {
+		w.Trace(e)
+	}
+	require.NoError(t, w.Flush())
+	return buf.String()
+}
+
+func TestWriter(t *testing.T) {
+	type testCase struct {
+		label  string
+		format trace.Format
+		filter trace.Filter
+		want   string
+	}
+
+	cases := []testCase{
+		{
+			label:  "text",
+			format: trace.FormatText,
+			want: "0200 6005 MVI        V0,#$05       V0:00->05\n" +
+				"0202 a300 MVI        I,#$300       I:0000->0300\n" +
+				"0300 00ee RTS                      error: stack underflow\n",
+		},
+		{
+			label:  "json",
+			format: trace.FormatJSON,
+			want: `{"pc":512,"opcode":24581,"instruction":"MVI        V0,#$05","changes":[{"register":"V0","old":0,"new":5}]}` + "\n" +
+				`{"pc":514,"opcode":41728,"instruction":"MVI        I,#$300","changes":[{"register":"I","old":0,"new":768}]}` + "\n" +
+				`{"pc":768,"opcode":238,"instruction":"RTS","error":"stack underflow"}` + "\n",
+		},
+		{
+			label:  "address range",
+			format: trace.FormatText,
+			filter: trace.Filter{Start: 0x202, End: 0x2ff},
+			want:   "0202 a300 MVI        I,#$300       I:0000->0300\n",
+		},
+		{
+			label:  "address from",
+			format: trace.FormatText,
+			filter: trace.Filter{Start: 0x300},
+			want:   "0300 00ee RTS                      error: stack underflow\n",
+		},
+		{
+			label:  "opcode classes",
+			format: trace.FormatText,
+			filter: trace.Filter{Classes: 1<<0x6 | 1<<0x0},
+			want: "0200 6005 MVI        V0,#$05       V0:00->05\n" +
+				"0300 00ee RTS                      error: stack underflow\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			assert.Equal(t, tc.want, write(t, tc.format, tc.filter))
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := trace.ParseFormat("json")
+	require.NoError(t, err)
+	assert.Equal(t, trace.FormatJSON, format)
+
+	_, err = trace.ParseFormat("xml")
+	assert.EqualError(t, err, `unknown trace format "xml", must be text or json`)
+}
+
+func TestParseRange(t *testing.T) {
+	start, end, err := trace.ParseRange("200-0x2FF")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x200), start)
+	assert.Equal(t, uint16(0x2ff), end)
+
+	for _, s := range []string{"200", "200-zz", "300-200"} {
+		_, _, err := trace.ParseRange(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	classes, err := trace.ParseClasses("8, D,fxxx")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1<<0x8|1<<0xd|1<<0xf), classes)
+
+	_, err = trace.ParseClasses("8,g")
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	type testCase struct {
+		label string
+		a, b  string
+		want  *trace.Divergence
+	}
+
+	cases := []testCase{
+		{label: "same", a: "one\ntwo\n", b: "one\ntwo\n"},
+		{
+			label: "differ",
+			a:     "one\ntwo\nthree\nfour\n",
+			b:     "one\ntwo\nthree\nFOUR\nfive\n",
+			want:  &trace.Divergence{Line: 4, Context: []string{"two", "three"}, A: "four", B: "FOUR"},
+		},
+		{
+			label: "first ends",
+			a:     "one\n",
+			b:     "one\ntwo\n",
+			want:  &trace.Divergence{Line: 2, Context: []string{"one"}, AEnd: true, B: "two"},
+		},
+		{
+			label: "second ends",
+			a:     "one\n",
+			b:     "",
+			want:  &trace.Divergence{Line: 1, A: "one", BEnd: true},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.label, func(t *testing.T) {
+			d, err := trace.Diff(strings.NewReader(tc.a), strings.NewReader(tc.b), 2)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, d)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package emulator is the public API for embedding the CHIP-8 emulator in
+// other Go programs.
+//
+// A Machine is created with New, given a program with LoadROM, and then
+// driven either an instruction at a time with Step or a 60 Hz frame at a time
+// with RunFrame. Between steps the display can be read with Framebuffer, the
+// CPU state inspected with Registers, and input fed in with SetKey.
+//
+// # Errors
+//
+// Apart from I/O errors reading the ROM, every error returned by this
+// package either is, or wraps, one of the exported Err values, so callers can
+// match on them with errors.Cause from github.com/pkg/errors:
+//
+//	ErrNoROM          Step or RunFrame was called before a ROM was loaded.
+//	ErrROMTooLarge    LoadROM was given more bytes than fit in memory.
+//	ErrInvalidKey     SetKey was given a key outside 0x0-0xF.
+//
+// Restore returns one of the snapshot errors:
+//
+//	ErrInvalidSnapshot   The data isn't a snapshot.
+//	ErrSnapshotVersion   The snapshot was written in an unsupported version.
+//	ErrSnapshotChecksum  The snapshot has been corrupted.
+//
+// When an instruction fails, Step and RunFrame return an *ExecutionError
+// holding the address and opcode of the instruction, and the machine halts
+// until the next LoadROM. Its cause is one of:
+//
+//	ErrUnknownOpcode      The opcode is not a CHIP-8 instruction.
+//	ErrStackOverflow      A subroutine call was made with a full stack.
+//	ErrStackUnderflow     A return was made with an empty stack.
+//	ErrMemoryOutOfBounds  An instruction addressed memory past the end of RAM.
+//	ErrRPLOutOfBounds     Fx75 or Fx85 used more than 8 RPL flags outside XO-CHIP.
+//
+// Errors from reading the ROM are not Err values. They are returned wrapped
+// but otherwise untouched, so errors.Cause gives the error of the io.Reader.
+package emulator
@@ -0,0 +1,277 @@
+package emulator
+
+import (
+	"io"
+	"io/ioutil"
+
+	"chip-8/internal/cpu"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ScreenWidth is the width of the low resolution display in pixels.
+	ScreenWidth = cpu.ScreenWidth
+	// ScreenHeight is the height of the low resolution display in pixels.
+	ScreenHeight = cpu.ScreenHeight
+	// HiResWidth is the width of the SUPER-CHIP high resolution display in
+	// pixels.
+	HiResWidth = cpu.HiResWidth
+	// HiResHeight is the height of the SUPER-CHIP high resolution display in
+	// pixels.
+	HiResHeight = cpu.HiResHeight
+
+	// DefaultInstructionsPerFrame is the number of instructions RunFrame
+	// executes when Config doesn't set one, roughly 600 instructions a second.
+	DefaultInstructionsPerFrame = 10
+)
+
+var (
+	// ErrNoROM is returned when the machine is run before a ROM is loaded.
+	ErrNoROM = errors.New("no ROM loaded")
+	// ErrROMTooLarge is returned when a ROM does not fit in memory.
+	ErrROMTooLarge = cpu.ErrProgramTooLarge
+	// ErrInvalidKey is returned when a key outside the 16 key keypad is used.
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrUnknownOpcode is the cause of an ExecutionError for an opcode that
+	// isn't a valid instruction.
+	ErrUnknownOpcode = cpu.ErrUnknownOpcode
+	// ErrStackOverflow is the cause of an ExecutionError for a subroutine
+	// call made with a full stack.
+	ErrStackOverflow = cpu.ErrStackOverflow
+	// ErrStackUnderflow is the cause of an ExecutionError for a return made
+	// with an empty stack.
+	ErrStackUnderflow = cpu.ErrStackUnderflow
+	// ErrMemoryOutOfBounds is the cause of an ExecutionError for an
+	// instruction that addresses memory past the end of RAM.
+	ErrMemoryOutOfBounds = cpu.ErrMemoryOutOfBounds
+	// ErrRPLOutOfBounds is the cause of an ExecutionError for an Fx75 or
+	// Fx85 that uses more than the 8 SUPER-CHIP RPL flags outside XO-CHIP
+	// mode.
+	ErrRPLOutOfBounds = cpu.ErrRPLOutOfBounds
+
+	// ErrInvalidSnapshot is returned when restoring data that isn't a
+	// snapshot.
+	ErrInvalidSnapshot = cpu.ErrInvalidSnapshot
+	// ErrSnapshotVersion is returned when restoring a snapshot written by an
+	// incompatible version.
+	ErrSnapshotVersion = cpu.ErrSnapshotVersion
+	// ErrSnapshotChecksum is returned when restoring a corrupted snapshot.
+	ErrSnapshotChecksum = cpu.ErrSnapshotChecksum
+)
+
+// ExecutionError is returned by Step and RunFrame when an instruction fails.
+// It records the address and opcode of the failing instruction, and its Err
+// field holds one of the Err values describing why it failed.
+type ExecutionError = cpu.ExecutionError
+
+// Config holds the settings for a Machine.
+type Config struct {
+	// InstructionsPerFrame is the number of instructions RunFrame executes
+	// between timer ticks. Defaults to DefaultInstructionsPerFrame.
+	InstructionsPerFrame int
+	// Quirks selects the interpreter behaviours the ROM relies on. The zero
+	// value follows Cowgod's technical reference.
+	Quirks Quirks
+	// XOCHIP turns on XO-CHIP mode, which extends memory to 64 KiB and
+	// enables the XO-CHIP instructions.
+	XOCHIP bool
+	// Random is the source of the numbers the CXKK instruction generates.
+	// It defaults to one seeded from the clock, so give a seeded generator
+	// such as rand.New(rand.NewSource(seed)) to make runs reproducible.
+	Random Random
+}
+
+// Random is a source of random numbers, which a *rand.Rand from math/rand
+// satisfies.
+type Random = cpu.Random
+
+// Quirks are the behaviours that differ between CHIP-8 interpreters.
+type Quirks = cpu.Quirks
+
+// The quirks of well known interpreters, for use in Config.
+var (
+	QuirksCOSMACVIP = cpu.QuirksCOSMACVIP
+	QuirksCHIP48    = cpu.QuirksCHIP48
+	QuirksSCHIP11   = cpu.QuirksSCHIP11
+	QuirksXOCHIP    = cpu.QuirksXOCHIP
+)
+
+// Registers is a snapshot of the CPU's registers, timers, and call stack.
+type Registers struct {
+	// V holds the general purpose registers V0 through VF.
+	V [16]byte
+	// I is the address register.
+	I uint16
+	// PC is the address of the next instruction to execute.
+	PC uint16
+	// Stack holds the subroutine return addresses, oldest first.
+	Stack []uint16
+	// Delay is the delay timer.
+	Delay byte
+	// Sound is the sound timer.
+	Sound byte
+}
+
+// Framebuffer is a copy of the display at a point in time.
+type Framebuffer struct {
+	Width  int
+	Height int
+	// Pixels holds one byte per pixel in row-major order, where a non-zero
+	// byte is a lit pixel.
+	Pixels []byte
+}
+
+// Pixel reports whether the pixel at (x, y) is lit. Coordinates outside the
+// framebuffer are never lit.
+func (f Framebuffer) Pixel(x, y int) bool {
+	if x < 0 || y < 0 || x >= f.Width || y >= f.Height {
+		return false
+	}
+	return f.Pixels[y*f.Width+x] != 0
+}
+
+// Machine is an embeddable CHIP-8 machine.
+type Machine struct {
+	cpu    *cpu.CPU
+	config Config
+	loaded bool
+}
+
+// New constructs a Machine with no ROM loaded.
+func New(config Config) *Machine {
+	if config.InstructionsPerFrame <= 0 {
+		config.InstructionsPerFrame = DefaultInstructionsPerFrame
+	}
+	return &Machine{
+		cpu:    cpu.NewCPU(),
+		config: config,
+	}
+}
+
+// LoadROM resets the machine and loads the program read from r into memory.
+func (m *Machine) LoadROM(r io.Reader) error {
+	program, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to read ROM")
+	}
+
+	c := cpu.NewCPU()
+	c.Quirks = m.config.Quirks
+	c.SetXOCHIP(m.config.XOCHIP)
+	if m.config.Random != nil {
+		c.SetRandom(m.config.Random)
+	}
+	if err := c.LoadProgram(program); err != nil {
+		return err
+	}
+
+	m.cpu = c
+	m.loaded = true
+	return nil
+}
+
+// Step executes a single instruction. If the instruction fails an
+// *ExecutionError is returned and the machine halts: every further Step or
+// RunFrame returns the same error until a ROM is loaded again.
+func (m *Machine) Step() error {
+	if !m.loaded {
+		return ErrNoROM
+	}
+	return m.cpu.Cycle()
+}
+
+// RunFrame executes one 60 Hz frame: the configured number of instructions
+// followed by a tick of the delay and sound timers.
+func (m *Machine) RunFrame() error {
+	for i := 0; i < m.config.InstructionsPerFrame; i++ {
+		if err := m.Step(); err != nil {
+			return err
+		}
+	}
+	m.cpu.TickTimers()
+	return nil
+}
+
+// Framebuffer returns a copy of the display, which is 64x32 pixels unless
+// the program has switched to SUPER-CHIP high resolution.
+func (m *Machine) Framebuffer() Framebuffer {
+	width, height := m.cpu.Resolution()
+	return Framebuffer{
+		Width:  width,
+		Height: height,
+		Pixels: m.cpu.Screen(),
+	}
+}
+
+// SetKey sets whether key k (0x0-0xF) of the hex keypad is held down.
+func (m *Machine) SetKey(k byte, down bool) error {
+	if k > 0xf {
+		return errors.Wrapf(ErrInvalidKey, "key %#x", k)
+	}
+	m.cpu.SetKey(k, down)
+	return nil
+}
+
+// Registers returns a copy of the CPU's registers.
+func (m *Machine) Registers() Registers {
+	r := m.cpu.Registers()
+	return Registers{
+		V:     r.V,
+		I:     r.I,
+		PC:    r.PC,
+		Stack: r.Stack,
+		Delay: r.Delay,
+		Sound: r.Sound,
+	}
+}
+
+// SoundActive reports whether the buzzer should be sounding.
+func (m *Machine) SoundActive() bool {
+	return m.cpu.SoundActive()
+}
+
+// Snapshot returns the entire state of the machine, including its memory,
+// display, and quirks, in a versioned binary format with a checksum.
+func (m *Machine) Snapshot() []byte {
+	return m.cpu.Snapshot()
+}
+
+// Restore replaces the state of the machine with a snapshot taken by
+// Snapshot, which also counts as loading a ROM. If the snapshot can't be
+// restored the machine is left untouched.
+func (m *Machine) Restore(snapshot []byte) error {
+	if err := m.cpu.Restore(snapshot); err != nil {
+		return err
+	}
+	m.loaded = true
+	return nil
+}
+
+// AudioPattern returns the XO-CHIP audio pattern, 128 bits played from the
+// most significant bit of the first byte while SoundActive, and the rate in
+// bits per second it is played at.
+func (m *Machine) AudioPattern() (pattern [16]byte, rate float64) {
+	return m.cpu.AudioPattern()
+}
+
+// Exited reports whether the program has exited with the SUPER-CHIP exit
+// instruction. Running an exited machine does nothing.
+func (m *Machine) Exited() bool {
+	return m.cpu.Exited()
+}
+
+// RPLFlags returns the SUPER-CHIP RPL user flags, which programs use to keep
+// data such as high scores between runs. Embedders that want the flags to
+// persist save them when the program exits and restore them with
+// SetRPLFlags after loading it again.
+func (m *Machine) RPLFlags() [16]byte {
+	return m.cpu.RPLFlags()
+}
+
+// SetRPLFlags sets the SUPER-CHIP RPL user flags. LoadROM resets them, so
+// they are set after the ROM is loaded.
+func (m *Machine) SetRPLFlags(flags [16]byte) {
+	m.cpu.SetRPLFlags(flags)
+}
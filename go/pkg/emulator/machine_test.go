@@ -0,0 +1,180 @@
+package emulator_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"chip-8/pkg/emulator"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drawDigitROM waits for a key press and draws a bar the width of the key's value.
+var drawDigitROM = []byte{
+	0xf0, 0x0a, // 0x200: WAITKEY    V0
+	0x61, 0x0a, // 0x202: MVI        V1,#$0a
+	0xf1, 0x15, // 0x204: MOV        DELAY,V1
+	0xa2, 0x0c, // 0x206: MVI        I,#$20c
+	0xd1, 0x11, // 0x208: SPRITE.    V1,V1,#$1
+	0x12, 0x0a, // 0x20a: JUMP       $20a
+	0xf0, //       0x20c: sprite data
+}
+
+func TestMachine(t *testing.T) {
+	m := emulator.New(emulator.Config{})
+	require.NoError(t, m.LoadROM(bytes.NewReader(drawDigitROM)))
+
+	require.NoError(t, m.Step())
+	assert.Equal(t, uint16(0x200), m.Registers().PC, "should wait for a key")
+
+	require.NoError(t, m.SetKey(0x7, true))
+	require.NoError(t, m.RunFrame())
+
+	regs := m.Registers()
+	assert.Equal(t, byte(0x7), regs.V[0])
+	assert.Equal(t, byte(0x0a), regs.V[1])
+	assert.Equal(t, byte(0x09), regs.Delay)
+	assert.Equal(t, uint16(0x20a), regs.PC)
+	assert.Empty(t, regs.Stack)
+
+	fb := m.Framebuffer()
+	assert.Equal(t, emulator.ScreenWidth, fb.Width)
+	assert.Equal(t, emulator.ScreenHeight, fb.Height)
+	for x := 10; x < 14; x++ {
+		assert.True(t, fb.Pixel(x, 10), "pixel (%d, 10)", x)
+	}
+	assert.False(t, fb.Pixel(14, 10))
+	assert.False(t, fb.Pixel(-1, 10))
+}
+
+func TestMachine_Errors(t *testing.T) {
+	m := emulator.New(emulator.Config{})
+
+	assert.Equal(t, emulator.ErrNoROM, errors.Cause(m.Step()))
+	assert.Equal(t, emulator.ErrNoROM, errors.Cause(m.RunFrame()))
+	assert.Equal(t, emulator.ErrInvalidKey, errors.Cause(m.SetKey(0x10, true)))
+
+	err := m.LoadROM(bytes.NewReader(make([]byte, 4096)))
+	assert.Equal(t, emulator.ErrROMTooLarge, errors.Cause(err))
+}
+
+func ExampleMachine() {
+	m := emulator.New(emulator.Config{InstructionsPerFrame: 12})
+	if err := m.LoadROM(bytes.NewReader(drawDigitROM)); err != nil {
+		panic(err)
+	}
+	if err := m.SetKey(0x3, true); err != nil {
+		panic(err)
+	}
+	if err := m.RunFrame(); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("V0=%#x PC=%#x\n", m.Registers().V[0], m.Registers().PC)
+	// Output: V0=0x3 PC=0x20a
+}
+
+func TestMachine_ExecutionError(t *testing.T) {
+	m := emulator.New(emulator.Config{})
+	require.NoError(t, m.LoadROM(bytes.NewReader([]byte{0x00, 0xee})))
+
+	err := m.RunFrame()
+	require.Error(t, err)
+	execErr, ok := err.(*emulator.ExecutionError)
+	require.True(t, ok, "expected *emulator.ExecutionError, got %T", err)
+	assert.Equal(t, uint16(0x200), execErr.PC)
+	assert.Equal(t, emulator.ErrStackUnderflow, errors.Cause(err))
+
+	assert.Equal(t, err, m.Step(), "halted machine should keep returning the fault")
+	assert.Equal(t, uint16(0x200), m.Registers().PC)
+}
+
+func TestMachine_Quirks(t *testing.T) {
+	// LD V0, $05; LD V1, $01; SHR V0, V1
+	shiftROM := []byte{0x60, 0x05, 0x61, 0x01, 0x80, 0x16}
+
+	m := emulator.New(emulator.Config{})
+	require.NoError(t, m.LoadROM(bytes.NewReader(shiftROM)))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Step())
+	}
+	assert.Equal(t, byte(0x02), m.Registers().V[0])
+
+	m = emulator.New(emulator.Config{Quirks: emulator.QuirksCOSMACVIP})
+	require.NoError(t, m.LoadROM(bytes.NewReader(shiftROM)))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Step())
+	}
+	assert.Equal(t, byte(0x00), m.Registers().V[0])
+}
+
+func TestMachine_Random(t *testing.T) {
+	// RND V0, $FF; RND V1, $FF; RND V2, $FF
+	randomROM := []byte{0xc0, 0xff, 0xc1, 0xff, 0xc2, 0xff}
+
+	run := func(seed int64) emulator.Registers {
+		m := emulator.New(emulator.Config{Random: rand.New(rand.NewSource(seed))})
+		require.NoError(t, m.LoadROM(bytes.NewReader(randomROM)))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, m.Step())
+		}
+		return m.Registers()
+	}
+
+	assert.Equal(t, run(1).V, run(1).V, "the same seed should generate the same numbers")
+	assert.NotEqual(t, run(1).V, run(2).V)
+}
+
+func TestMachine_SuperChip(t *testing.T) {
+	m := emulator.New(emulator.Config{})
+	require.NoError(t, m.LoadROM(bytes.NewReader([]byte{
+		0x00, 0xff, // HIRES
+		0x60, 0x2a, // MVI V0,#$2a
+		0xf0, 0x75, // MOVM RPL,V0-V0
+		0x00, 0xfd, // EXIT
+	})))
+	require.NoError(t, m.RunFrame())
+
+	fb := m.Framebuffer()
+	assert.Equal(t, emulator.HiResWidth, fb.Width)
+	assert.Equal(t, emulator.HiResHeight, fb.Height)
+	assert.Len(t, fb.Pixels, emulator.HiResWidth*emulator.HiResHeight)
+	assert.True(t, m.Exited())
+	assert.Equal(t, [16]byte{0x2a}, m.RPLFlags())
+}
+
+func TestMachine_XOCHIP(t *testing.T) {
+	longLoad := []byte{0xf0, 0x00, 0x12, 0x34}
+
+	m := emulator.New(emulator.Config{})
+	require.NoError(t, m.LoadROM(bytes.NewReader(longLoad)))
+	assert.Equal(t, emulator.ErrUnknownOpcode, errors.Cause(m.Step()))
+
+	m = emulator.New(emulator.Config{Quirks: emulator.QuirksXOCHIP, XOCHIP: true})
+	require.NoError(t, m.LoadROM(bytes.NewReader(longLoad)))
+	require.NoError(t, m.Step())
+	assert.Equal(t, uint16(0x1234), m.Registers().I)
+
+	require.NoError(t, m.LoadROM(bytes.NewReader(make([]byte, 0x8000))), "XO-CHIP ROMs can be larger than 4 KiB")
+}
+
+func TestMachine_SnapshotRestore(t *testing.T) {
+	m := emulator.New(emulator.Config{})
+	require.NoError(t, m.LoadROM(bytes.NewReader(drawDigitROM)))
+	require.NoError(t, m.RunFrame())
+	snapshot := m.Snapshot()
+
+	restored := emulator.New(emulator.Config{})
+	require.NoError(t, restored.Restore(snapshot))
+	assert.Equal(t, m.Registers(), restored.Registers())
+	assert.Equal(t, m.Framebuffer(), restored.Framebuffer())
+
+	require.NoError(t, restored.RunFrame(), "a restored machine counts as loaded")
+
+	snapshot[10] ^= 0xff
+	assert.Equal(t, emulator.ErrSnapshotChecksum, errors.Cause(restored.Restore(snapshot)))
+}
@@ -0,0 +1,160 @@
+package conformance_test
+
+import (
+	"encoding/json"
+	"flag"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"chip-8/internal/cpu"
+	"chip-8/internal/runner"
+	"chip-8/internal/screenshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden images")
+
+const (
+	romDir    = "../roms"
+	goldenDir = "../golden"
+	outputDir = "../output"
+
+	// seed seeds the random number generator so ROMs that use CXKK are
+	// reproducible.
+	seed = 1
+)
+
+// manifest is the layout of conformance.json.
+type manifest struct {
+	ROMs []romTest `json:"roms"`
+}
+
+// romTest describes how a conformance ROM is run.
+type romTest struct {
+	// File is the name of the ROM in the ROM directory.
+	File string `json:"file"`
+	// Frames is the number of 60 Hz frames to run the ROM for.
+	Frames int `json:"frames"`
+	// Speed is the number of instructions executed per second, by default
+	// runner.DefaultSpeed.
+	Speed int `json:"speed"`
+	// Profiles are the quirk profiles to run the ROM under, by default all
+	// of them.
+	Profiles []string `json:"profiles"`
+	// Keys are the hex keypad keys held down for the whole run, such as
+	// the key choosing a ROM's menu entry.
+	Keys []string `json:"keys"`
+	// Beeps is the number of times the buzzer is expected to sound, which
+	// isn't checked when it is nil.
+	Beeps *int `json:"beeps"`
+}
+
+// beepDisplay is a Display that discards frames, never has key presses, and
+// counts beeps.
+type beepDisplay struct {
+	beeps int
+}
+
+func (*beepDisplay) Draw([]byte, int, int) error { return nil }
+func (*beepDisplay) Keys() <-chan byte           { return nil }
+
+func (d *beepDisplay) Beep() error {
+	d.beeps++
+	return nil
+}
+
+func TestConformance(t *testing.T) {
+	data, err := ioutil.ReadFile("conformance.json")
+	require.NoError(t, err)
+	var m manifest
+	require.NoError(t, json.Unmarshal(data, &m))
+
+	for _, rt := range m.ROMs {
+		program, err := ioutil.ReadFile(filepath.Join(romDir, rt.File))
+		require.NoError(t, err)
+
+		profiles := rt.Profiles
+		if len(profiles) == 0 {
+			profiles = cpu.ProfileNames()
+		}
+		for _, profile := range profiles {
+			rt, profile := rt, profile
+			name := strings.TrimSuffix(rt.File, filepath.Ext(rt.File)) + "." + profile
+			t.Run(name, func(t *testing.T) {
+				got, beeps := run(t, rt, profile, program)
+				if rt.Beeps != nil {
+					assert.Equal(t, *rt.Beeps, beeps, "beeps")
+				}
+				golden := filepath.Join(goldenDir, name+".png")
+				if *update {
+					writePNG(t, golden, got)
+					return
+				}
+				compare(t, name, golden, got)
+			})
+		}
+	}
+}
+
+// run runs the ROM under the profile and returns a screenshot of the final
+// screen and the number of times the buzzer sounded.
+func run(t *testing.T, rt romTest, profile string, program []byte) (image.Image, int) {
+	quirks, ok := cpu.ProfileByName(profile)
+	require.True(t, ok, "unknown profile %q", profile)
+
+	c := cpu.NewCPU()
+	c.Quirks = quirks
+	c.SetXOCHIP(profile == "xochip")
+	c.SetRandom(rand.New(rand.NewSource(seed)))
+	require.NoError(t, c.LoadProgram(program))
+	for _, k := range rt.Keys {
+		key, err := strconv.ParseUint(k, 16, 4)
+		require.NoError(t, err, "invalid key %q", k)
+		c.SetKey(byte(key), true)
+	}
+
+	display := &beepDisplay{}
+	r := runner.New(c, display, runner.Config{Speed: rt.Speed})
+	require.NoError(t, r.Headless(rt.Frames, 0))
+
+	width, height := c.Resolution()
+	return screenshot.Image(c.Screen(), width, height), display.beeps
+}
+
+// compare fails the test if the screenshot differs from the golden image,
+// writing the screenshot and a diff image to the output directory.
+func compare(t *testing.T, name, golden string, got image.Image) {
+	f, err := os.Open(golden)
+	require.NoError(t, err, "missing golden image, run the tests with -update to create it")
+	want, err := png.Decode(f)
+	f.Close()
+	require.NoError(t, err)
+
+	diff, differ := screenshot.Diff(want, got)
+	if differ == 0 {
+		return
+	}
+	require.NoError(t, os.MkdirAll(outputDir, 0755))
+	gotFile := filepath.Join(outputDir, name+".png")
+	diffFile := filepath.Join(outputDir, name+".diff.png")
+	writePNG(t, gotFile, got)
+	writePNG(t, diffFile, diff)
+	t.Errorf("%d pixels differ from %s, see %s and %s (red is missing, green is extra)",
+		differ, golden, gotFile, diffFile)
+}
+
+func writePNG(t *testing.T, file string, img image.Image) {
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+}
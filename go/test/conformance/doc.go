@@ -0,0 +1,9 @@
+// Package conformance checks the emulator against conformance test ROMs.
+//
+// The ROMs listed in conformance.json are run headless from test/roms under
+// each quirk profile, and the final screen is compared to a golden image in
+// test/golden. The quirks, flags, keypad, and beep ROMs are written for the
+// suite, and their .asm sources are kept next to them. Run the tests with
+// -update to regenerate the golden images after a deliberate change, and
+// inspect the diff images written to test/output when they fail.
+package conformance
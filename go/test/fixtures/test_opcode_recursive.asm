@@ -0,0 +1,291 @@
+0200 12 62 JUMP       L262
+D202:
+0202 ea    db         $ea        ; ###.#.#.
+0203 ac    db         $ac        ; #.#.##..
+0204 aa    db         $aa        ; #.#.#.#.
+0205 ea    db         $ea        ; ###.#.#.
+D206:
+0206 ce    db         $ce        ; ##..###.
+0207 aa    db         $aa        ; #.#.#.#.
+0208 aa    db         $aa        ; #.#.#.#.
+0209 ae    db         $ae        ; #.#.###.
+D20a:
+020a e0    db         $e0        ; ###.....
+020b a0    db         $a0        ; #.#.....
+020c a0    db         $a0        ; #.#.....
+020d e0    db         $e0        ; ###.....
+D20e:
+020e c0    db         $c0        ; ##......
+020f 40    db         $40        ; .#......
+0210 40    db         $40        ; .#......
+0211 e0    db         $e0        ; ###.....
+D212:
+0212 e0    db         $e0        ; ###.....
+0213 20    db         $20        ; ..#.....
+0214 c0    db         $c0        ; ##......
+0215 e0    db         $e0        ; ###.....
+D216:
+0216 e0    db         $e0        ; ###.....
+0217 60    db         $60        ; .##.....
+0218 20    db         $20        ; ..#.....
+0219 e0    db         $e0        ; ###.....
+D21a:
+021a a0    db         $a0        ; #.#.....
+021b e0    db         $e0        ; ###.....
+021c 20    db         $20        ; ..#.....
+021d 20    db         $20        ; ..#.....
+D21e:
+021e 60    db         $60        ; .##.....
+021f 40    db         $40        ; .#......
+0220 20    db         $20        ; ..#.....
+0221 40    db         $40        ; .#......
+D222:
+0222 e0    db         $e0        ; ###.....
+0223 80    db         $80        ; #.......
+0224 e0    db         $e0        ; ###.....
+0225 e0    db         $e0        ; ###.....
+D226:
+0226 e0    db         $e0        ; ###.....
+0227 20    db         $20        ; ..#.....
+0228 20    db         $20        ; ..#.....
+0229 20    db         $20        ; ..#.....
+D22a:
+022a e0    db         $e0        ; ###.....
+022b e0    db         $e0        ; ###.....
+022c a0    db         $a0        ; #.#.....
+022d e0    db         $e0        ; ###.....
+D22e:
+022e e0    db         $e0        ; ###.....
+022f e0    db         $e0        ; ###.....
+0230 20    db         $20        ; ..#.....
+0231 e0    db         $e0        ; ###.....
+D232:
+0232 40    db         $40        ; .#......
+0233 a0    db         $a0        ; #.#.....
+0234 e0    db         $e0        ; ###.....
+0235 a0    db         $a0        ; #.#.....
+D236:
+0236 e0    db         $e0        ; ###.....
+0237 c0    db         $c0        ; ##......
+0238 80    db         $80        ; #.......
+0239 e0    db         $e0        ; ###.....
+D23a:
+023a e0    db         $e0        ; ###.....
+023b 80    db         $80        ; #.......
+023c c0    db         $c0        ; ##......
+023d 80    db         $80        ; #.......
+D23e:
+023e a0    db         $a0        ; #.#.....
+023f 40    db         $40        ; .#......
+0240 a0    db         $a0        ; #.#.....
+0241 a0    db         $a0        ; #.#.....
+S242:
+0242 6b 1a MVI        VB,#$1a
+0244 a2 32 MVI        I,D232
+0246 d8 b4 SPRITE.    V8,VB,#$4
+0248 a2 3e MVI        I,D23e
+024a d9 b4 SPRITE.    V9,VB,#$4
+024c a2 02 MVI        I,D202
+024e da b4 SPRITE.    VA,VB,#$4
+0250 00 ee RTS       
+L252:
+0252 6b 1a MVI        VB,#$1a
+0254 a2 0e MVI        I,D20e
+0256 d8 b4 SPRITE.    V8,VB,#$4
+0258 a2 3e MVI        I,D23e
+025a d9 b4 SPRITE.    V9,VB,#$4
+025c a2 02 MVI        I,D202
+025e da b4 SPRITE.    VA,VB,#$4
+0260 13 dc JUMP       L3dc
+L262:
+0262 68 01 MVI        V8,#$01
+0264 69 05 MVI        V9,#$05
+0266 6a 0a MVI        VA,#$0a
+0268 6b 01 MVI        VB,#$01
+026a 65 2a MVI        V5,#$2a
+026c 66 2b MVI        V6,#$2b
+026e a2 16 MVI        I,D216
+0270 d8 b4 SPRITE.    V8,VB,#$4
+0272 a2 3e MVI        I,D23e
+0274 d9 b4 SPRITE.    V9,VB,#$4
+0276 a2 06 MVI        I,D206
+0278 36 2a SKIP.EQ    V6,#$2a
+027a a2 02 MVI        I,D202
+027c da b4 SPRITE.    VA,VB,#$4
+027e 6b 06 MVI        VB,#$06
+0280 a2 1a MVI        I,D21a
+0282 d8 b4 SPRITE.    V8,VB,#$4
+0284 a2 3e MVI        I,D23e
+0286 d9 b4 SPRITE.    V9,VB,#$4
+0288 a2 06 MVI        I,D206
+028a 45 2a SKIP.NE    V5,#$2a
+028c a2 02 MVI        I,D202
+028e da b4 SPRITE.    VA,VB,#$4
+0290 6b 0b MVI        VB,#$0b
+0292 a2 1e MVI        I,D21e
+0294 d8 b4 SPRITE.    V8,VB,#$4
+0296 a2 3e MVI        I,D23e
+0298 d9 b4 SPRITE.    V9,VB,#$4
+029a a2 06 MVI        I,D206
+029c 55 60 SKIP.EQ    V5,V6
+029e a2 02 MVI        I,D202
+02a0 da b4 SPRITE.    VA,VB,#$4
+02a2 6b 10 MVI        VB,#$10
+02a4 a2 26 MVI        I,D226
+02a6 d8 b4 SPRITE.    V8,VB,#$4
+02a8 a2 3e MVI        I,D23e
+02aa d9 b4 SPRITE.    V9,VB,#$4
+02ac a2 06 MVI        I,D206
+02ae 76 ff ADI        V6,#$ff
+02b0 46 2a SKIP.NE    V6,#$2a
+02b2 a2 02 MVI        I,D202
+02b4 da b4 SPRITE.    VA,VB,#$4
+02b6 6b 15 MVI        VB,#$15
+02b8 a2 2e MVI        I,D22e
+02ba d8 b4 SPRITE.    V8,VB,#$4
+02bc a2 3e MVI        I,D23e
+02be d9 b4 SPRITE.    V9,VB,#$4
+02c0 a2 06 MVI        I,D206
+02c2 95 60 SKIP.NE    V5,V6
+02c4 a2 02 MVI        I,D202
+02c6 da b4 SPRITE.    VA,VB,#$4
+02c8 22 42 CALL       S242
+02ca 68 17 MVI        V8,#$17
+02cc 69 1b MVI        V9,#$1b
+02ce 6a 20 MVI        VA,#$20
+02d0 6b 01 MVI        VB,#$01
+02d2 a2 0a MVI        I,D20a
+02d4 d8 b4 SPRITE.    V8,VB,#$4
+02d6 a2 36 MVI        I,D236
+02d8 d9 b4 SPRITE.    V9,VB,#$4
+02da a2 02 MVI        I,D202
+02dc da b4 SPRITE.    VA,VB,#$4
+02de 6b 06 MVI        VB,#$06
+02e0 a2 2a MVI        I,D22a
+02e2 d8 b4 SPRITE.    V8,VB,#$4
+02e4 a2 0a MVI        I,D20a
+02e6 d9 b4 SPRITE.    V9,VB,#$4
+02e8 a2 06 MVI        I,D206
+02ea 87 50 MOV        V7,V5
+02ec 47 2a SKIP.NE    V7,#$2a
+02ee a2 02 MVI        I,D202
+02f0 da b4 SPRITE.    VA,VB,#$4
+02f2 6b 0b MVI        VB,#$0b
+02f4 a2 2a MVI        I,D22a
+02f6 d8 b4 SPRITE.    V8,VB,#$4
+02f8 a2 0e MVI        I,D20e
+02fa d9 b4 SPRITE.    V9,VB,#$4
+02fc a2 06 MVI        I,D206
+02fe 67 2a MVI        V7,#$2a
+0300 87 b1 OR         V7,VB
+0302 47 2b SKIP.NE    V7,#$2b
+0304 a2 02 MVI        I,D202
+0306 da b4 SPRITE.    VA,VB,#$4
+0308 6b 10 MVI        VB,#$10
+030a a2 2a MVI        I,D22a
+030c d8 b4 SPRITE.    V8,VB,#$4
+030e a2 12 MVI        I,D212
+0310 d9 b4 SPRITE.    V9,VB,#$4
+0312 a2 06 MVI        I,D206
+0314 66 78 MVI        V6,#$78
+0316 67 1f MVI        V7,#$1f
+0318 87 62 AND        V7,V6
+031a 47 18 SKIP.NE    V7,#$18
+031c a2 02 MVI        I,D202
+031e da b4 SPRITE.    VA,VB,#$4
+0320 6b 15 MVI        VB,#$15
+0322 a2 2a MVI        I,D22a
+0324 d8 b4 SPRITE.    V8,VB,#$4
+0326 a2 16 MVI        I,D216
+0328 d9 b4 SPRITE.    V9,VB,#$4
+032a a2 06 MVI        I,D206
+032c 66 78 MVI        V6,#$78
+032e 67 1f MVI        V7,#$1f
+0330 87 63 XOR        V7,V6
+0332 47 67 SKIP.NE    V7,#$67
+0334 a2 02 MVI        I,D202
+0336 da b4 SPRITE.    VA,VB,#$4
+0338 6b 1a MVI        VB,#$1a
+033a a2 2a MVI        I,D22a
+033c d8 b4 SPRITE.    V8,VB,#$4
+033e a2 1a MVI        I,D21a
+0340 d9 b4 SPRITE.    V9,VB,#$4
+0342 a2 06 MVI        I,D206
+0344 66 8c MVI        V6,#$8c
+0346 67 8c MVI        V7,#$8c
+0348 87 64 ADD.       V7,V6
+034a 47 18 SKIP.NE    V7,#$18
+034c a2 02 MVI        I,D202
+034e da b4 SPRITE.    VA,VB,#$4
+0350 68 2c MVI        V8,#$2c
+0352 69 30 MVI        V9,#$30
+0354 6a 34 MVI        VA,#$34
+0356 6b 01 MVI        VB,#$01
+0358 a2 2a MVI        I,D22a
+035a d8 b4 SPRITE.    V8,VB,#$4
+035c a2 1e MVI        I,D21e
+035e d9 b4 SPRITE.    V9,VB,#$4
+0360 a2 06 MVI        I,D206
+0362 66 8c MVI        V6,#$8c
+0364 67 78 MVI        V7,#$78
+0366 87 65 SUB.       V7,V6
+0368 47 ec SKIP.NE    V7,#$ec
+036a a2 02 MVI        I,D202
+036c da b4 SPRITE.    VA,VB,#$4
+036e 6b 06 MVI        VB,#$06
+0370 a2 2a MVI        I,D22a
+0372 d8 b4 SPRITE.    V8,VB,#$4
+0374 a2 22 MVI        I,D222
+0376 d9 b4 SPRITE.    V9,VB,#$4
+0378 a2 06 MVI        I,D206
+037a 66 e0 MVI        V6,#$e0
+037c 86 6e SHL.       V6
+037e 46 c0 SKIP.NE    V6,#$c0
+0380 a2 02 MVI        I,D202
+0382 da b4 SPRITE.    VA,VB,#$4
+0384 6b 0b MVI        VB,#$0b
+0386 a2 2a MVI        I,D22a
+0388 d8 b4 SPRITE.    V8,VB,#$4
+038a a2 36 MVI        I,D236
+038c d9 b4 SPRITE.    V9,VB,#$4
+038e a2 06 MVI        I,D206
+0390 66 0f MVI        V6,#$0f
+0392 86 66 SHR.       V6
+0394 46 07 SKIP.NE    V6,#$07
+0396 a2 02 MVI        I,D202
+0398 da b4 SPRITE.    VA,VB,#$4
+039a 6b 10 MVI        VB,#$10
+039c a2 3a MVI        I,D23a
+039e d8 b4 SPRITE.    V8,VB,#$4
+03a0 a2 1e MVI        I,D21e
+03a2 d9 b4 SPRITE.    V9,VB,#$4
+03a4 a3 e8 MVI        I,#$3e8
+03a6 60 00 MVI        V0,#$00
+03a8 61 30 MVI        V1,#$30
+03aa f1 55 MOVM       (I),V0-V1
+03ac a3 e9 MVI        I,#$3e9
+03ae f0 65 MOVM       V0-V0,(I)
+03b0 a2 06 MVI        I,D206
+03b2 40 30 SKIP.NE    V0,#$30
+03b4 a2 02 MVI        I,D202
+03b6 da b4 SPRITE.    VA,VB,#$4
+03b8 6b 15 MVI        VB,#$15
+03ba a2 3a MVI        I,D23a
+03bc d8 b4 SPRITE.    V8,VB,#$4
+03be a2 16 MVI        I,D216
+03c0 d9 b4 SPRITE.    V9,VB,#$4
+03c2 a3 e8 MVI        I,#$3e8
+03c4 66 89 MVI        V6,#$89
+03c6 f6 33 MOVBCD     V6
+03c8 f2 65 MOVM       V0-V2,(I)
+03ca a2 02 MVI        I,D202
+03cc 30 01 SKIP.EQ    V0,#$01
+03ce a2 06 MVI        I,D206
+03d0 31 03 SKIP.EQ    V1,#$03
+03d2 a2 06 MVI        I,D206
+03d4 32 07 SKIP.EQ    V2,#$07
+03d6 a2 06 MVI        I,D206
+03d8 da b4 SPRITE.    VA,VB,#$4
+03da 12 52 JUMP       L252
+L3dc:
+03dc 13 dc JUMP       L3dc
@@ -0,0 +1,26 @@
+; Beep conformance ROM. Sounds the buzzer three times, for 6 frames every
+; 12 frames, drawing a mark on the screen for each beep, then stops.
+;
+; Assemble with: chip8 assemble beep.asm -o beep.ch8
+
+        MVI     V6,#$02         ; x of the next mark
+        MVI     V7,#$02         ; y of the marks
+        MVI     I,mark
+        MVI     V2,#$03         ; beeps left
+
+beep:   MVI     V0,#$06
+        MOV     SOUND,V0
+        SPRITE. V6,V7,#$4
+        ADI     V6,#$06
+        MVI     V0,#$0c
+        MOV     DELAY,V0
+wait:   MOV     V0,DELAY
+        SKIP.EQ V0,#$00
+        JUMP    wait
+        ADI     V2,#$ff
+        SKIP.EQ V2,#$00
+        JUMP    beep
+
+halt:   JUMP    halt
+
+mark:   db      $f0,$f0,$f0,$f0
@@ -0,0 +1,92 @@
+; Flags conformance ROM. Draws the value VF is left with by each of these,
+; as a hex digit, left to right:
+;
+;   8xy4 without and with a carry                  0 1
+;   8xy5 without and with a borrow                 1 0
+;   8xy7 without and with a borrow                 1 0
+;   8xy6 and 8xyE shifting out a 1                 1 1
+;
+; and on the row below, with VF as Vx, where the flag wins over the result:
+;
+;   8Fy4 with a carry, 8Fy5 without a borrow       1 1
+;   8Fy6 shifting out a 1                          1
+;
+; then the quirk sensitive ones:
+;
+;   8xy1 with VF set to 1                          0 when logic resets VF
+;   8xy6 with an odd Vx and even Vy                0 when it shifts Vy
+;
+; Assemble with: chip8 assemble flags.asm -o flags.ch8
+
+        MVI     V6,#$02         ; x of the next digit
+        MVI     V7,#$02         ; y of the digits
+
+        MVI     V1,#$01
+        MVI     V2,#$02
+        ADD.    V1,V2
+        CALL    flag
+        MVI     V1,#$ff
+        ADD.    V1,V2
+        CALL    flag
+
+        MVI     V1,#$05
+        MVI     V2,#$03
+        SUB.    V1,V2
+        CALL    flag
+        MVI     V1,#$03
+        MVI     V2,#$05
+        SUB.    V1,V2
+        CALL    flag
+
+        MVI     V1,#$03
+        MVI     V2,#$05
+        SUBB.   V1,V2
+        CALL    flag
+        MVI     V1,#$05
+        MVI     V2,#$03
+        SUBB.   V1,V2
+        CALL    flag
+
+        MVI     V1,#$03
+        MVI     V2,#$03
+        SHR.    V1,V2
+        CALL    flag
+        MVI     V1,#$81
+        MVI     V2,#$81
+        SHL.    V1,V2
+        CALL    flag
+
+        MVI     V6,#$02
+        MVI     V7,#$0a
+        MVI     VF,#$ff
+        MVI     V2,#$02
+        ADD.    VF,V2
+        CALL    flag
+        MVI     VF,#$05
+        MVI     V2,#$03
+        SUB.    VF,V2
+        CALL    flag
+        MVI     VF,#$03
+        MVI     V2,#$03
+        SHR.    VF,V2
+        CALL    flag
+
+        MVI     V6,#$02
+        MVI     V7,#$12
+        MVI     VF,#$01
+        MVI     V1,#$00
+        OR      V1,V2
+        CALL    flag
+        MVI     V1,#$01
+        MVI     V2,#$02
+        SHR.    V1,V2
+        CALL    flag
+
+halt:   JUMP    halt
+
+; flag draws the value of VF as a hex digit and moves along to the next one.
+flag:   MOV     V0,VF
+        SPRITECHAR V0
+        SPRITE. V6,V7,#$5
+        ADI     V6,#$06
+        RTS
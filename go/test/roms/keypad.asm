@@ -0,0 +1,40 @@
+; Keypad conformance ROM. Waits for a key with Fx0A and draws it, then
+; draws every key that Ex9E finds held down on the row below, and every key
+; ExA1 finds held down by not skipping on the row below that. Run it with
+; keys held down, all three rows show the same keys.
+;
+; Assemble with: chip8 assemble keypad.asm -o keypad.ch8
+
+        MVI     V6,#$02         ; x of the next digit
+        MVI     V7,#$02         ; y of the digits
+        WAITKEY V0
+        CALL    digit
+
+        MVI     V6,#$02
+        MVI     V7,#$0a
+        MVI     V0,#$00
+down:   SKIP.KEY V0
+        JUMP    nextd
+        CALL    digit
+nextd:  ADI     V0,#$01
+        SKIP.EQ V0,#$10
+        JUMP    down
+
+        MVI     V6,#$02
+        MVI     V7,#$12
+        MVI     V0,#$00
+up:     SKIP.NOKEY V0
+        JUMP    drawu
+        JUMP    nextu
+drawu:  CALL    digit
+nextu:  ADI     V0,#$01
+        SKIP.EQ V0,#$10
+        JUMP    up
+
+halt:   JUMP    halt
+
+; digit draws the hex digit in V0 and moves along to the next one.
+digit:  SPRITECHAR V0
+        SPRITE. V6,V7,#$5
+        ADI     V6,#$06
+        RTS
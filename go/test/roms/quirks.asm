@@ -0,0 +1,59 @@
+; Quirks conformance ROM. Draws one hex digit for each quirk, left to right:
+;
+;   shift       4 when 8xy6 shifts Vy into Vx, 2 when it shifts Vx
+;   load/store  5, 6, or 7 when Fx55 leaves I unchanged, adds x, or adds x+1
+;   jump        2 when Bnnn adds V0, 1 when it adds Vx
+;   logic       0 when 8xy1 resets VF, 5 when it leaves it alone
+;
+; then draws a bar across the right edge of the screen, which wraps around
+; onto the left edge unless sprites clip.
+;
+; Assemble with: chip8 assemble quirks.asm -o quirks.ch8
+
+        JUMP    main
+target: ADI     V3,#$01         ; $202, where Bnnn lands adding V0
+        ADI     V3,#$01         ; $204, where it lands adding V2
+        JUMP    logic
+
+main:   MVI     V6,#$02         ; x of the next digit
+        MVI     V7,#$02         ; y of the digits
+
+        MVI     V1,#$04
+        MVI     V2,#$08
+        SHR.    V1,V2
+        MOV     V0,V1
+        CALL    digit
+
+        MVI     I,data
+        MVI     V0,#$05
+        MVI     V1,#$06
+        MOVM    (I),V0-V1
+        MOVM    V0-V0,(I)
+        CALL    digit
+
+        MVI     V0,#$00
+        MVI     V2,#$02
+        MVI     V3,#$00
+        JUMP    target(V0)
+logic:  MOV     V0,V3
+        CALL    digit
+
+        MVI     VF,#$05
+        OR      V0,V1
+        MOV     V0,VF
+        CALL    digit
+
+        MVI     I,bar
+        MVI     V0,#$3c
+        MVI     V1,#$14
+        SPRITE. V0,V1,#$2
+halt:   JUMP    halt
+
+; digit draws the hex digit in V0 and moves along to the next one.
+digit:  SPRITECHAR V0
+        SPRITE. V6,V7,#$5
+        ADI     V6,#$06
+        RTS
+
+data:   db      $00,$00,$07
+bar:    db      $ff,$ff